@@ -23,36 +23,73 @@ func analyzeByDistrict(properties []Property) map[string][]Property {
 	return districtMap
 }
 
+// Annual rental yield as a percentage of the price
+func calculateROI(prop Property, monthlyRent float64) float64 {
+	if prop.Price == 0 {
+		return 0
+	}
+	return monthlyRent * 12 / prop.Price * 100
+}
+
 // District statistics struct
 type DistrictStats struct {
-	District      string
-	PropertyCount int
-	AveragePrice  float64
-	MostExpensive Property
+	District        string
+	PropertyCount   int
+	AveragePrice    float64
+	MostExpensive   Property
+	AverageROI      float64 // Over the properties that have a known rent
+	BestROI         float64
+	BestROIProperty Property
 }
 
-// Calculate district statistics
-func calculateDistrictStats(districtMap map[string][]Property) []DistrictStats {
+// Calculate district statistics; monthlyRents maps a property name to its
+// rent, and properties without one are left out of the ROI figures
+func calculateDistrictStats(districtMap map[string][]Property, monthlyRents map[string]float64) []DistrictStats {
 	var stats []DistrictStats
 
 	for district, props := range districtMap {
 		totalPrice := 0.0
 		mostExpensive := props[0]
 
+		totalROI := 0.0
+		rented := 0
+		var bestROI float64
+		var bestROIProperty Property
+
 		for _, prop := range props {
 			totalPrice += prop.Price
 			if prop.Price > mostExpensive.Price {
 				mostExpensive = prop
 			}
+
+			rent, ok := monthlyRents[prop.Name]
+			if !ok {
+				continue
+			}
+			roi := calculateROI(prop, rent)
+			totalROI += roi
+			if rented == 0 || roi > bestROI {
+				bestROI = roi
+				bestROIProperty = prop
+			}
+			rented++
 		}
 
 		avgPrice := totalPrice / float64(len(props))
 
+		avgROI := 0.0
+		if rented > 0 {
+			avgROI = totalROI / float64(rented)
+		}
+
 		stats = append(stats, DistrictStats{
-			District:      district,
-			PropertyCount: len(props),
-			AveragePrice:  avgPrice,
-			MostExpensive: mostExpensive,
+			District:        district,
+			PropertyCount:   len(props),
+			AveragePrice:    avgPrice,
+			MostExpensive:   mostExpensive,
+			AverageROI:      avgROI,
+			BestROI:         bestROI,
+			BestROIProperty: bestROIProperty,
 		})
 	}
 
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func statsByDistrict(stats []DistrictStats) map[string]DistrictStats {
+	byDistrict := make(map[string]DistrictStats)
+	for _, s := range stats {
+		byDistrict[s.District] = s
+	}
+	return byDistrict
+}
+
+func TestCalculateDistrictStatsROI(t *testing.T) {
+	stats := statsByDistrict(calculateDistrictStats(analyzeByDistrict(sampleProperties), sampleMonthlyRents))
+
+	tests := []struct {
+		district string
+		count    int
+		avgROI   float64
+		bestROI  float64
+		best     string
+	}{
+		// Saigon Apartment 12%, Luxury Penthouse 540M/5.5B = 9.82%
+		{"District 1", 2, (12 + 540.0/55) / 2, 12, "Saigon Apartment"},
+		// HCMC House 10%, Cozy Condo 12%
+		{"District 7", 2, 11, 12, "Cozy Condo"},
+		// Budget Studio 144M/800M
+		{"Binh Thanh", 1, 18, 18, "Budget Studio"},
+	}
+	if len(stats) != len(tests) {
+		t.Fatalf("got %d districts, want %d", len(stats), len(tests))
+	}
+	for _, tt := range tests {
+		s, ok := stats[tt.district]
+		if !ok {
+			t.Errorf("%s missing", tt.district)
+			continue
+		}
+		if s.PropertyCount != tt.count {
+			t.Errorf("%s: PropertyCount = %d, want %d", tt.district, s.PropertyCount, tt.count)
+		}
+		if math.Abs(s.AverageROI-tt.avgROI) > 1e-9 {
+			t.Errorf("%s: AverageROI = %.4f, want %.4f", tt.district, s.AverageROI, tt.avgROI)
+		}
+		if math.Abs(s.BestROI-tt.bestROI) > 1e-9 || s.BestROIProperty.Name != tt.best {
+			t.Errorf("%s: best = %s (%.4f), want %s (%.4f)", tt.district, s.BestROIProperty.Name, s.BestROI, tt.best, tt.bestROI)
+		}
+	}
+}
+
+func TestCalculateDistrictStatsSkipsMissingRent(t *testing.T) {
+	rents := map[string]float64{"HCMC House": 35000000}
+	s := statsByDistrict(calculateDistrictStats(analyzeByDistrict(sampleProperties), rents))
+
+	// Cozy Condo has no rent, so only HCMC House counts towards the ROI
+	d7 := s["District 7"]
+	if d7.PropertyCount != 2 || d7.AverageROI != 10 || d7.BestROIProperty.Name != "HCMC House" {
+		t.Errorf("District 7 = %+v, want 2 properties and HCMC House at 10%%", d7)
+	}
+	// No rents at all: no ROI figures, but the price stats remain
+	d1 := s["District 1"]
+	if d1.AverageROI != 0 || d1.BestROIProperty.Name != "" || d1.MostExpensive.Name != "Luxury Penthouse" {
+		t.Errorf("District 1 = %+v, want no ROI and Luxury Penthouse most expensive", d1)
+	}
+}
@@ -14,15 +14,27 @@ type Property struct {
 	District string
 }
 
+// Sample properties
+var sampleProperties = []Property{
+	{"Saigon Apartment", 2500000000, 75.5, 2, "District 1"},
+	{"HCMC House", 4200000000, 120.0, 3, "District 7"},
+	{"Budget Studio", 800000000, 35.0, 1, "Binh Thanh"},
+	{"Luxury Penthouse", 5500000000, 150.0, 3, "District 1"},
+	{"Cozy Condo", 1800000000, 60.0, 2, "District 7"},
+}
+
+// Monthly rent for each property, by name
+var sampleMonthlyRents = map[string]float64{
+	"Saigon Apartment": 25000000,
+	"HCMC House":       35000000,
+	"Budget Studio":    12000000,
+	"Luxury Penthouse": 45000000,
+	"Cozy Condo":       18000000,
+}
+
 func main() {
-	// Sample properties
-	properties := []Property{
-		{"Saigon Apartment", 2500000000, 75.5, 2, "District 1"},
-		{"HCMC House", 4200000000, 120.0, 3, "District 7"},
-		{"Budget Studio", 800000000, 35.0, 1, "Binh Thanh"},
-		{"Luxury Penthouse", 5500000000, 150.0, 3, "District 1"},
-		{"Cozy Condo", 1800000000, 60.0, 2, "District 7"},
-	}
+	properties := sampleProperties
+	monthlyRents := sampleMonthlyRents
 
 	fmt.Println("=== All Properties ===")
 	for i, prop := range properties {
@@ -52,7 +64,7 @@ func main() {
 	// TASK 2.2: District analysis
 	// District analysis
 	districtMap := analyzeByDistrict(properties)
-	stats := calculateDistrictStats(districtMap)
+	stats := calculateDistrictStats(districtMap, monthlyRents)
 
 	fmt.Println("\n=== District Analysis ===")
 	for _, stat := range stats {
@@ -61,6 +73,10 @@ func main() {
 			stat.PropertyCount,
 			formatPrice(stat.AveragePrice),
 			stat.MostExpensive.Name)
+		fmt.Printf("   Avg ROI: %.1f%%, Best ROI: %s (%.1f%%)\n",
+			stat.AverageROI,
+			stat.BestROIProperty.Name,
+			stat.BestROI)
 	}
 
 	// Sort districts by average price descending
@@ -72,4 +88,14 @@ func main() {
 	for i, stat := range stats {
 		fmt.Printf("%d. %s: %s\n", i+1, stat.District, formatPrice(stat.AveragePrice))
 	}
+
+	// Sort districts by average ROI descending
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].AverageROI > stats[j].AverageROI
+	})
+
+	fmt.Println("\n=== Ranking by Average ROI ===")
+	for i, stat := range stats {
+		fmt.Printf("%d. %s: %.1f%% (best: %s)\n", i+1, stat.District, stat.AverageROI, stat.BestROIProperty.Name)
+	}
 }
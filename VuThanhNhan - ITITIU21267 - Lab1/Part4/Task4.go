@@ -220,6 +220,76 @@ func optimizePortfolio(properties []Property, monthlyRents []float64, totalBudge
 	return portfolio
 }
 
+// Smallest price step used by the knapsack, and the most budget steps it
+// will allocate; for bigger budgets the step grows instead
+const (
+	knapsackUnit     = 10000000.0 // 10 million VND
+	knapsackMaxCells = 100000
+)
+
+// Optimal portfolio: 0/1 knapsack maximizing total annual rent within the
+// budget. Prices are rounded up and the budget down to whole units, so the
+// result never goes over budget.
+// Time O(n*C), space O(n*C), where C = budget/unit <= knapsackMaxCells.
+func optimizePortfolioOptimal(properties []Property, monthlyRents []float64, budget float64) []Property {
+	if budget <= 0 || len(properties) == 0 {
+		return nil
+	}
+
+	unit := math.Max(knapsackUnit, budget/knapsackMaxCells)
+	capacity := int(budget / unit)
+
+	// best[c] = highest annual rent using at most c units so far;
+	// take[i][c] records whether property i was used to reach best[c]
+	best := make([]float64, capacity+1)
+	take := make([][]bool, len(properties))
+
+	for i, prop := range properties {
+		take[i] = make([]bool, capacity+1)
+		cost := int(math.Ceil(prop.Price / unit))
+		rent := monthlyRents[i] * 12
+
+		// Walk capacity downwards so each property is used at most once
+		for c := capacity; c >= cost; c-- {
+			if best[c-cost]+rent > best[c] {
+				best[c] = best[c-cost] + rent
+				take[i][c] = true
+			}
+		}
+	}
+
+	// Rebuild the selection from the last property backwards
+	var portfolio []Property
+	c := capacity
+	for i := len(properties) - 1; i >= 0; i-- {
+		if take[i][c] {
+			portfolio = append(portfolio, properties[i])
+			c -= int(math.Ceil(properties[i].Price / unit))
+		}
+	}
+
+	// Keep the input order
+	for i, j := 0, len(portfolio)-1; i < j; i, j = i+1, j-1 {
+		portfolio[i], portfolio[j] = portfolio[j], portfolio[i]
+	}
+
+	return portfolio
+}
+
+// Total annual rent of a portfolio
+func portfolioAnnualRent(portfolio []Property, monthlyRents []float64, properties []Property) float64 {
+	total := 0.0
+	for _, prop := range portfolio {
+		for i, p := range properties {
+			if p.Name == prop.Name {
+				total += monthlyRents[i] * 12
+				break
+			}
+		}
+	}
+	return total
+}
+
 // Calculate portfolio stats
 func calculatePortfolioStats(portfolio []Property, monthlyRents []float64, properties []Property) (float64, float64) {
 	totalInvested := 0.0
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func names(portfolio []Property) string {
+	var out []string
+	for _, p := range portfolio {
+		out = append(out, p.Name)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestOptimalBeatsGreedy(t *testing.T) {
+	// A has the best ROI, but once greedy buys it the rest of the budget
+	// fits neither B nor C; B+C together earn more rent than A alone
+	properties := []Property{
+		{Name: "A", Price: 6000000000},
+		{Name: "B", Price: 5000000000},
+		{Name: "C", Price: 5000000000},
+	}
+	monthlyRents := []float64{60000000, 45000000, 45000000} // ROI 12%, 10.8%, 10.8%
+	budget := 10000000000.0
+
+	greedy := optimizePortfolio(properties, monthlyRents, budget)
+	optimal := optimizePortfolioOptimal(properties, monthlyRents, budget)
+
+	if got := names(greedy); got != "A" {
+		t.Errorf("greedy = %s, want A", got)
+	}
+	if got := names(optimal); got != "B,C" {
+		t.Errorf("optimal = %s, want B,C", got)
+	}
+
+	greedyRent := portfolioAnnualRent(greedy, monthlyRents, properties)
+	optimalRent := portfolioAnnualRent(optimal, monthlyRents, properties)
+	if greedyRent != 720000000 || optimalRent != 1080000000 {
+		t.Errorf("annual rent: greedy %.0f, optimal %.0f; want 720000000 and 1080000000", greedyRent, optimalRent)
+	}
+}
+
+func TestOptimalStaysWithinBudget(t *testing.T) {
+	properties := []Property{
+		{Name: "Fits", Price: 2999000000},
+		{Name: "TooBig", Price: 3001000000},
+		{Name: "Small", Price: 1000000},
+	}
+	monthlyRents := []float64{15000000, 90000000, 5000}
+
+	tests := []struct {
+		budget float64
+		want   string
+	}{
+		{3000000000, "Fits"},         // TooBig pays best but is over budget
+		{3001000000, "Fits"},         // prices round up to 10M units, so no exact fit
+		{3010000000, "TooBig"},       // TooBig's 301 units fit
+		{4000000000, "TooBig,Small"}, // both fit; Fits+TooBig would not
+		{0, ""},
+	}
+	for _, tt := range tests {
+		optimal := optimizePortfolioOptimal(properties, monthlyRents, tt.budget)
+		if got := names(optimal); got != tt.want {
+			t.Errorf("budget %.0f: optimal = %s, want %s", tt.budget, got, tt.want)
+		}
+		invested, _ := calculatePortfolioStats(optimal, monthlyRents, properties)
+		if invested > tt.budget {
+			t.Errorf("budget %.0f: invested %.0f", tt.budget, invested)
+		}
+	}
+}
@@ -55,4 +55,19 @@ func main() {
 	fmt.Printf("\nTotal Invested: %s\n", formatPrice(totalInvested))
 	fmt.Printf("Remaining Budget: %s\n", formatPrice(remaining))
 	fmt.Printf("Portfolio Average ROI: %.1f%%\n", avgROI)
+
+	// Knapsack vs greedy on the same budget
+	fmt.Println("\n=== Optimal Portfolio (knapsack) ===")
+	optimal := optimizePortfolioOptimal(properties, monthlyRents, portfolioBudget)
+
+	optimalInvested := 0.0
+	for i, prop := range optimal {
+		optimalInvested += prop.Price
+		fmt.Printf("%d. %s: %s\n", i+1, prop.Name, formatPrice(prop.Price))
+	}
+
+	greedyRent := portfolioAnnualRent(portfolio, monthlyRents, properties)
+	optimalRent := portfolioAnnualRent(optimal, monthlyRents, properties)
+	fmt.Printf("\nTotal Invested: %s\n", formatPrice(optimalInvested))
+	fmt.Printf("Annual Rent: %s (greedy: %s)\n", formatPrice(optimalRent), formatPrice(greedyRent))
 }
@@ -1,18 +1,28 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"os"
 	"sort"
+	"strings"
 )
 
 // Property struct
 type Property struct {
-	Name     string
-	Price    float64
-	Area     float64
-	Bedrooms int
-	District string
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Area     float64 `json:"area"`
+	Bedrooms int     `json:"bedrooms"`
+	District string  `json:"district"`
+}
+
+// PropertyData is the JSON file layout; MonthlyRents[i] belongs to Properties[i]
+type PropertyData struct {
+	Properties   []Property `json:"properties"`
+	MonthlyRents []float64  `json:"monthly_rents"`
 }
 
 // LoanInfo struct
@@ -22,6 +32,15 @@ type LoanInfo struct {
 	TotalInterest  float64
 }
 
+// Payment is one month of an amortization schedule
+type Payment struct {
+	Month     int
+	Amount    float64
+	Principal float64
+	Interest  float64
+	Balance   float64 // Remaining loan balance after this payment
+}
+
 // PropertyWithROI helper struct
 type PropertyWithROI struct {
 	Property Property
@@ -39,6 +58,66 @@ func formatPrice(price float64) string {
 	return fmt.Sprintf("%.0f triệu VND", millions)
 }
 
+// readLine reads one line from stdin, unbuffered like fmt.Scanln so the
+// two can be mixed (names and districts contain spaces)
+func readLine(prompt string) string {
+	fmt.Print(prompt)
+	var sb strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if n == 0 || err != nil || buf[0] == '\n' {
+			break
+		}
+		sb.WriteByte(buf[0])
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ============= PERSISTENCE =============
+
+// saveProperties writes the properties and their rents to a JSON file
+func saveProperties(path string, properties []Property, monthlyRents []float64) error {
+	data, err := json.MarshalIndent(PropertyData{
+		Properties:   properties,
+		MonthlyRents: monthlyRents,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// loadProperties reads a file written by saveProperties. A missing file
+// returns an error wrapping os.ErrNotExist.
+func loadProperties(path string) ([]Property, []float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pd PropertyData
+	if err := json.Unmarshal(data, &pd); err != nil {
+		return nil, nil, fmt.Errorf("invalid property file: %v", err)
+	}
+
+	// Every menu indexes monthlyRents with the property index
+	if len(pd.Properties) != len(pd.MonthlyRents) {
+		return nil, nil, fmt.Errorf("%d properties but %d monthly rents",
+			len(pd.Properties), len(pd.MonthlyRents))
+	}
+	if len(pd.Properties) == 0 {
+		return nil, nil, errors.New("no properties in file")
+	}
+	for i, prop := range pd.Properties {
+		if prop.Price <= 0 || pd.MonthlyRents[i] < 0 {
+			return nil, nil, fmt.Errorf("invalid price or rent for %q", prop.Name)
+		}
+	}
+
+	return pd.Properties, pd.MonthlyRents, nil
+}
+
 // ============= PROPERTY METHODS =============
 
 func (p Property) PricePerM2() float64 {
@@ -99,6 +178,45 @@ func (p Property) CalculateLoan(downPaymentPercent, interestRate float64, years
 	}
 }
 
+// AmortizationSchedule splits every monthly payment into principal and
+// interest. The last payment pays off whatever balance is left so rounding
+// never leaves a few VND owing; at 0% every payment is equal principal.
+func (p Property) AmortizationSchedule(downPaymentPercent, interestRate float64, years int) []Payment {
+	if years <= 0 {
+		return nil
+	}
+
+	balance := p.Price - p.Price*(downPaymentPercent/100)
+	monthlyRate := interestRate / 100 / 12
+	monthlyPayment := calculateMonthlyPayment(balance, interestRate, years)
+	numPayments := years * 12
+
+	schedule := make([]Payment, 0, numPayments)
+	for month := 1; month <= numPayments; month++ {
+		interest := balance * monthlyRate
+		principal := monthlyPayment - interest
+		if month == numPayments {
+			principal = balance
+		}
+		balance -= principal
+
+		schedule = append(schedule, Payment{
+			Month:     month,
+			Amount:    principal + interest,
+			Principal: principal,
+			Interest:  interest,
+			Balance:   balance,
+		})
+	}
+
+	// Drop floating point noise from the final balance
+	if math.Abs(schedule[len(schedule)-1].Balance) < 0.01 {
+		schedule[len(schedule)-1].Balance = 0
+	}
+
+	return schedule
+}
+
 // ============= MENU FUNCTIONS =============
 
 func viewAllProperties(properties []Property) {
@@ -334,3 +452,84 @@ func optimizePortfolio(properties []Property, monthlyRents []float64, totalBudge
 
 	return portfolio
 }
+
+func addPropertyMenu(properties []Property, monthlyRents []float64) ([]Property, []float64) {
+	fmt.Println("\n=== Add New Property ===")
+
+	var prop Property
+	var rent float64
+
+	prop.Name = readLine("Name: ")
+	fmt.Print("Price (VND): ")
+	fmt.Scanln(&prop.Price)
+	fmt.Print("Area (m²): ")
+	fmt.Scanln(&prop.Area)
+	fmt.Print("Bedrooms: ")
+	fmt.Scanln(&prop.Bedrooms)
+	prop.District = readLine("District: ")
+	fmt.Print("Monthly rent (VND): ")
+	fmt.Scanln(&rent)
+
+	if prop.Name == "" || prop.Price <= 0 || prop.Area <= 0 || prop.Bedrooms < 0 || rent < 0 {
+		fmt.Println("❌ Invalid property, nothing added.")
+		return properties, monthlyRents
+	}
+
+	// Append to both slices so the indexes stay aligned
+	properties = append(properties, prop)
+	monthlyRents = append(monthlyRents, rent)
+	fmt.Printf("✓ Added %s (%s)\n", prop.Name, formatPrice(prop.Price))
+
+	return properties, monthlyRents
+}
+
+func amortizationMenu(properties []Property) {
+	fmt.Println("\n=== Amortization Schedule ===")
+	for i, prop := range properties {
+		fmt.Printf("%d. %s (%s)\n", i+1, prop.Name, formatPrice(prop.Price))
+	}
+
+	var index int
+	var downPayment, interestRate float64
+	var years int
+
+	fmt.Print("Choose property: ")
+	fmt.Scanln(&index)
+	if index < 1 || index > len(properties) {
+		fmt.Println("❌ Invalid property number.")
+		return
+	}
+	fmt.Print("Down payment percentage (e.g., 20 for 20%): ")
+	fmt.Scanln(&downPayment)
+	fmt.Print("Annual interest rate (e.g., 8.5 for 8.5%): ")
+	fmt.Scanln(&interestRate)
+	fmt.Print("Loan term in years: ")
+	fmt.Scanln(&years)
+
+	prop := properties[index-1]
+	schedule := prop.AmortizationSchedule(downPayment, interestRate, years)
+	if len(schedule) == 0 {
+		fmt.Println("❌ Loan term must be at least 1 year.")
+		return
+	}
+
+	printRow := func(p Payment) {
+		fmt.Printf("%5d  %18.0f  %18.0f  %18.0f  %18.0f\n",
+			p.Month, p.Amount, p.Principal, p.Interest, p.Balance)
+	}
+
+	fmt.Printf("\n%s - %d payments (VND)\n", prop.Name, len(schedule))
+	fmt.Printf("%5s  %18s  %18s  %18s  %18s\n", "Month", "Payment", "Principal", "Interest", "Balance")
+	for i, p := range schedule {
+		if i == 12 {
+			break
+		}
+		printRow(p)
+	}
+	if len(schedule) > 12 {
+		if len(schedule) > 13 {
+			fmt.Printf("%5s\n", "...")
+		}
+		printRow(schedule[len(schedule)-1])
+	}
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
 )
 
+// Saved properties are read from here on startup
+const propertiesFile = "properties.json"
+
 func main() {
-	// Property data
+	// Built-in property data, used when there is no saved file
 	properties := []Property{
 		{"Saigon Apartment", 2500000000, 75.5, 2, "District 1"},
 		{"HCMC House", 4200000000, 120.0, 3, "District 7"},
@@ -17,6 +22,13 @@ func main() {
 	// Monthly rents for each property
 	monthlyRents := []float64{25000000, 35000000, 12000000, 18000000, 45000000}
 
+	if loaded, rents, err := loadProperties(propertiesFile); err == nil {
+		properties, monthlyRents = loaded, rents
+		fmt.Printf("Loaded %d properties from %s\n", len(properties), propertiesFile)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("⚠️ Could not load %s (%v), using defaults\n", propertiesFile, err)
+	}
+
 	// Main menu loop
 	for {
 		fmt.Println("\n=== Property Analyzer Menu ===")
@@ -26,6 +38,10 @@ func main() {
 		fmt.Println("4. Loan calculator")
 		fmt.Println("5. Get recommendations")
 		fmt.Println("6. Optimize portfolio")
+		fmt.Println("7. Add new property")
+		fmt.Println("8. Save properties")
+		fmt.Println("9. Load saved properties")
+		fmt.Println("10. Amortization schedule")
 		fmt.Println("0. Exit")
 		fmt.Print("\nChoose option: ")
 
@@ -51,13 +67,35 @@ func main() {
 		case 6:
 			optimizePortfolioMenu(properties, monthlyRents)
 
+		case 7:
+			properties, monthlyRents = addPropertyMenu(properties, monthlyRents)
+
+		case 8:
+			if err := saveProperties(propertiesFile, properties, monthlyRents); err != nil {
+				fmt.Println("\n❌ Save failed:", err)
+			} else {
+				fmt.Printf("\n✓ Saved %d properties to %s\n", len(properties), propertiesFile)
+			}
+
+		case 9:
+			loaded, rents, err := loadProperties(propertiesFile)
+			if err != nil {
+				fmt.Println("\n❌ Load failed:", err)
+			} else {
+				properties, monthlyRents = loaded, rents
+				fmt.Printf("\n✓ Loaded %d properties from %s\n", len(properties), propertiesFile)
+			}
+
+		case 10:
+			amortizationMenu(properties)
+
 		case 0:
 			fmt.Println("\n👋 Thank you for using Property Analyzer!")
 			fmt.Println("Goodbye!")
 			return
 
 		default:
-			fmt.Println("\n❌ Invalid option! Please choose 0-6.")
+			fmt.Println("\n❌ Invalid option! Please choose 0-10.")
 		}
 
 		fmt.Print("\nPress Enter to continue...")
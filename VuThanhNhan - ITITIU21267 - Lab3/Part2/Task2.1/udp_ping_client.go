@@ -1,44 +1,59 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+// Largest UDP payload, so padded replies are never truncated
+const maxPacketSize = 65507
+
+// Extra bytes appended to every PING to measure RTT with larger packets
+var payloadSize = flag.Int("payload", 0, "padding bytes added to each PING")
+
 // PingResult stores the ping result for each server
 type PingResult struct {
 	ServerAddr string        // Server address (e.g., localhost:9001)
+	Seq        int           // Sequence number sent in the PING
 	RTT        time.Duration // Round-Trip Time
 	Success    bool          // Ping succeeded or timed out
 }
 
-// pingOnce - Ping a server once and measure RTT
-func pingOnce(serverAddr string, timeout time.Duration) PingResult {
+// dialServer - Resolve the server address and create a UDP connection
+func dialServer(serverAddr string) (*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", serverAddr)
+	if err != nil {
+		return nil, err
+	}
+	return net.DialUDP("udp", nil, addr)
+}
+
+// pingSeq - Send "PING <seq> [padding]" on conn and wait for the matching
+// "PONG <seq>". Replies carrying another sequence number (late answers to
+// an earlier ping, or duplicates) are reported and skipped.
+func pingSeq(conn *net.UDPConn, serverAddr string, seq int, timeout time.Duration) PingResult {
 	result := PingResult{
 		ServerAddr: serverAddr,
+		Seq:        seq,
 		Success:    false,
 	}
 
-	// Resolve server address
-	addr, err := net.ResolveUDPAddr("udp", serverAddr)
-	if err != nil {
-		return result
+	message := fmt.Sprintf("PING %d", seq)
+	if *payloadSize > 0 {
+		message += " " + strings.Repeat("x", *payloadSize)
 	}
 
-	// Create UDP connection
-	conn, err := net.DialUDP("udp", nil, addr)
-	if err != nil {
-		return result
-	}
-	defer conn.Close()
-
 	// Start measuring time
 	startTime := time.Now()
 
 	// Send PING message
-	_, err = conn.Write([]byte("PING"))
+	_, err := conn.Write([]byte(message))
 	if err != nil {
 		return result
 	}
@@ -46,34 +61,160 @@ func pingOnce(serverAddr string, timeout time.Duration) PingResult {
 	// Set read timeout for response
 	conn.SetReadDeadline(time.Now().Add(timeout))
 
-	// Wait for PONG response
-	buffer := make([]byte, 1024)
-	n, err := conn.Read(buffer)
-	if err != nil {
-		// Timeout or other error
+	// Wait for the PONG with our sequence number
+	buffer := make([]byte, maxPacketSize)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			// Timeout or other error
+			return result
+		}
+
+		// Expected format: PONG <seq> <serverID> <timestamp> [padding]
+		fields := strings.Fields(string(buffer[:n]))
+		if len(fields) < 2 || fields[0] != "PONG" {
+			continue
+		}
+
+		replySeq, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		if replySeq != seq {
+			kind := "late"
+			if replySeq > seq {
+				kind = "unexpected"
+			}
+			fmt.Printf("%s: %s reply seq=%d (waiting for seq=%d)\n", serverAddr, kind, replySeq, seq)
+			continue
+		}
+
+		// Calculate RTT (Round-Trip Time)
+		result.RTT = time.Since(startTime)
+		result.Success = true
 		return result
 	}
+}
 
-	// Calculate RTT (Round-Trip Time)
-	rtt := time.Since(startTime)
+// PingStats aggregates several pings to one server
+type PingStats struct {
+	ServerAddr string
+	Sent       int
+	Received   int
+	MinRTT     time.Duration
+	AvgRTT     time.Duration
+	MaxRTT     time.Duration
+	LossPct    float64 // Percentage of pings that timed out
+}
 
-	// Check if response starts with "PONG"
-	response := string(buffer[:n])
-	if len(response) >= 4 && response[:4] == "PONG" {
-		result.RTT = rtt
-		result.Success = true
+// pingAll - Ping every server count times; servers are pinged concurrently
+// (one goroutine each) and the pings to one server run sequentially
+func pingAll(servers []string, count int, timeout time.Duration) map[string]PingStats {
+	results := make(map[string]PingStats)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, serverAddr := range servers {
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+
+			stats := PingStats{ServerAddr: addr, Sent: count}
+			var totalRTT time.Duration
+
+			// One socket for all pings, so a reply that arrives after its
+			// timeout shows up as a late sequence number
+			conn, err := dialServer(addr)
+			if err == nil {
+				defer conn.Close()
+			}
+
+			for i := 0; err == nil && i < count; i++ {
+				result := pingSeq(conn, addr, i+1, timeout)
+				if !result.Success {
+					continue
+				}
+				if stats.Received == 0 || result.RTT < stats.MinRTT {
+					stats.MinRTT = result.RTT
+				}
+				if result.RTT > stats.MaxRTT {
+					stats.MaxRTT = result.RTT
+				}
+				totalRTT += result.RTT
+				stats.Received++
+			}
+
+			if stats.Received > 0 {
+				stats.AvgRTT = totalRTT / time.Duration(stats.Received)
+			}
+			if count > 0 {
+				stats.LossPct = float64(count-stats.Received) / float64(count) * 100
+			}
+
+			mu.Lock()
+			results[addr] = stats
+			mu.Unlock()
+		}(serverAddr)
 	}
 
-	return result
+	wg.Wait()
+	return results
+}
+
+// printPingSummary - Display the stats sorted by average RTT; servers
+// that never answered go last
+func printPingSummary(results map[string]PingStats) {
+	stats := make([]PingStats, 0, len(results))
+	for _, s := range results {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if (stats[i].Received == 0) != (stats[j].Received == 0) {
+			return stats[j].Received == 0
+		}
+		if stats[i].AvgRTT != stats[j].AvgRTT {
+			return stats[i].AvgRTT < stats[j].AvgRTT
+		}
+		return stats[i].ServerAddr < stats[j].ServerAddr
+	})
+
+	ms := func(d time.Duration) string {
+		return fmt.Sprintf("%.1fms", float64(d.Microseconds())/1000.0)
+	}
+
+	fmt.Println("\nServer                  Min       Avg       Max       Loss")
+	fmt.Println("--------------------------------------------------------------")
+	for _, s := range stats {
+		if s.Received == 0 {
+			fmt.Printf("%-23s %-9s %-9s %-9s %.0f%%\n", s.ServerAddr, "-", "-", "-", s.LossPct)
+			continue
+		}
+		fmt.Printf("%-23s %-9s %-9s %-9s %.0f%%\n", s.ServerAddr, ms(s.MinRTT), ms(s.AvgRTT), ms(s.MaxRTT), s.LossPct)
+	}
 }
 
 // pingMonitor - Ping all servers and display results
 func pingMonitor(servers []string) {
 	timeout := 1 * time.Second
 
-	for {
+	// One socket per server across rounds, as in pingAll, so a reply that
+	// misses its timeout is reported as late in the next round instead of
+	// being lost with a closed socket
+	conns := make(map[string]*net.UDPConn)
+
+	for seq := 1; ; seq++ {
 		fmt.Println("\nPinging servers...")
 
+		// Dial servers that have no socket yet; a failed dial is retried
+		// next round
+		for _, serverAddr := range servers {
+			if conns[serverAddr] == nil {
+				if conn, err := dialServer(serverAddr); err == nil {
+					conns[serverAddr] = conn
+				}
+			}
+		}
+
 		// Channel to receive results from goroutines
 		resultsChan := make(chan PingResult, len(servers))
 		var wg sync.WaitGroup
@@ -81,11 +222,14 @@ func pingMonitor(servers []string) {
 		// Ping all servers concurrently
 		for _, serverAddr := range servers {
 			wg.Add(1)
-			go func(addr string) {
+			go func(addr string, conn *net.UDPConn) {
 				defer wg.Done()
-				result := pingOnce(addr, timeout)
-				resultsChan <- result
-			}(serverAddr)
+				if conn == nil {
+					resultsChan <- PingResult{ServerAddr: addr, Seq: seq}
+					return
+				}
+				resultsChan <- pingSeq(conn, addr, seq, timeout)
+			}(serverAddr, conns[serverAddr])
 		}
 
 		// Wait for all goroutines to finish
@@ -137,7 +281,16 @@ func pingMonitor(servers []string) {
 }
 
 func main() {
+	flag.Parse()
 	fmt.Println("=== UDP Ping Monitor ===\n")
+	// Leave room for the PING/PONG header in front of the padding
+	if *payloadSize < 0 || *payloadSize > maxPacketSize-64 {
+		fmt.Printf("Payload must be between 0 and %d bytes\n", maxPacketSize-64)
+		return
+	}
+	if *payloadSize > 0 {
+		fmt.Printf("Payload: %d bytes of padding\n", *payloadSize)
+	}
 
 	// Server configuration
 	servers := []string{
@@ -146,6 +299,10 @@ func main() {
 		"localhost:9003",
 	}
 
+	// Summary report over several pings per server
+	fmt.Println("Summary of 5 pings per server:")
+	printPingSummary(pingAll(servers, 5, 1*time.Second))
+
 	// Start monitoring
 	pingMonitor(servers)
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"net"
+	"strings"
 	"time"
 )
 
@@ -25,7 +26,8 @@ func pingServer(port int, serverID string) {
 
 	fmt.Printf("Ping server [%s] started on port %d\n", serverID, port)
 
-	buffer := make([]byte, 1024)
+	// Large enough for padded pings (max UDP payload)
+	buffer := make([]byte, 65507)
 
 	// Infinite loop waiting for ping requests
 	for {
@@ -36,12 +38,20 @@ func pingServer(port int, serverID string) {
 			continue
 		}
 
-		message := string(buffer[:n]) // "PING"
+		message := string(buffer[:n]) // "PING" or "PING <seq> [padding]"
+		fields := strings.SplitN(message, " ", 3)
 
 		// Check if it is a PING request
-		if message == "PING" {
-			// Create PONG response with timestamp
+		if fields[0] == "PING" {
+			// Create PONG response with timestamp; a sequence number and
+			// padding are echoed back so the client can match the reply
 			pongMsg := fmt.Sprintf("PONG %s %d", serverID, time.Now().Unix())
+			if len(fields) >= 2 {
+				pongMsg = fmt.Sprintf("PONG %s %s %d", fields[1], serverID, time.Now().Unix())
+			}
+			if len(fields) == 3 {
+				pongMsg += " " + fields[2]
+			}
 
 			// Send PONG back to client
 			_, err = conn.WriteToUDP([]byte(pongMsg), clientAddr)
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,12 +19,20 @@ type Message struct {
 
 type UserStatus struct {
 	Username string
-	Status   string // "online", "typing", "away"
+	Status   string // "online", "typing", "away", "offline"
 	LastSeen time.Time
 }
 
+// Clients send a heartbeat every 5 seconds, so 3 missed beats means the
+// client is gone
+const (
+	statusTimeout = 15 * time.Second
+	sweepInterval = 5 * time.Second
+)
+
 type HybridChatServer struct {
 	tcpClients     map[net.Conn]string
+	tcpUsers       map[string]net.Conn // reverse of tcpClients for /pm
 	messageHistory []Message
 	userStatuses   map[string]*UserStatus
 	mu             sync.RWMutex
@@ -33,6 +43,7 @@ type HybridChatServer struct {
 func NewHybridChatServer() *HybridChatServer {
 	return &HybridChatServer{
 		tcpClients:     make(map[net.Conn]string),
+		tcpUsers:       make(map[string]net.Conn),
 		messageHistory: make([]Message, 0, 100),
 		userStatuses:   make(map[string]*UserStatus),
 		clientAddrs:    make(map[string]*net.UDPAddr),
@@ -97,6 +108,80 @@ func (s *HybridChatServer) broadcastStatus(username, status string) {
 	}
 }
 
+// Periodically mark users whose LastSeen is too old as offline
+func (s *HybridChatServer) expireStatuses() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		var expired []string
+
+		s.mu.Lock()
+		for username, st := range s.userStatuses {
+			if st.Status != "offline" && time.Since(st.LastSeen) > statusTimeout {
+				st.Status = "offline"
+				expired = append(expired, username)
+			}
+		}
+		s.mu.Unlock()
+
+		// broadcastStatus takes the lock itself
+		for _, username := range expired {
+			fmt.Printf("[UDP] %s: offline (no heartbeat)\n", username)
+			s.broadcastStatus(username, "offline")
+		}
+	}
+}
+
+// Send a private message to one TCP client and echo it to the sender
+func (s *HybridChatServer) sendPrivateMessage(from string, fromConn net.Conn, to, content string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	target, ok := s.tcpUsers[to]
+	if !ok {
+		fromConn.Write([]byte(fmt.Sprintf("*** User %s is not connected ***\n", to)))
+		return
+	}
+
+	timestamp := time.Now().Format("15:04:05")
+	target.Write([]byte(fmt.Sprintf("[%s] [PM from %s]: %s\n", timestamp, from, content)))
+	if target != fromConn {
+		fromConn.Write([]byte(fmt.Sprintf("[%s] [PM to %s]: %s\n", timestamp, to, content)))
+	}
+}
+
+// Send the last count messages from history to one client
+// (count <= 0 sends the whole history)
+func (s *HybridChatServer) sendHistory(conn net.Conn, count int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.messageHistory
+	if count > 0 && count < len(history) {
+		history = history[len(history)-count:]
+	}
+
+	for _, msg := range history {
+		timestamp := msg.Timestamp.Format("15:04:05")
+		formatted := fmt.Sprintf("[%s] %s: %s\n", timestamp, msg.Username, msg.Content)
+		conn.Write([]byte(formatted))
+	}
+}
+
+// Send the usernames of all connected TCP clients to one client
+func (s *HybridChatServer) sendUserList(conn net.Conn) {
+	s.mu.RLock()
+	users := make([]string, 0, len(s.tcpUsers))
+	for username := range s.tcpUsers {
+		users = append(users, username)
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(users)
+	conn.Write([]byte(fmt.Sprintf("*** Online (%d): %s ***\n", len(users), strings.Join(users, ", "))))
+}
+
 // Handle a TCP client connection
 func (s *HybridChatServer) handleTCPClient(conn net.Conn) {
 	defer conn.Close()
@@ -114,19 +199,14 @@ func (s *HybridChatServer) handleTCPClient(conn net.Conn) {
 	// Save client
 	s.mu.Lock()
 	s.tcpClients[conn] = username
+	s.tcpUsers[username] = conn
 	s.mu.Unlock()
 
 	fmt.Printf("%s connected via TCP\n", username)
 	conn.Write([]byte("*** Joined chat room ***\n"))
 
 	// Send message history
-	s.mu.RLock()
-	for _, msg := range s.messageHistory {
-		timestamp := msg.Timestamp.Format("15:04:05")
-		formatted := fmt.Sprintf("[%s] %s: %s\n", timestamp, msg.Username, msg.Content)
-		conn.Write([]byte(formatted))
-	}
-	s.mu.RUnlock()
+	s.sendHistory(conn, 0)
 
 	// Read messages from client
 	for {
@@ -140,9 +220,32 @@ func (s *HybridChatServer) handleTCPClient(conn net.Conn) {
 			continue
 		}
 
-		// Handle special commands
-		if strings.HasPrefix(message, "HISTORY:") {
-			// Message history already sent on connection
+		// Handle special commands; replies go only to this client
+		if message == "/list" {
+			s.sendUserList(conn)
+			continue
+		}
+
+		if strings.HasPrefix(message, "/history") || strings.HasPrefix(message, "HISTORY:") {
+			arg := strings.TrimPrefix(strings.TrimPrefix(message, "/history"), "HISTORY:")
+			count, err := strconv.Atoi(strings.TrimSpace(arg))
+			if err != nil || count <= 0 {
+				conn.Write([]byte("*** Usage: /history <count> ***\n"))
+				continue
+			}
+			s.sendHistory(conn, count)
+			continue
+		}
+
+		// Private message: /pm <username> <message>
+		if strings.HasPrefix(message, "/pm ") || message == "/pm" {
+			parts := strings.SplitN(message, " ", 3)
+			if len(parts) < 3 || strings.TrimSpace(parts[2]) == "" {
+				conn.Write([]byte("*** Usage: /pm <username> <message> ***\n"))
+				continue
+			}
+			fmt.Printf("[TCP] %s -> %s: %s\n", username, parts[1], parts[2])
+			s.sendPrivateMessage(username, conn, parts[1], strings.TrimSpace(parts[2]))
 			continue
 		}
 
@@ -161,6 +264,10 @@ func (s *HybridChatServer) handleTCPClient(conn net.Conn) {
 	// Remove client on disconnect
 	s.mu.Lock()
 	delete(s.tcpClients, conn)
+	// A newer connection may have taken over the name
+	if s.tcpUsers[username] == conn {
+		delete(s.tcpUsers, username)
+	}
 	s.mu.Unlock()
 
 	fmt.Printf("%s disconnected\n", username)
@@ -244,6 +351,7 @@ func main() {
 	// Start both servers
 	go server.startTCPServer()
 	go server.startUDPServer()
+	go server.expireStatuses()
 
 	// Keep server running
 	select {}
@@ -3,12 +3,15 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -19,11 +22,21 @@ type Book struct {
 	Rating       string `json:"rating"`
 	Availability string `json:"availability"`
 	ImageURL     string `json:"image_url"`
+	URL          string `json:"url,omitempty"`
+}
+
+// Listing data plus fields only found on the product page
+type BookDetail struct {
+	Book
+	Description string `json:"description"`
+	UPC         string `json:"upc"`
+	ProductType string `json:"product_type"`
+	NumReviews  int    `json:"num_reviews"`
 }
 
 // Scrape all book data from a page
-func scrapeBooks(url string) ([]Book, error) {
-	resp, err := http.Get(url) 
+func scrapeBooks(pageURL string) ([]Book, error) {
+	resp, err := http.Get(pageURL) 
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch page: %w", err) 
 	}
@@ -52,7 +65,8 @@ func scrapeBooks(url string) ([]Book, error) {
 			for _, attr := range n.Attr {
 				if attr.Key == "class" && strings.Contains(attr.Val, "product_pod") {
 					book := extractBookData(n)
-					books = append(books, book) // add book to list
+					book.URL = resolveURL(pageURL, book.URL) // product link is relative
+					books = append(books, book)              // add book to list
 					break
 				}
 			}
@@ -81,6 +95,9 @@ func extractBookData(n *html.Node) Book {
 							if attr.Key == "title" {
 								book.Title = strings.TrimSpace(attr.Val)
 							}
+							if attr.Key == "href" {
+								book.URL = attr.Val
+							}
 						}
 					}
 				}
@@ -121,6 +138,110 @@ func extractBookData(n *html.Node) Book {
 	return book
 }
 
+// Resolve a link found on pageURL to an absolute URL
+func resolveURL(pageURL, link string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil || link == "" {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// Scrape description, UPC, product type and review count from a product page
+func scrapeBookDetail(bookURL string) (BookDetail, error) {
+	var detail BookDetail
+
+	resp, err := http.Get(bookURL)
+	if err != nil {
+		return detail, fmt.Errorf("failed to fetch detail page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return detail, fmt.Errorf("bad status code: %d", resp.StatusCode)
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return detail, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "div":
+				// <div id="product_description"> is followed by the description <p>
+				for _, attr := range n.Attr {
+					if attr.Key == "id" && attr.Val == "product_description" {
+						if p := nextElement(n); p != nil && p.Data == "p" {
+							detail.Description = extractText(p)
+						}
+					}
+				}
+			case "tr":
+				// Product information table: <tr><th>UPC</th><td>...</td></tr>
+				var key, value string
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					if c.Type == html.ElementNode && c.Data == "th" {
+						key = extractText(c)
+					}
+					if c.Type == html.ElementNode && c.Data == "td" {
+						value = extractText(c)
+					}
+				}
+				switch key {
+				case "UPC":
+					detail.UPC = value
+				case "Product Type":
+					detail.ProductType = value
+				case "Number of reviews":
+					detail.NumReviews, _ = strconv.Atoi(value)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return detail, nil
+}
+
+// Next sibling that is an element, skipping whitespace text nodes
+func nextElement(n *html.Node) *html.Node {
+	for s := n.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+// Follow each book's product link, waiting delay between requests
+func enrichBooks(books []Book, delay time.Duration) []BookDetail {
+	var details []BookDetail
+	for i, b := range books {
+		if i > 0 {
+			time.Sleep(delay) // rate limit
+		}
+		fmt.Printf("[%d/%d] Fetching details: %s\n", i+1, len(books), b.Title)
+
+		detail, err := scrapeBookDetail(b.URL)
+		if err != nil {
+			fmt.Printf("  Error: %v\n", err)
+		}
+		detail.Book = b // keep listing data even if the detail page failed
+		details = append(details, detail)
+	}
+	return details
+}
+
 // Extract text content (trimmed)
 func extractText(n *html.Node) string {
 	if n.Type == html.TextNode { 
@@ -150,6 +271,10 @@ func priceToFloat(price string) float64 {
 }
 
 func main() {
+	withDetails := flag.Bool("details", false, "also scrape each book's product page")
+	detailDelay := flag.Duration("detail-delay", time.Second, "pause between product page requests")
+	flag.Parse()
+
 	url := "http://books.toscrape.com/catalogue/page-1.html"
 	fmt.Printf("Scraping books from: %s\n", url)
 
@@ -188,4 +313,20 @@ func main() {
 	}
 
 	fmt.Printf("Saved %d books to books.json\n", len(books))
+
+	if *withDetails {
+		fmt.Println("\nScraping book details...")
+		details := enrichBooks(books, *detailDelay)
+
+		data, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			fmt.Printf("Failed to marshal details: %v\n", err)
+			return
+		}
+		if err := os.WriteFile("books_detailed.json", data, 0644); err != nil {
+			fmt.Printf("Failed to save details: %v\n", err)
+			return
+		}
+		fmt.Printf("Saved %d detailed books to books_detailed.json\n", len(details))
+	}
 }
@@ -2,16 +2,32 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 )
 
 const TMDBBaseURL = "https://api.themoviedb.org/3"
 
+// How long search results stay cached by default
+const defaultCacheTTL = 10 * time.Minute
+
+// Pause between page requests to stay under the TMDB rate limit
+const pageDelay = 250 * time.Millisecond
+
+// Retry policy for transient failures (network errors, 429, 5xx)
+const (
+	maxRetries  = 3
+	baseBackoff = 1 * time.Second
+)
+
 type TMDBSearchResponse struct {
 	Page    int `json:"page"`
 	Results []struct {
@@ -24,6 +40,7 @@ type TMDBSearchResponse struct {
 		PosterPath  string  `json:"poster_path"`
 	} `json:"results"`
 	TotalResults int `json:"total_results"`
+	TotalPages   int `json:"total_pages"`
 }
 
 type Movie struct {
@@ -41,34 +58,171 @@ type TMDBClient struct {
 	BaseURL    string
 	HTTPClient *http.Client
 	GenreMap   map[int]string
+	CacheTTL   time.Duration // 0 disables the search cache
+
+	cacheMu sync.Mutex
+	cache   map[string]cacheEntry // keyed by request URL
+}
+
+type cacheEntry struct {
+	movies     []Movie
+	totalPages int
+	expiresAt  time.Time
+}
+
+// Client settings; BaseURL can point at a mock server in tests
+type Config struct {
+	APIKey  string
+	BaseURL string
+	Timeout time.Duration
+}
+
+// API key from -apikey or TMDB_API_KEY (flag wins)
+func loadConfig() (Config, error) {
+	apiKey := flag.String("apikey", "", "TMDB API key (overrides TMDB_API_KEY)")
+	flag.Parse()
+
+	cfg := Config{
+		APIKey:  os.Getenv("TMDB_API_KEY"),
+		BaseURL: TMDBBaseURL,
+		Timeout: 15 * time.Second,
+	}
+	if *apiKey != "" {
+		cfg.APIKey = *apiKey
+	}
+	if cfg.APIKey == "" {
+		return cfg, fmt.Errorf("TMDB API key missing: set TMDB_API_KEY or pass -apikey")
+	}
+	return cfg, nil
 }
 
 // Create new client
-func NewTMDBClient(apiKey string) *TMDBClient {
+func NewTMDBClient(cfg Config) *TMDBClient {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = TMDBBaseURL
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 15 * time.Second
+	}
 	return &TMDBClient{
-		APIKey:  apiKey,
-		BaseURL: TMDBBaseURL,
+		APIKey:  cfg.APIKey,
+		BaseURL: cfg.BaseURL,
 		HTTPClient: &http.Client{
-			Timeout: 15 * time.Second,
+			Timeout: cfg.Timeout,
 		},
 		GenreMap: make(map[int]string),
+		CacheTTL: defaultCacheTTL,
+		cache:    make(map[string]cacheEntry),
+	}
+}
+
+// Cached results for endpoint, if present and not expired
+func (c *TMDBClient) cachedMovies(endpoint string) (cacheEntry, bool) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	entry, ok := c.cache[endpoint]
+	if !ok || c.CacheTTL <= 0 {
+		return cacheEntry{}, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.cache, endpoint)
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *TMDBClient) storeMovies(endpoint string, movies []Movie, totalPages int) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if c.CacheTTL <= 0 {
+		return
 	}
+	c.cache[endpoint] = cacheEntry{movies: movies, totalPages: totalPages, expiresAt: time.Now().Add(c.CacheTTL)}
+}
+
+// Drop all cached responses
+func (c *TMDBClient) ClearCache() {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	c.cache = make(map[string]cacheEntry)
+}
+
+// Turn caching off, e.g. for tests that count requests
+func (c *TMDBClient) DisableCache() {
+	c.ClearCache()
+	c.cacheMu.Lock()
+	c.CacheTTL = 0
+	c.cacheMu.Unlock()
+}
+
+// GET endpoint, retrying network errors, 429 and 5xx with exponential
+// backoff. Returns only 200 responses; the caller closes the body.
+func (c *TMDBClient) doRequest(endpoint string) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		var wait time.Duration
+
+		resp, err := c.HTTPClient.Get(endpoint)
+		switch {
+		case err != nil:
+			lastErr = err
+		case resp.StatusCode == http.StatusOK:
+			return resp, nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("TMDB error (%d): %s", resp.StatusCode, body)
+			wait = parseRetryAfter(resp.Header.Get("Retry-After"))
+		default: // other 4xx won't get better by retrying
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("TMDB error: %s", body)
+		}
+
+		if attempt == maxRetries {
+			return nil, fmt.Errorf("giving up after %d retries: %w", maxRetries, lastErr)
+		}
+		if wait == 0 {
+			wait = backoff(attempt)
+		}
+		fmt.Printf("Request failed (%v), retrying in %v...\n", lastErr, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+	}
+}
+
+// 1s, 2s, 4s... plus up to 50% random jitter
+func backoff(attempt int) time.Duration {
+	d := baseBackoff << attempt
+	return d + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// Retry-After is either delay-seconds or an HTTP date; 0 if absent/invalid
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
 }
 
 // Load genres (id → name)
 func (c *TMDBClient) loadGenres() error {
 	endpoint := fmt.Sprintf("%s/genre/movie/list?api_key=%s", c.BaseURL, c.APIKey)
-	resp, err := c.HTTPClient.Get(endpoint)
+	resp, err := c.doRequest(endpoint)
 	if err != nil {
 		return fmt.Errorf("failed to fetch genres: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("TMDB error: %s", body)
-	}
-
 	var data struct {
 		Genres []struct {
 			ID   int    `json:"id"`
@@ -88,25 +242,54 @@ func (c *TMDBClient) loadGenres() error {
 	return nil
 }
 
-// Search movies by keyword
+// Search movies by keyword (first page only)
 func (c *TMDBClient) searchMovies(query string) ([]Movie, error) {
+	movies, _, err := c.searchPage(query, 1)
+	return movies, err
+}
+
+// Search movies across up to maxPages result pages
+func (c *TMDBClient) searchMoviesPaged(query string, maxPages int) ([]Movie, error) {
+	var all []Movie
+	for page := 1; page <= maxPages; page++ {
+		if page > 1 {
+			time.Sleep(pageDelay)
+		}
+
+		movies, totalPages, err := c.searchPage(query, page)
+		if err != nil {
+			return all, fmt.Errorf("page %d: %w", page, err)
+		}
+		if len(movies) == 0 {
+			break
+		}
+		all = append(all, movies...)
+
+		if page >= totalPages { // last page reached
+			break
+		}
+	}
+	return all, nil
+}
+
+// Fetch one page of search results and the total page count
+func (c *TMDBClient) searchPage(query string, page int) ([]Movie, int, error) {
 	escaped := url.QueryEscape(query) // escape query for URL
-	endpoint := fmt.Sprintf("%s/search/movie?api_key=%s&query=%s", c.BaseURL, c.APIKey, escaped)
+	endpoint := fmt.Sprintf("%s/search/movie?api_key=%s&query=%s&page=%d", c.BaseURL, c.APIKey, escaped, page)
 
-	resp, err := c.HTTPClient.Get(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search movies: %w", err)
+	if entry, ok := c.cachedMovies(endpoint); ok {
+		return entry.movies, entry.totalPages, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("TMDB error: %s", body)
+	resp, err := c.doRequest(endpoint)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search movies: %w", err)
 	}
+	defer resp.Body.Close()
 
 	var tmdbResp TMDBSearchResponse // parse search response
 	if err := json.NewDecoder(resp.Body).Decode(&tmdbResp); err != nil { //decode JSON
-		return nil, fmt.Errorf("failed to parse search response: %w", err)
+		return nil, 0, fmt.Errorf("failed to parse search response: %w", err)
 	}
 
 	var movies []Movie
@@ -126,23 +309,20 @@ func (c *TMDBClient) searchMovies(query string) ([]Movie, error) {
 		}
 		movies = append(movies, m)
 	}
-	return movies, nil
+
+	c.storeMovies(endpoint, movies, tmdbResp.TotalPages)
+	return movies, tmdbResp.TotalPages, nil
 }
 
 // Get full movie details
 func (c *TMDBClient) getMovieDetails(id int) (*Movie, error) {
 	endpoint := fmt.Sprintf("%s/movie/%d?api_key=%s", c.BaseURL, id, c.APIKey)
-	resp, err := c.HTTPClient.Get(endpoint)
+	resp, err := c.doRequest(endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch movie details: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("TMDB error: %s", body)
-	}
-
 	var data struct {
 		ID          int     `json:"id"`
 		Title       string  `json:"title"`
@@ -187,8 +367,12 @@ func saveMoviesToJSON(movies []Movie, filename string) error {
 }
 
 func main() {
-	apiKey := "33be097c32c7ec8df2864b26e113d643" 
-	client := NewTMDBClient(apiKey)
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	client := NewTMDBClient(cfg)
 
 	fmt.Println("Loading movie genres...")
 	if err := client.loadGenres(); err != nil {
@@ -198,7 +382,7 @@ func main() {
 
 	query := "inception"
 	fmt.Printf("Searching for: %s\n", query)
-	movies, err := client.searchMovies(query)
+	movies, err := client.searchMoviesPaged(query, 3)
 	if err != nil {
 		fmt.Printf("Search error: %v\n", err)
 		return
@@ -209,8 +393,8 @@ func main() {
 	if len(movies) > 0 {
 		fmt.Println("Movie 1:")
 		m := movies[0]
-		fmt.Printf("  ID: %d\n  Title: %s\n  Release Date: %s\n  Rating: %.1f/10\n  
-		Genres: %v\n  Overview: %.80s...\n\n",
+		fmt.Printf("  ID: %d\n  Title: %s\n  Release Date: %s\n  Rating: %.1f/10\n"+
+			"  Genres: %v\n  Overview: %.80s...\n\n",
 			m.ID, m.Title, m.ReleaseDate, m.Rating, m.Genres, m.Overview)
 	}
 
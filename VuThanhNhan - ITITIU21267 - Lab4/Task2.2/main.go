@@ -12,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 // ============ TMDB Client Code (from Task 2.1) ============
@@ -264,13 +265,17 @@ func (m *MockScraperSource) GetName() string {
 	return m.name
 }
 
+// Titles scoring at or above this are treated as the same movie
+const DefaultSimilarityThreshold = 0.8
+
 // MovieAggregator - combines multiple sources
 type MovieAggregator struct {
-	Sources []MovieSource
+	Sources   []MovieSource
+	Threshold float64 // similarity needed to merge two results
 }
 
 func NewMovieAggregator(sources ...MovieSource) *MovieAggregator {
-	return &MovieAggregator{Sources: sources}
+	return &MovieAggregator{Sources: sources, Threshold: DefaultSimilarityThreshold}
 }
 
 func (a *MovieAggregator) Search(query string, limitPerSource int) ([]MovieInfo, error) {
@@ -305,12 +310,38 @@ func (a *MovieAggregator) Search(query string, limitPerSource int) ([]MovieInfo,
 	wg.Wait()
 
 	// Deduplicate and merge
-	deduplicated := deduplicateMovies(allMovies)
+	deduplicated := deduplicateMovies(allMovies, a.Threshold)
 
 	return deduplicated, nil
 }
 
-func deduplicateMovies(movies []MovieInfo) []MovieInfo {
+// SearchByGenre runs Search and keeps only movies sharing at least one of
+// the given genres (case-insensitive). Order stays rating-sorted.
+func (a *MovieAggregator) SearchByGenre(query string, genres []string, limitPerSource int) ([]MovieInfo, error) {
+	movies, err := a.Search(query, limitPerSource)
+	if err != nil || len(genres) == 0 {
+		return movies, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, g := range genres {
+		wanted[strings.ToLower(strings.TrimSpace(g))] = true
+	}
+
+	filtered := []MovieInfo{}
+	for _, movie := range movies {
+		// Movies without genres never match an active filter
+		for _, g := range movie.Genres {
+			if wanted[strings.ToLower(g)] {
+				filtered = append(filtered, movie)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+func deduplicateMovies(movies []MovieInfo, threshold float64) []MovieInfo {
 	if len(movies) == 0 {
 		return movies
 	}
@@ -338,7 +369,7 @@ func deduplicateMovies(movies []MovieInfo) []MovieInfo {
 			}
 
 			similarity := calculateSimilarity(movies[i].Title, movies[j].Title)
-			if similarity >= 0.8 { // 80% similarity threshold
+			if similarity >= threshold {
 				used[j] = true
 
 				// Merge data: keep highest rating
@@ -381,40 +412,55 @@ func deduplicateMovies(movies []MovieInfo) []MovieInfo {
 	return unique
 }
 
+// Combine normalized Levenshtein similarity with word overlap
 func calculateSimilarity(title1, title2 string) float64 {
-	// Normalize titles
-	t1 := strings.ToLower(strings.TrimSpace(title1))
-	t2 := strings.ToLower(strings.TrimSpace(title2))
+	words1 := strings.Fields(normalizeTitle(title1))
+	words2 := strings.Fields(normalizeTitle(title2))
+	if len(words1) == 0 || len(words2) == 0 {
+		return 0.0
+	}
 
-	// Exact match
-	if t1 == t2 {
+	// Compare without spaces so "Spider-Man" and "Spiderman" line up
+	compact1 := strings.Join(words1, "")
+	compact2 := strings.Join(words2, "")
+	if compact1 == compact2 {
 		return 1.0
 	}
 
-	// Remove common words and punctuation for better comparison
-	t1Clean := strings.Map(func(r rune) rune {
-		if r == ':' || r == '-' || r == ',' {
-			return ' '
-		}
-		return r
-	}, t1)
-	t2Clean := strings.Map(func(r rune) rune {
-		if r == ':' || r == '-' || r == ',' {
-			return ' '
-		}
-		return r
-	}, t2)
+	editSimilarity := 1.0 - float64(levenshtein(compact1, compact2))/float64(maxInt(len([]rune(compact1)), len([]rune(compact2))))
+	similarity := 0.7*editSimilarity + 0.3*wordOverlap(words1, words2)
 
-	words1 := strings.Fields(t1Clean)
-	words2 := strings.Fields(t2Clean)
+	// One title containing the other as whole words ("Avengers" in
+	// "The Avengers") is almost certainly the same movie
+	if (containsWords(words1, words2) || containsWords(words2, words1)) && similarity < 0.9 {
+		similarity = 0.9
+	}
 
-	// Check word overlap
-	matchCount := 0
-	totalWords := len(words1)
-	if len(words2) > totalWords {
-		totalWords = len(words2)
+	// Sequels differ by a number only ("Spider-Man 2"), keep them apart
+	if numbersIn(words1) != numbersIn(words2) {
+		similarity *= 0.5
 	}
+	return similarity
+}
 
+// Lowercase and turn punctuation into spaces
+func normalizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, title)
+}
+
+// Whether inner appears in outer as a run of consecutive words
+func containsWords(outer, inner []string) bool {
+	return strings.Contains(" "+strings.Join(outer, " ")+" ", " "+strings.Join(inner, " ")+" ")
+}
+
+// Share of words (longer than 2 chars) found in both titles
+func wordOverlap(words1, words2 []string) float64 {
+	matchCount := 0
 	for _, w1 := range words1 {
 		for _, w2 := range words2 {
 			if w1 == w2 && len(w1) > 2 { // Ignore short words
@@ -423,24 +469,54 @@ func calculateSimilarity(title1, title2 string) float64 {
 			}
 		}
 	}
+	return float64(matchCount) / float64(maxInt(len(words1), len(words2)))
+}
 
-	if totalWords == 0 {
-		return 0.0
+func numbersIn(words []string) string {
+	var nums []string
+	for _, w := range words {
+		if _, err := strconv.Atoi(w); err == nil {
+			nums = append(nums, w)
+		}
 	}
+	return strings.Join(nums, " ")
+}
 
-	wordSimilarity := float64(matchCount) / float64(totalWords)
-
-	// Check if one contains the other
-	if strings.Contains(t1, t2) || strings.Contains(t2, t1) {
-		return 0.9
+// Edit distance between two strings, counted in runes
+func levenshtein(a, b string) int {
+	r1, r2 := []rune(a), []rune(b)
+	prev := make([]int, len(r2)+1)
+	curr := make([]int, len(r2)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(r1); i++ {
+		curr[0] = i
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
 	}
+	return prev[len(r2)]
+}
 
-	// Use Levenshtein-inspired simple distance
-	if wordSimilarity > 0.5 {
-		return 0.7 + wordSimilarity*0.3
+func minInt(a, b int) int {
+	if a < b {
+		return a
 	}
+	return b
+}
 
-	return wordSimilarity * 0.6
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
 
 func generateReport(movies []MovieInfo) {
@@ -572,4 +648,16 @@ func main() {
 	if err != nil {
 		fmt.Printf("Error saving to JSON: %v\n", err)
 	}
+
+	// Same search restricted to a few genres
+	genres := []string{"Action", "Adventure"}
+	fmt.Printf("\n=== Genre Filter: %v ===\n", genres)
+	filtered, err := aggregator.SearchByGenre(query, genres, 10)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	for i, movie := range filtered {
+		fmt.Printf("%d. %s (%d) - %.1f/10 %v\n", i+1, movie.Title, movie.Year, movie.Rating, movie.Genres)
+	}
 }
@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestCalculateSimilarity(t *testing.T) {
+	tests := []struct {
+		title1, title2 string
+		duplicate      bool
+	}{
+		// Same movie, different spelling
+		{"The Matrix", "The Matrix", true},
+		{"The Matrix", "the matrix", true},
+		{"Spider-Man", "Spiderman", true},
+		{"Spider-Man", "SPIDER MAN", true},
+		{"Avengers", "The Avengers", true},
+		{"The Lord of the Rings: The Fellowship of the Ring", "The Lord of the Rings - The Fellowship of the Ring", true},
+		{"Inception", "Inception.", true},
+		{"Star Wars", "Star Wars: A New Hope", true},
+
+		// Different movies
+		{"Spider-Man", "Spider-Man 2", false},
+		{"Toy Story 2", "Toy Story 3", false},
+		{"The Matrix", "The Godfather", false},
+		{"Inception", "Interstellar", false},
+		{"Cars", "Oscars Night", false},
+		{"Alien", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title1+" vs "+tt.title2, func(t *testing.T) {
+			got := calculateSimilarity(tt.title1, tt.title2)
+			if tt.duplicate && got < DefaultSimilarityThreshold {
+				t.Errorf("similarity = %.2f, want >= %.2f", got, DefaultSimilarityThreshold)
+			}
+			if !tt.duplicate && got >= DefaultSimilarityThreshold {
+				t.Errorf("similarity = %.2f, want < %.2f", got, DefaultSimilarityThreshold)
+			}
+			if back := calculateSimilarity(tt.title2, tt.title1); back != got {
+				t.Errorf("not symmetric: %.2f one way, %.2f the other", got, back)
+			}
+		})
+	}
+}
+
+func TestDeduplicateMoviesMergesContainedTitles(t *testing.T) {
+	movies := []MovieInfo{
+		{Title: "The Avengers", Rating: 7.7, Genres: []string{"Action"}, Source: "TMDB"},
+		{Title: "Avengers", Rating: 8.0, Genres: []string{"Science Fiction"}, Director: "Joss Whedon", Source: "IMDB"},
+		{Title: "The Godfather", Rating: 9.2, Source: "IMDB"},
+	}
+
+	got := deduplicateMovies(movies, DefaultSimilarityThreshold)
+	if len(got) != 2 {
+		t.Fatalf("got %d movies, want 2: %+v", len(got), got)
+	}
+
+	avengers := got[1]
+	if avengers.Title != "The Avengers" || avengers.Rating != 8.0 || avengers.Director != "Joss Whedon" {
+		t.Errorf("merged movie = %+v, want The Avengers with rating 8.0 and director Joss Whedon", avengers)
+	}
+	if len(avengers.Genres) != 2 {
+		t.Errorf("merged genres = %v, want both sources' genres", avengers.Genres)
+	}
+}
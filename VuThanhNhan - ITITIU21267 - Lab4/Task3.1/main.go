@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"encoding/csv"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"golang.org/x/net/html"
-	// "io"
+	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 )
 
 // ============================================================================
@@ -17,12 +24,45 @@ import (
 // ============================================================================
 
 type Book struct {
-	Title  string  `json:"title"`
-	Price  string  `json:"price"`
-	Rating string  `json:"rating"`
-	URL    string  `json:"url"`
+	Title       string  `json:"title"`
+	Price       string  `json:"price"`
+	PriceValue  float64 `json:"price_value"`
+	Rating      string  `json:"rating"`
+	RatingValue int     `json:"rating_value"`
+	URL         string  `json:"url"`
 }
 
+// Star words used in the "star-rating X" class
+var ratingWords = map[string]int{
+	"One":   1,
+	"Two":   2,
+	"Three": 3,
+	"Four":  4,
+	"Five":  5,
+}
+
+// ScraperConfig controls how politely the scraper crawls
+type ScraperConfig struct {
+	UserAgent   string
+	Timeout     time.Duration // per request
+	Delay       time.Duration // pause between pages
+	Jitter      time.Duration // random extra pause, up to this much
+	CheckRobots bool          // skip paths disallowed by robots.txt
+}
+
+func DefaultScraperConfig() ScraperConfig {
+	return ScraperConfig{
+		UserAgent:   "NetcenBookScraper/1.0 (+student lab project)",
+		Timeout:     15 * time.Second,
+		Delay:       1 * time.Second,
+		Jitter:      500 * time.Millisecond,
+		CheckRobots: true,
+	}
+}
+
+// Gap between requests in scrapePagesConcurrent when cfg.Delay is zero
+const defaultConcurrentDelay = 500 * time.Millisecond
+
 type ScraperStats struct {
 	PagesScraped int
 	BooksFound   int
@@ -35,9 +75,21 @@ type ScraperStats struct {
 // Utility functions
 // ============================================================================
 
+// get sends a GET with the configured User-Agent and timeout
+func get(pageURL string, cfg ScraperConfig) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", cfg.UserAgent)
+
+	client := &http.Client{Timeout: cfg.Timeout}
+	return client.Do(req)
+}
+
 // fetchPage downloads and parses HTML from a URL
-func fetchPage(pageURL string) (*html.Node, error) {
-	resp, err := http.Get(pageURL)
+func fetchPage(pageURL string, cfg ScraperConfig) (*html.Node, error) {
+	resp, err := get(pageURL, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -50,57 +102,117 @@ func fetchPage(pageURL string) (*html.Node, error) {
 	return doc, nil
 }
 
-// extractBooks extracts book info from one page
-func extractBooks(doc *html.Node, baseURL string) []Book {
-	var books []Book
+// politeSleep waits Delay plus a random share of Jitter
+func politeSleep(cfg ScraperConfig) {
+	wait := cfg.Delay
+	if cfg.Jitter > 0 {
+		wait += time.Duration(rand.Int63n(int64(cfg.Jitter)))
+	}
+	time.Sleep(wait)
+}
+
+// robotsRules holds the Disallow prefixes that apply to our User-Agent
+type robotsRules struct {
+	disallow []string
+}
+
+// loadRobots fetches robots.txt for the host of siteURL. A missing file
+// means everything is allowed.
+func loadRobots(siteURL string, cfg ScraperConfig) (*robotsRules, error) {
+	u, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := get(u.Scheme+"://"+u.Host+"/robots.txt", cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	rules := &robotsRules{}
+	if resp.StatusCode != http.StatusOK {
+		return rules, nil
+	}
+
+	// Only groups for "*" or our own agent name count. A group starts with
+	// one or more consecutive User-agent lines and applies if any of them
+	// matches; the first rule line ends the list.
+	agent := strings.ToLower(strings.SplitN(cfg.UserAgent, "/", 2)[0])
+	applies := false
+	inAgents := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.SplitN(scanner.Text(), "#", 2)[0])
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if key != "user-agent" {
+			inAgents = false
+		}
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				applies = false // a new group
+				inAgents = true
+			}
+			// An empty name matches nobody
+			name := strings.ToLower(value)
+			if name == "*" || (name != "" && agent != "" && strings.Contains(agent, name)) {
+				applies = true
+			}
+		case "disallow":
+			if applies && value != "" {
+				rules.disallow = append(rules.disallow, value)
+			}
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// Allowed reports whether pageURL's path is not disallowed
+func (r *robotsRules) Allowed(pageURL string) bool {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range r.disallow {
+		if strings.HasPrefix(u.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// robotsFor loads the robots.txt rules for baseURL when cfg asks for it.
+// An unreadable robots.txt allows everything.
+func robotsFor(baseURL string, cfg ScraperConfig) *robotsRules {
+	if !cfg.CheckRobots {
+		return &robotsRules{}
+	}
+	rules, err := loadRobots(baseURL, cfg)
+	if err != nil {
+		fmt.Printf("Could not read robots.txt, continuing: %v\n", err)
+		return &robotsRules{}
+	}
+	return rules
+}
+
+// extractBooks extracts book info from one page.
+// Articles that fail to parse are logged, skipped and counted in skipped.
+func extractBooks(doc *html.Node, baseURL string) (books []Book, skipped int) {
 	var f func(*html.Node)
 	f = func(n *html.Node) {
 		if n.Type == html.ElementNode && n.Data == "article" {
 			for _, a := range n.Attr {
 				if a.Key == "class" && strings.Contains(a.Val, "product_pod") {
-					var b Book
-
-					// Find title and relative link
-					findLink := func(n *html.Node) {
-						if n.Type == html.ElementNode && n.Data == "a" {
-							for _, a := range n.Attr {
-								if a.Key == "title" {
-									b.Title = a.Val
-								}
-								if a.Key == "href" {
-									link, _ := url.JoinPath(baseURL, a.Val)
-									b.URL = link
-								}
-							}
-						}
-					}
-
-					// Find price and rating
-					findPrice := func(n *html.Node) {
-						if n.Type == html.ElementNode && n.Data == "p" {
-							for _, a := range n.Attr {
-								if a.Key == "class" && strings.Contains(a.Val, "price_color") && n.FirstChild != nil {
-									b.Price = n.FirstChild.Data
-								}
-								if a.Key == "class" && strings.Contains(a.Val, "star-rating") {
-									b.Rating = strings.TrimPrefix(a.Val, "star-rating ")
-								}
-							}
-						}
-					}
-
-					// Walk inside <article>
-					var inner func(*html.Node)
-					inner = func(c *html.Node) {
-						findLink(c)
-						findPrice(c)
-						for child := c.FirstChild; child != nil; child = child.NextSibling {
-							inner(child) // recursive
-						}
-					}
-					inner(n)
-
-					if b.Title != "" {
+					b, err := extractArticle(n, baseURL)
+					if err != nil {
+						fmt.Printf("  Skipping malformed article: %v\n", err)
+						skipped++
+					} else if b.Title != "" {
 						books = append(books, b)
 					}
 				}
@@ -111,7 +223,81 @@ func extractBooks(doc *html.Node, baseURL string) []Book {
 		}
 	}
 	f(doc)
-	return books
+	return books, skipped
+}
+
+// extractArticle reads one product_pod; a panic on a malformed node is
+// turned into an error so the rest of the page can still be scraped
+func extractArticle(n *html.Node, baseURL string) (b Book, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered from panic: %v", r)
+		}
+	}()
+	return articleParser(n, baseURL), nil
+}
+
+// articleParser parses one product_pod; tests swap it to make it panic
+var articleParser = parseArticle
+
+// parseArticle reads the title, link, price and rating of one product_pod
+func parseArticle(n *html.Node, baseURL string) (b Book) {
+	// Find title and relative link
+	findLink := func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			for _, a := range n.Attr {
+				if a.Key == "title" {
+					b.Title = a.Val
+				}
+				if a.Key == "href" {
+					link, _ := url.JoinPath(baseURL, a.Val)
+					b.URL = link
+				}
+			}
+		}
+	}
+
+	// Find price and rating
+	findPrice := func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "p" {
+			for _, a := range n.Attr {
+				if a.Key == "class" && strings.Contains(a.Val, "price_color") && n.FirstChild != nil {
+					b.Price = n.FirstChild.Data
+				}
+				if a.Key == "class" && strings.Contains(a.Val, "star-rating") {
+					b.Rating = strings.TrimPrefix(a.Val, "star-rating ")
+				}
+			}
+		}
+	}
+
+	// Walk inside <article>
+	var inner func(*html.Node)
+	inner = func(c *html.Node) {
+		findLink(c)
+		findPrice(c)
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			inner(child) // recursive
+		}
+	}
+	inner(n)
+
+	b.PriceValue = parsePrice(b.Price)
+	b.RatingValue = ratingWords[b.Rating]
+	return b
+}
+
+// parsePrice turns "£51.77" (or a bare "51.77") into 51.77; 0 if unparsable
+func parsePrice(price string) float64 {
+	// Strip the currency symbol, including the mis-decoded "Â£" some pages give
+	numeric := strings.TrimLeftFunc(strings.TrimSpace(price), func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	value, err := strconv.ParseFloat(numeric, 64)
+	if err != nil {
+		return 0
+	}
+	return value
 }
 
 // getNextPageURL finds the next page link and returns absolute URL
@@ -155,25 +341,42 @@ func getNextPageURL(doc *html.Node, baseURL string) (string, bool) {
 // Main scraper logic
 // ============================================================================
 
-func scrapePaginatedBooks(baseURL string, maxPages int) ([]Book, *ScraperStats, error) {
+// scrapePaginatedBooks follows "next" links from baseURL. With a non-nil
+// stream, books are written out as they are found and not kept in memory.
+func scrapePaginatedBooks(baseURL string, maxPages int, cfg ScraperConfig, stream *JSONLWriter) ([]Book, *ScraperStats, error) {
 	stats := &ScraperStats{StartTime: time.Now()}
 	var allBooks []Book
 	currentURL := baseURL
 
+	robots := robotsFor(baseURL, cfg)
+
 	for page := 1; page <= maxPages; page++ {
+		if !robots.Allowed(currentURL) {
+			fmt.Printf("Skipping %s: disallowed by robots.txt\n", currentURL)
+			break
+		}
 		fmt.Printf("Scraping page %d/%d...\n", page, maxPages)
 
-		doc, err := fetchPage(currentURL)
+		doc, err := fetchPage(currentURL, cfg)
 		if err != nil {
 			fmt.Printf("  Error loading page: %v\n", err)
 			stats.Errors++
-			time.Sleep(2 * time.Second)
+			time.Sleep(2 * cfg.Delay) // back off, then retry this page
 			continue
 		}
 
-		books := extractBooks(doc, baseURL)
+		books, skipped := extractBooks(doc, baseURL)
 		fmt.Printf("  Found %d books\n", len(books))
-		allBooks = append(allBooks, books...)
+		stats.Errors += skipped
+		if stream != nil {
+			for _, b := range books {
+				if err := stream.Append(b); err != nil {
+					return allBooks, stats, fmt.Errorf("writing JSONL: %w", err)
+				}
+			}
+		} else {
+			allBooks = append(allBooks, books...)
+		}
 		stats.PagesScraped++
 		stats.BooksFound += len(books)
 
@@ -184,13 +387,94 @@ func scrapePaginatedBooks(baseURL string, maxPages int) ([]Book, *ScraperStats,
 		currentURL = nextURL
 
 		// Rate limit
-		time.Sleep(1 * time.Second)
+		politeSleep(cfg)
 	}
 
 	stats.EndTime = time.Now()
 	return allBooks, stats, nil
 }
 
+// scrapePagesConcurrent fetches explicit page numbers (baseURL + "page-N.html")
+// with a pool of workers sharing one rate limiter. Books come back in page
+// order; failed pages are counted in stats and returned as errors, and pages
+// disallowed by robots.txt are skipped.
+func scrapePagesConcurrent(baseURL string, pages []int, workers int, cfg ScraperConfig) ([]Book, *ScraperStats, []error) {
+	stats := &ScraperStats{StartTime: time.Now()}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	// Fetched once up front; every worker checks the same rules
+	robots := robotsFor(baseURL, cfg)
+
+	// One slot per page so the merged result doesn't depend on timing
+	results := make([][]Book, len(pages))
+	var errs []error
+	var mu sync.Mutex // guards stats and errs
+
+	// The workers share one ticker, so cfg.Delay spaces out requests across
+	// all of them; with no Delay set they still keep a default gap
+	interval := cfg.Delay
+	if interval <= 0 {
+		interval = defaultConcurrentDelay
+	}
+	limiter := time.NewTicker(interval)
+	defer limiter.Stop()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				pageURL := fmt.Sprintf("%spage-%d.html", baseURL, pages[i])
+				if !robots.Allowed(pageURL) {
+					fmt.Printf("Skipping %s: disallowed by robots.txt\n", pageURL)
+					continue
+				}
+				<-limiter.C
+				if cfg.Jitter > 0 {
+					time.Sleep(time.Duration(rand.Int63n(int64(cfg.Jitter))))
+				}
+				fmt.Printf("Scraping page %d...\n", pages[i])
+
+				doc, err := fetchPage(pageURL, cfg)
+				if err != nil {
+					mu.Lock()
+					stats.Errors++
+					errs = append(errs, fmt.Errorf("page %d: %w", pages[i], err))
+					mu.Unlock()
+					continue
+				}
+
+				books, skipped := extractBooks(doc, baseURL)
+				results[i] = books
+
+				mu.Lock()
+				stats.PagesScraped++
+				stats.BooksFound += len(books)
+				stats.Errors += skipped
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range pages {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var allBooks []Book
+	for _, books := range results {
+		allBooks = append(allBooks, books...)
+	}
+
+	stats.EndTime = time.Now()
+	return allBooks, stats, errs
+}
+
 // ============================================================================
 // Reporting
 // ============================================================================
@@ -209,11 +493,56 @@ func printStats(stats *ScraperStats) {
 	fmt.Printf("Average books per page: %.1f\n", avgBooks)
 }
 
+// JSONLWriter writes one JSON object per line, so a deep crawl can be
+// saved incrementally instead of marshalling one big slice at the end
+type JSONLWriter struct {
+	enc *json.Encoder
+}
+
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{enc: json.NewEncoder(w)}
+}
+
+// Append writes b followed by a newline
+func (j *JSONLWriter) Append(b Book) error {
+	return j.enc.Encode(b)
+}
+
+// saveBooksToCSV writes title,price,rating,url rows; the csv writer quotes
+// titles that contain commas or quotes
+func saveBooksToCSV(books []Book, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write([]string{"title", "price", "rating", "url"}); err != nil {
+		return err
+	}
+	for _, b := range books {
+		if err := w.Write([]string{b.Title, b.Price, b.Rating, b.URL}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
 // ============================================================================
 // Main
 // ============================================================================
 
 func main() {
+	format := flag.String("format", "json", "output format: json, csv or both")
+	jsonlPath := flag.String("jsonl", "", "stream books to this JSON Lines file instead of -format output")
+	flag.Parse()
+	if *format != "json" && *format != "csv" && *format != "both" {
+		fmt.Printf("Unknown -format %q (want json, csv or both)\n", *format)
+		os.Exit(2)
+	}
+
 	baseURL := "http://books.toscrape.com/catalogue/"
 	startPage := "page-1.html"
 	maxPages := 5
@@ -221,7 +550,18 @@ func main() {
 	fmt.Printf("Starting paginated scraper...\n")
 	fmt.Printf("Max pages: %d\n\n", maxPages)
 
-	allBooks, stats, err := scrapePaginatedBooks(baseURL+startPage, maxPages)
+	var stream *JSONLWriter
+	if *jsonlPath != "" {
+		file, err := os.Create(*jsonlPath)
+		if err != nil {
+			fmt.Printf("Error creating %s: %v\n", *jsonlPath, err)
+			return
+		}
+		defer file.Close()
+		stream = NewJSONLWriter(file)
+	}
+
+	allBooks, stats, err := scrapePaginatedBooks(baseURL+startPage, maxPages, DefaultScraperConfig(), stream)
 	if err != nil {
 		fmt.Printf("Scraping failed: %v\n", err)
 		return
@@ -229,9 +569,25 @@ func main() {
 
 	printStats(stats)
 
-	data, _ := json.MarshalIndent(allBooks, "", "  ")
-	filename := "paginated_books.json"
-	_ = os.WriteFile(filename, data, 0644)
+	if stream != nil {
+		fmt.Printf("\nStreamed %d books to %s\n", stats.BooksFound, *jsonlPath)
+		return
+	}
+
+	if *format == "json" || *format == "both" {
+		data, _ := json.MarshalIndent(allBooks, "", "  ")
+		filename := "paginated_books.json"
+		_ = os.WriteFile(filename, data, 0644)
 
-	fmt.Printf("\nSaved %d books to %s\n", len(allBooks), filename)
+		fmt.Printf("\nSaved %d books to %s\n", len(allBooks), filename)
+	}
+
+	if *format == "csv" || *format == "both" {
+		filename := "paginated_books.csv"
+		if err := saveBooksToCSV(allBooks, filename); err != nil {
+			fmt.Printf("Error saving CSV: %v\n", err)
+			return
+		}
+		fmt.Printf("\nSaved %d books to %s\n", len(allBooks), filename)
+	}
 }
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// One product_pod in the books.toscrape.com layout
+func articleHTML(title, price, rating string) string {
+	return fmt.Sprintf(`<article class="product_pod">
+	<p class="star-rating %s"></p>
+	<h3><a href="%s/index.html" title="%s">%s</a></h3>
+	<div class="product_price"><p class="price_color">%s</p></div>
+</article>`, rating, strings.ToLower(strings.ReplaceAll(title, " ", "-")), title, title, price)
+}
+
+func pageHTML(articles ...string) string {
+	return "<html><body><ol>" + strings.Join(articles, "\n") + "</ol></body></html>"
+}
+
+func parseHTML(t *testing.T, s string) *html.Node {
+	t.Helper()
+	doc, err := html.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// Config for tests: no pauses, robots.txt honored
+func testConfig() ScraperConfig {
+	cfg := DefaultScraperConfig()
+	cfg.Delay = 0
+	cfg.Jitter = 0
+	return cfg
+}
+
+func TestScrapePagesConcurrentHonorsRobots(t *testing.T) {
+	var mu sync.Mutex
+	requested := make(map[string]string) // path -> User-Agent
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path] = r.UserAgent()
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /catalogue/page-2.html\n")
+		case "/catalogue/page-1.html", "/catalogue/page-2.html", "/catalogue/page-3.html":
+			page := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/catalogue/page-"), ".html")
+			fmt.Fprint(w, pageHTML(articleHTML("Book "+page, "£10.00", "One")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	books, stats, errs := scrapePagesConcurrent(srv.URL+"/catalogue/", []int{1, 2, 3}, 2, cfg)
+	if len(errs) > 0 {
+		t.Fatalf("errors: %v", errs)
+	}
+
+	var titles []string
+	for _, b := range books {
+		titles = append(titles, b.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Book 1,Book 3" {
+		t.Errorf("books = %s, want Book 1,Book 3", got)
+	}
+	if stats.PagesScraped != 2 {
+		t.Errorf("PagesScraped = %d, want 2", stats.PagesScraped)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := requested["/catalogue/page-2.html"]; ok {
+		t.Error("disallowed page-2.html was fetched")
+	}
+	for path, ua := range requested {
+		if ua != cfg.UserAgent {
+			t.Errorf("%s requested with User-Agent %q, want %q", path, ua, cfg.UserAgent)
+		}
+	}
+}
+
+func TestScrapePagesConcurrentWithoutRobots(t *testing.T) {
+	var robotsFetched bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetched = true
+			fmt.Fprint(w, "User-agent: *\nDisallow: /\n")
+			return
+		}
+		fmt.Fprint(w, pageHTML(articleHTML("Any Book", "£1.00", "Two")))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.CheckRobots = false
+	books, _, errs := scrapePagesConcurrent(srv.URL+"/catalogue/", []int{1}, 1, cfg)
+	if len(errs) > 0 || len(books) != 1 {
+		t.Fatalf("books = %v, errors = %v; want one book", books, errs)
+	}
+	if robotsFetched {
+		t.Error("robots.txt fetched with CheckRobots off")
+	}
+}
+
+func TestLoadRobotsGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		robots   string
+		disallow []string // paths that must be blocked
+		allow    []string // paths that must stay allowed
+	}{
+		{"star then other agent in one group", "User-agent: *\nUser-agent: foo\nDisallow: /a\n", []string{"/a"}, nil},
+		{"other agent then star in one group", "User-agent: foo\nUser-agent: *\nDisallow: /a\n", []string{"/a"}, nil},
+		{"our name among others", "User-agent: foo\nUser-agent: NetcenBookScraper\nDisallow: /a\n", []string{"/a"}, nil},
+		{"empty name matches nobody", "User-agent:\nDisallow: /a\n", nil, []string{"/a"}},
+		{"empty name next to star", "User-agent:\nUser-agent: *\nDisallow: /a\n", []string{"/a"}, nil},
+		{"rule line ends the agent list", "User-agent: *\nDisallow: /a\nUser-agent: foo\nDisallow: /b\n", []string{"/a"}, []string{"/b"}},
+		{"separate groups", "User-agent: foo\nDisallow: /a\n\nUser-agent: *\nDisallow: /b\n", []string{"/b"}, []string{"/a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.robots)
+			}))
+			defer srv.Close()
+
+			rules, err := loadRobots(srv.URL, testConfig())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, path := range tt.disallow {
+				if rules.Allowed(srv.URL + path) {
+					t.Errorf("%s allowed, want disallowed (rules %v)", path, rules.disallow)
+				}
+			}
+			for _, path := range tt.allow {
+				if !rules.Allowed(srv.URL + path) {
+					t.Errorf("%s disallowed, want allowed (rules %v)", path, rules.disallow)
+				}
+			}
+		})
+	}
+}
+
+func TestScrapePagesConcurrentUsesDelay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pageHTML(articleHTML("Any Book", "£1.00", "Two")))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.CheckRobots = false
+	cfg.Delay = 50 * time.Millisecond
+
+	// 4 pages, one per tick, whatever the number of workers
+	start := time.Now()
+	_, stats, errs := scrapePagesConcurrent(srv.URL+"/catalogue/", []int{1, 2, 3, 4}, 4, cfg)
+	elapsed := time.Since(start)
+	if len(errs) > 0 || stats.PagesScraped != 4 {
+		t.Fatalf("PagesScraped = %d, errors = %v", stats.PagesScraped, errs)
+	}
+	if elapsed < 4*cfg.Delay {
+		t.Errorf("took %v, want at least %v at one page per %v", elapsed, 4*cfg.Delay, cfg.Delay)
+	}
+	if elapsed >= 4*defaultConcurrentDelay {
+		t.Errorf("took %v, the default gap was used instead of Delay", elapsed)
+	}
+}
+
+func TestExtractBooksSurvivesBrokenArticles(t *testing.T) {
+	page := pageHTML(
+		articleHTML("Good Book", "£51.77", "Three"),
+		// price_color paragraph with no text node (nil FirstChild)
+		`<article class="product_pod"><h3><a href="empty-price/index.html" title="Empty Price">x</a></h3><p class="price_color"></p></article>`,
+		// No title link at all
+		`<article class="product_pod"><p class="price_color">£3.00</p></article>`,
+		articleHTML("Last Book", "£9.99", "Five"),
+		// Unclosed tags and a rating class with no star word; last, since
+		// everything after it would end up inside this article
+		`<article class="product_pod"><p class="star-rating"><h3><a title="Unclosed" href="unclosed/index.html">Unclosed`,
+	)
+
+	books, skipped := extractBooks(parseHTML(t, page), "http://example.com/catalogue/")
+	if skipped != 0 {
+		t.Errorf("skipped = %d, want 0", skipped)
+	}
+
+	got := make(map[string]Book)
+	for _, b := range books {
+		got[b.Title] = b
+	}
+	for _, title := range []string{"Good Book", "Empty Price", "Unclosed", "Last Book"} {
+		if _, ok := got[title]; !ok {
+			t.Errorf("%q missing from %v", title, books)
+		}
+	}
+	if len(books) != 4 {
+		t.Errorf("got %d books, want 4 (the untitled article is dropped)", len(books))
+	}
+	if b := got["Empty Price"]; b.Price != "" || b.PriceValue != 0 {
+		t.Errorf("Empty Price = %+v, want no price", b)
+	}
+	if b := got["Unclosed"]; b.RatingValue != 0 {
+		t.Errorf("Unclosed = %+v, want rating 0", b)
+	}
+	if b := got["Last Book"]; b.PriceValue != 9.99 || b.RatingValue != 5 {
+		t.Errorf("Last Book = %+v, want price 9.99 and rating 5", b)
+	}
+}
+
+// Make articleParser panic on the article titled "Boom" until the test ends
+func panicOnBoom(t *testing.T) {
+	t.Helper()
+	articleParser = func(n *html.Node, baseURL string) Book {
+		b := parseArticle(n, baseURL)
+		if b.Title == "Boom" {
+			var missing *html.Node
+			_ = missing.Data // nil pointer dereference
+		}
+		return b
+	}
+	t.Cleanup(func() { articleParser = parseArticle })
+}
+
+func TestExtractBooksSkipsPanickingArticle(t *testing.T) {
+	panicOnBoom(t)
+	page := pageHTML(
+		articleHTML("Before", "£1.00", "One"),
+		articleHTML("Boom", "£2.00", "Two"),
+		articleHTML("After", "£3.00", "Three"),
+	)
+
+	books, skipped := extractBooks(parseHTML(t, page), "http://example.com/catalogue/")
+	if skipped != 1 {
+		t.Errorf("skipped = %d, want 1", skipped)
+	}
+	var titles []string
+	for _, b := range books {
+		titles = append(titles, b.Title)
+	}
+	if got := strings.Join(titles, ","); got != "Before,After" {
+		t.Errorf("books = %s, want Before,After", got)
+	}
+}
+
+func TestScrapeCountsPanickingArticleAsError(t *testing.T) {
+	panicOnBoom(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, pageHTML(
+			articleHTML("Before", "£1.00", "One"),
+			articleHTML("Boom", "£2.00", "Two"),
+			articleHTML("After", "£3.00", "Three"),
+		))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.CheckRobots = false
+	cfg.Delay = time.Millisecond
+
+	books, stats, err := scrapePaginatedBooks(srv.URL+"/catalogue/page-1.html", 1, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Errors != 1 || len(books) != 2 {
+		t.Errorf("paginated: Errors = %d, books = %d; want 1 and 2", stats.Errors, len(books))
+	}
+
+	books, stats, errs := scrapePagesConcurrent(srv.URL+"/catalogue/", []int{1}, 1, cfg)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if stats.Errors != 1 || len(books) != 2 {
+		t.Errorf("concurrent: Errors = %d, books = %d; want 1 and 2", stats.Errors, len(books))
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"£51.77", 51.77},
+		{"51.77", 51.77},
+		{"Â£51.77", 51.77}, // mis-decoded pound sign
+		{" £13.99 ", 13.99},
+		{"£0.50", 0.5},
+		{"", 0},
+		{"£", 0},
+		{"free", 0},
+	}
+	for _, tt := range tests {
+		if got := parsePrice(tt.in); got != tt.want {
+			t.Errorf("parsePrice(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExtractBooksRatingWords(t *testing.T) {
+	for word, want := range map[string]int{"One": 1, "Two": 2, "Three": 3, "Four": 4, "Five": 5, "Six": 0} {
+		books, _ := extractBooks(parseHTML(t, pageHTML(articleHTML("Rated "+word, "£10.00", word))), "http://example.com/")
+		if len(books) != 1 {
+			t.Fatalf("%s: got %d books, want 1", word, len(books))
+		}
+		b := books[0]
+		if b.RatingValue != want || b.Rating != word {
+			t.Errorf("%s: Rating = %q, RatingValue = %d; want %q and %d", word, b.Rating, b.RatingValue, word, want)
+		}
+		if b.Price != "£10.00" || b.PriceValue != 10 {
+			t.Errorf("%s: Price = %q, PriceValue = %v; want £10.00 and 10", word, b.Price, b.PriceValue)
+		}
+	}
+}
+
+func TestSaveBooksToCSVRoundTrip(t *testing.T) {
+	books := []Book{
+		{Title: "A Light in the Attic", Price: "£51.77", Rating: "Three", URL: "http://example.com/a/index.html"},
+		{Title: "Sapiens: A Brief History of Humankind, Vol. 1", Price: "£54.23", Rating: "Five", URL: "http://example.com/b/index.html"},
+		{Title: `The "Quoted" Book, Again`, Price: "£10.00", Rating: "One", URL: "http://example.com/c/index.html"},
+	}
+	filename := filepath.Join(t.TempDir(), "books.csv")
+	if err := saveBooksToCSV(books, filename); err != nil {
+		t.Fatalf("saveBooksToCSV: %v", err)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV back: %v", err)
+	}
+
+	if len(records) != len(books)+1 {
+		t.Fatalf("got %d records, want header plus %d", len(records), len(books))
+	}
+	if got := strings.Join(records[0], ","); got != "title,price,rating,url" {
+		t.Errorf("header = %s", got)
+	}
+	for i, b := range books {
+		want := []string{b.Title, b.Price, b.Rating, b.URL}
+		if strings.Join(records[i+1], "|") != strings.Join(want, "|") {
+			t.Errorf("row %d = %q, want %q", i+1, records[i+1], want)
+		}
+	}
+}
+
+func TestJSONLWriterLinesUnmarshalIndependently(t *testing.T) {
+	books := []Book{
+		{Title: "First", Price: "£1.00", PriceValue: 1, Rating: "One", RatingValue: 1},
+		{Title: "Second, with\nnewline", Price: "£2.00", PriceValue: 2, Rating: "Two", RatingValue: 2},
+		{Title: "Third", Price: "£3.00", PriceValue: 3, Rating: "Three", RatingValue: 3},
+	}
+
+	var buf bytes.Buffer
+	w := NewJSONLWriter(&buf)
+	for _, b := range books {
+		if err := w.Append(b); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(books) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(books), buf.String())
+	}
+	for i, line := range lines {
+		var got Book
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Errorf("line %d does not unmarshal on its own: %v", i+1, err)
+			continue
+		}
+		if got != books[i] {
+			t.Errorf("line %d = %+v, want %+v", i+1, got, books[i])
+		}
+	}
+}
+
+func TestScrapePaginatedBooksStreamsJSONL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/catalogue/page-1.html":
+			fmt.Fprint(w, pageHTML(articleHTML("Page One Book", "£1.00", "One"))+`<li class="next"><a href="page-2.html">next</a></li>`)
+		case "/catalogue/page-2.html":
+			fmt.Fprint(w, pageHTML(articleHTML("Page Two Book", "£2.00", "Two")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	books, stats, err := scrapePaginatedBooks(srv.URL+"/catalogue/page-1.html", 5, testConfig(), NewJSONLWriter(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 0 {
+		t.Errorf("streaming kept %d books in memory, want 0", len(books))
+	}
+	if stats.BooksFound != 2 {
+		t.Errorf("BooksFound = %d, want 2", stats.BooksFound)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("streamed %d lines, want 2:\n%s", lines, buf.String())
+	}
+}
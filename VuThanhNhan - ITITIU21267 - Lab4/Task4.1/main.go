@@ -9,6 +9,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -124,7 +125,10 @@ func (c *TMDBClient) searchMovies(query string) ([]TMDBMovie, error) {
 // Movie Database
 // ============================================================================
 
+// MovieDatabase is safe for concurrent use; mu guards all maps and counters
 type MovieDatabase struct {
+	mu sync.RWMutex
+
 	Movies      map[string]MovieInfo `json:"movies"`
 	Genres      map[string][]string  `json:"genres"`
 	Directors   map[string][]string  `json:"directors"`
@@ -143,38 +147,40 @@ func NewMovieDatabase() *MovieDatabase {
 }
 
 func (db *MovieDatabase) Add(movie MovieInfo) error {
+	db.AddNew(movie)
+	return nil
+}
+
+// AddNew adds movie and reports whether it was new (false for duplicates)
+func (db *MovieDatabase) AddNew(movie MovieInfo) bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
 	movieID := movie.ID
 
 	// Check if already exists
 	if _, exists := db.Movies[movieID]; exists {
-		return nil
+		return false
 	}
 
-	// Add movie to Movies map
+	// Add movie to Movies map and the genre, director and year indexes
 	db.Movies[movieID] = movie
-
-	// Update genre index
-	for _, genre := range movie.Genres {
-		db.Genres[genre] = append(db.Genres[genre], movieID)
-	}
-
-	// Update director index
-	if movie.Director != "" {
-		db.Directors[movie.Director] = append(db.Directors[movie.Director], movieID)
-	}
-
-	// Update year index
-	if movie.Year > 0 {
-		db.Years[movie.Year] = append(db.Years[movie.Year], movieID)
-	}
+	db.index(movie)
 
 	// Update count
 	db.TotalCount++
 
-	return nil
+	return true
 }
 
 func (db *MovieDatabase) Get(id string) (*MovieInfo, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.get(id)
+}
+
+// get assumes the caller holds db.mu
+func (db *MovieDatabase) get(id string) (*MovieInfo, error) {
 	movie, exists := db.Movies[id]
 	if !exists {
 		return nil, fmt.Errorf("movie not found: %s", id)
@@ -183,6 +189,9 @@ func (db *MovieDatabase) Get(id string) (*MovieInfo, error) {
 }
 
 func (db *MovieDatabase) Search(query string) ([]MovieInfo, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	var results []MovieInfo
 	query = strings.ToLower(query)
 
@@ -196,6 +205,9 @@ func (db *MovieDatabase) Search(query string) ([]MovieInfo, error) {
 }
 
 func (db *MovieDatabase) GetByGenre(genre string) ([]MovieInfo, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	var results []MovieInfo
 
 	movieIDs, exists := db.Genres[genre]
@@ -204,7 +216,7 @@ func (db *MovieDatabase) GetByGenre(genre string) ([]MovieInfo, error) {
 	}
 
 	for _, id := range movieIDs {
-		if movie, err := db.Get(id); err == nil {
+		if movie, err := db.get(id); err == nil {
 			results = append(results, *movie)
 		}
 	}
@@ -213,6 +225,9 @@ func (db *MovieDatabase) GetByGenre(genre string) ([]MovieInfo, error) {
 }
 
 func (db *MovieDatabase) GetByYear(year int) ([]MovieInfo, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	var results []MovieInfo
 
 	movieIDs, exists := db.Years[year]
@@ -221,7 +236,7 @@ func (db *MovieDatabase) GetByYear(year int) ([]MovieInfo, error) {
 	}
 
 	for _, id := range movieIDs {
-		if movie, err := db.Get(id); err == nil {
+		if movie, err := db.get(id); err == nil {
 			results = append(results, *movie)
 		}
 	}
@@ -230,6 +245,9 @@ func (db *MovieDatabase) GetByYear(year int) ([]MovieInfo, error) {
 }
 
 func (db *MovieDatabase) GetByDirector(director string) ([]MovieInfo, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	var results []MovieInfo
 
 	movieIDs, exists := db.Directors[director]
@@ -238,7 +256,7 @@ func (db *MovieDatabase) GetByDirector(director string) ([]MovieInfo, error) {
 	}
 
 	for _, id := range movieIDs {
-		if movie, err := db.Get(id); err == nil {
+		if movie, err := db.get(id); err == nil {
 			results = append(results, *movie)
 		}
 	}
@@ -246,24 +264,118 @@ func (db *MovieDatabase) GetByDirector(director string) ([]MovieInfo, error) {
 	return results, nil
 }
 
+// GetByRatingRange returns movies rated within [min, max], best first.
+//
+// This scans Movies instead of keeping a sorted rating index: the database
+// holds a few hundred movies, so a scan is cheap, and an index would have to
+// be re-sorted on every Add and kept in sync by Update (ratings change there)
+// and Delete. Worth revisiting if the collection grows by orders of magnitude.
+func (db *MovieDatabase) GetByRatingRange(min, max float64) []MovieInfo {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	results := []MovieInfo{}
+	for _, movie := range db.Movies {
+		if movie.Rating >= min && movie.Rating <= max {
+			results = append(results, movie)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rating != results[j].Rating {
+			return results[i].Rating > results[j].Rating
+		}
+		return results[i].Title < results[j].Title // stable order for ties
+	})
+	return results
+}
+
 func (db *MovieDatabase) Update(movie MovieInfo) error {
-	if _, exists := db.Movies[movie.ID]; !exists {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	old, exists := db.Movies[movie.ID]
+	if !exists {
 		return fmt.Errorf("movie not found: %s", movie.ID)
 	}
+
+	// Genres, director or year may have changed, so re-index from scratch
+	db.unindex(old)
 	db.Movies[movie.ID] = movie
+	db.index(movie)
 	return nil
 }
 
 func (db *MovieDatabase) Delete(id string) error {
-	if _, exists := db.Movies[id]; !exists {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	movie, exists := db.Movies[id]
+	if !exists {
 		return fmt.Errorf("movie not found: %s", id)
 	}
 	delete(db.Movies, id)
+	db.unindex(movie)
 	db.TotalCount--
 	return nil
 }
 
+// index adds movie's ID to the genre, director and year indexes; the caller
+// holds db.mu
+func (db *MovieDatabase) index(movie MovieInfo) {
+	for _, genre := range movie.Genres {
+		db.Genres[genre] = append(db.Genres[genre], movie.ID)
+	}
+	if movie.Director != "" {
+		db.Directors[movie.Director] = append(db.Directors[movie.Director], movie.ID)
+	}
+	if movie.Year > 0 {
+		db.Years[movie.Year] = append(db.Years[movie.Year], movie.ID)
+	}
+}
+
+// unindex drops movie's ID from every index it appears in, removing keys
+// left empty; the caller holds db.mu
+func (db *MovieDatabase) unindex(movie MovieInfo) {
+	id := movie.ID
+	for _, genre := range movie.Genres {
+		if ids := removeID(db.Genres[genre], id); len(ids) > 0 {
+			db.Genres[genre] = ids
+		} else {
+			delete(db.Genres, genre)
+		}
+	}
+	if movie.Director != "" {
+		if ids := removeID(db.Directors[movie.Director], id); len(ids) > 0 {
+			db.Directors[movie.Director] = ids
+		} else {
+			delete(db.Directors, movie.Director)
+		}
+	}
+	if movie.Year > 0 {
+		if ids := removeID(db.Years[movie.Year], id); len(ids) > 0 {
+			db.Years[movie.Year] = ids
+		} else {
+			delete(db.Years, movie.Year)
+		}
+	}
+}
+
+// Copy of ids without id
+func removeID(ids []string, id string) []string {
+	var kept []string
+	for _, existing := range ids {
+		if existing != id {
+			kept = append(kept, existing)
+		}
+	}
+	return kept
+}
+
 func (db *MovieDatabase) Save(filename string) error {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	data, err := json.MarshalIndent(db, "", "  ")
 	if err != nil {
 		return err
@@ -276,10 +388,75 @@ func (db *MovieDatabase) Load(filename string) error {
 	if err != nil {
 		return err
 	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
 	return json.Unmarshal(data, db)
 }
 
+// Merge adds the movies from a saved file to the current contents instead of
+// replacing them like Load does. Existing movies win on duplicate IDs.
+func (db *MovieDatabase) Merge(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	// Only the movies are trusted; saved indexes may be stale or hand-edited
+	var saved struct {
+		Movies map[string]MovieInfo `json:"movies"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	for id, movie := range saved.Movies {
+		if movie.ID == "" {
+			movie.ID = id
+		}
+		db.Add(movie)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.rebuildIndexes()
+	db.LastUpdated = time.Now()
+	return nil
+}
+
+// Recompute Genres, Directors, Years and TotalCount from Movies.
+// Caller must hold db.mu.
+func (db *MovieDatabase) rebuildIndexes() {
+	db.Genres = make(map[string][]string)
+	db.Directors = make(map[string][]string)
+	db.Years = make(map[int][]string)
+
+	// Sorted IDs keep index order deterministic
+	ids := make([]string, 0, len(db.Movies))
+	for id := range db.Movies {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		movie := db.Movies[id]
+		for _, genre := range movie.Genres {
+			db.Genres[genre] = append(db.Genres[genre], id)
+		}
+		if movie.Director != "" {
+			db.Directors[movie.Director] = append(db.Directors[movie.Director], id)
+		}
+		if movie.Year > 0 {
+			db.Years[movie.Year] = append(db.Years[movie.Year], id)
+		}
+	}
+	db.TotalCount = len(db.Movies)
+}
+
 func (db *MovieDatabase) PrintStatistics() {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	fmt.Println("\n=== Movie Database Statistics ===")
 	fmt.Printf("Total Movies: %d\n", db.TotalCount)
 	fmt.Printf("Total Genres: %d\n", len(db.Genres))
@@ -310,9 +487,14 @@ func (db *MovieDatabase) PrintStatistics() {
 // Collection Pipeline
 // ============================================================================
 
-func buildMovieDatabase(apiKey string) (*MovieDatabase, error) {
+// Worker pool defaults for buildMovieDatabase
+const (
+	defaultConcurrency = 3
+	requestInterval    = 250 * time.Millisecond // at most 4 TMDB requests/sec overall
+)
+
+func buildMovieDatabase(client *TMDBClient, concurrency int) (*MovieDatabase, error) {
 	db := NewMovieDatabase()
-	client := NewTMDBClient(apiKey)
 
 	// Load genres first
 	fmt.Println("Loading movie genres from TMDB...")
@@ -329,44 +511,64 @@ func buildMovieDatabase(apiKey string) (*MovieDatabase, error) {
 		"2023", "2022", "2021", "classic",
 	}
 
-	fmt.Println("\nBuilding movie database...")
-
-	for i, query := range searchQueries {
-		fmt.Printf("[%d/%d] Searching for: %s\n", i+1, len(searchQueries), query)
-
-		// Search movies using TMDB client
-		movies, err := client.searchMovies(query)
-		if err != nil {
-			fmt.Printf("  Error: %v\n", err)
-			continue
-		}
-
-		// Add to database
-		added := 0
-		for _, movie := range movies {
-			movieInfo := MovieInfo{
-				ID:          fmt.Sprintf("%d", movie.ID),
-				Title:       movie.Title,
-				Year:        extractYear(movie.ReleaseDate),
-				Description: movie.Overview,
-				Genres:      movie.Genres,
-				Rating:      movie.Rating,
-				Source:      "TMDB",
-				LastUpdated: time.Now().Format(time.RFC3339),
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	fmt.Printf("\nBuilding movie database (%d workers)...\n", concurrency)
+
+	// Shared limiter: all workers together stay under the TMDB rate limit
+	limiter := time.NewTicker(requestInterval)
+	defer limiter.Stop()
+
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				query := searchQueries[i]
+				<-limiter.C
+
+				// Search movies using TMDB client
+				movies, err := client.searchMovies(query)
+				if err != nil {
+					fmt.Printf("[%d/%d] %s: Error: %v\n", i+1, len(searchQueries), query, err)
+					continue
+				}
+
+				// Add to database
+				added := 0
+				for _, movie := range movies {
+					movieInfo := MovieInfo{
+						ID:          fmt.Sprintf("%d", movie.ID),
+						Title:       movie.Title,
+						Year:        extractYear(movie.ReleaseDate),
+						Description: movie.Overview,
+						Genres:      movie.Genres,
+						Rating:      movie.Rating,
+						Source:      "TMDB",
+						LastUpdated: time.Now().Format(time.RFC3339),
+					}
+
+					// Only counts movies that weren't already in the database
+					if db.AddNew(movieInfo) {
+						added++
+					}
+				}
+
+				fmt.Printf("[%d/%d] %s: Added %d new movies (found %d total)\n",
+					i+1, len(searchQueries), query, added, len(movies))
 			}
+		}()
+	}
 
-			// Only add if not duplicate
-			if _, exists := db.Movies[movieInfo.ID]; !exists {
-				db.Add(movieInfo)
-				added++
-			}
-		}
-
-		fmt.Printf("  Added %d new movies (found %d total)\n", added, len(movies))
-
-		// Rate limiting: 1 request per second
-		time.Sleep(1 * time.Second)
+	for i := range searchQueries {
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
 
 	db.LastUpdated = time.Now()
 	fmt.Printf("\nDatabase building complete!\n")
@@ -388,13 +590,13 @@ func extractYear(dateStr string) int {
 // ============================================================================
 
 func main() {
-	fmt.Println("=== Movie Database Builder ===\n")
+	fmt.Print("=== Movie Database Builder ===\n\n")
 
 	apiKey := "33be097c32c7ec8df2864b26e113d643" // Replace with your key
 
 	// Build database
 	fmt.Println("Starting database collection...")
-	db, err := buildMovieDatabase(apiKey)
+	db, err := buildMovieDatabase(NewTMDBClient(apiKey), defaultConcurrency)
 	if err != nil {
 		fmt.Printf("Error building database: %v\n", err)
 		return
@@ -433,6 +635,14 @@ func main() {
 		}
 	}
 
+	// Search by rating
+	fmt.Println("\nTop rated (8.0 - 10.0):")
+	for i, movie := range db.GetByRatingRange(8.0, 10.0) {
+		if i < 3 {
+			fmt.Printf("  %d. %s (%d) - Rating: %.1f\n", i+1, movie.Title, movie.Year, movie.Rating)
+		}
+	}
+
 	// Save to file
 	filename := "movie_database.json"
 	err = db.Save(filename)
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func sampleMovie() MovieInfo {
+	return MovieInfo{
+		ID:       "1",
+		Title:    "Inception",
+		Year:     2010,
+		Rating:   8.4,
+		Genres:   []string{"Action", "Science Fiction"},
+		Director: "Christopher Nolan",
+	}
+}
+
+// Output of db.PrintStatistics
+func captureStatistics(t *testing.T, db *MovieDatabase) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	db.PrintStatistics()
+	os.Stdout = stdout
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func assertIndexesEmpty(t *testing.T, db *MovieDatabase) {
+	t.Helper()
+	if len(db.Genres) != 0 {
+		t.Errorf("Genres = %v, want empty", db.Genres)
+	}
+	if len(db.Directors) != 0 {
+		t.Errorf("Directors = %v, want empty", db.Directors)
+	}
+	if len(db.Years) != 0 {
+		t.Errorf("Years = %v, want empty", db.Years)
+	}
+}
+
+func TestDeleteRemovesMovieFromIndexes(t *testing.T) {
+	db := NewMovieDatabase()
+	movie := sampleMovie()
+	if err := db.Add(movie); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(movie.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if len(db.Movies) != 0 || db.TotalCount != 0 {
+		t.Errorf("Movies = %d, TotalCount = %d, want 0 and 0", len(db.Movies), db.TotalCount)
+	}
+	assertIndexesEmpty(t, db)
+
+	stats := captureStatistics(t, db)
+	for _, want := range []string{"Total Movies: 0", "Total Genres: 0", "Total Directors: 0", "Year Range: 0 entries"} {
+		if !strings.Contains(stats, want) {
+			t.Errorf("PrintStatistics output missing %q:\n%s", want, stats)
+		}
+	}
+	if strings.Contains(stats, "Science Fiction") {
+		t.Errorf("PrintStatistics still lists a genre of the deleted movie:\n%s", stats)
+	}
+}
+
+func TestDeleteKeepsOtherMoviesIndexed(t *testing.T) {
+	db := NewMovieDatabase()
+	first := sampleMovie()
+	second := sampleMovie()
+	second.ID, second.Title, second.Genres = "2", "Interstellar", []string{"Science Fiction"}
+	db.Add(first)
+	db.Add(second)
+
+	if err := db.Delete(first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := db.Genres["Action"]; ok {
+		t.Error(`Genres["Action"] still exists after its only movie was deleted`)
+	}
+	if got := db.Genres["Science Fiction"]; len(got) != 1 || got[0] != "2" {
+		t.Errorf(`Genres["Science Fiction"] = %v, want [2]`, got)
+	}
+	if got := db.Directors["Christopher Nolan"]; len(got) != 1 || got[0] != "2" {
+		t.Errorf(`Directors["Christopher Nolan"] = %v, want [2]`, got)
+	}
+	if db.TotalCount != 1 {
+		t.Errorf("TotalCount = %d, want 1", db.TotalCount)
+	}
+}
+
+func TestDeleteMissingMovie(t *testing.T) {
+	db := NewMovieDatabase()
+	if err := db.Delete("missing"); err == nil {
+		t.Error("Delete of a missing movie returned nil error")
+	}
+}
+
+func TestUpdateReindexesMovie(t *testing.T) {
+	db := NewMovieDatabase()
+	movie := sampleMovie()
+	db.Add(movie)
+
+	movie.Genres = []string{"Thriller"}
+	movie.Director = "Someone Else"
+	movie.Year = 2011
+	if err := db.Update(movie); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	for _, stale := range []string{"Action", "Science Fiction"} {
+		if _, ok := db.Genres[stale]; ok {
+			t.Errorf("Genres[%q] still exists after Update", stale)
+		}
+	}
+	if _, ok := db.Directors["Christopher Nolan"]; ok {
+		t.Error(`Directors["Christopher Nolan"] still exists after Update`)
+	}
+	if _, ok := db.Years[2010]; ok {
+		t.Error("Years[2010] still exists after Update")
+	}
+
+	if got, _ := db.GetByGenre("Thriller"); len(got) != 1 {
+		t.Errorf(`GetByGenre("Thriller") returned %d movies, want 1`, len(got))
+	}
+	if got, _ := db.GetByDirector("Someone Else"); len(got) != 1 {
+		t.Errorf(`GetByDirector("Someone Else") returned %d movies, want 1`, len(got))
+	}
+	if got, _ := db.GetByYear(2011); len(got) != 1 {
+		t.Errorf("GetByYear(2011) returned %d movies, want 1", len(got))
+	}
+
+	// Deleting after an update must leave nothing behind either
+	if err := db.Delete(movie.ID); err != nil {
+		t.Fatal(err)
+	}
+	assertIndexesEmpty(t, db)
+}
+
+func ratingDB() *MovieDatabase {
+	db := NewMovieDatabase()
+	for _, m := range []MovieInfo{
+		{ID: "1", Title: "Low", Rating: 5.0},
+		{ID: "2", Title: "Mid", Rating: 7.0},
+		{ID: "3", Title: "High", Rating: 8.5},
+		{ID: "4", Title: "Also Mid", Rating: 7.0},
+	} {
+		db.Add(m)
+	}
+	return db
+}
+
+func titles(movies []MovieInfo) []string {
+	var out []string
+	for _, m := range movies {
+		out = append(out, m.Title)
+	}
+	return out
+}
+
+func TestGetByRatingRange(t *testing.T) {
+	db := ratingDB()
+
+	tests := []struct {
+		name     string
+		min, max float64
+		want     []string
+	}{
+		{"bounds are inclusive", 7.0, 8.5, []string{"High", "Also Mid", "Mid"}},
+		{"single rating", 7.0, 7.0, []string{"Also Mid", "Mid"}},
+		{"everything", 0, 10, []string{"High", "Also Mid", "Mid", "Low"}},
+		{"no movies in range", 9.0, 10, nil},
+		{"min above max", 8.0, 6.0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := db.GetByRatingRange(tt.min, tt.max)
+			if got == nil {
+				t.Fatal("GetByRatingRange returned nil, want an empty slice")
+			}
+			if strings.Join(titles(got), ",") != strings.Join(tt.want, ",") {
+				t.Errorf("GetByRatingRange(%v, %v) = %v, want %v", tt.min, tt.max, titles(got), tt.want)
+			}
+		})
+	}
+}
+
+// Fake TMDB: every search returns the same two shared movies plus one movie
+// of its own, so workers keep adding duplicates concurrently
+func newMockTMDB(t *testing.T) (*httptest.Server, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	queries := make(map[string]int)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/genre/movie/list":
+			json.NewEncoder(w).Encode(TMDBGenreResponse{Genres: []struct {
+				ID   int    `json:"id"`
+				Name string `json:"name"`
+			}{{ID: 28, Name: "Action"}, {ID: 35, Name: "Comedy"}}})
+		case "/search/movie":
+			mu.Lock()
+			query := r.URL.Query().Get("query")
+			if _, ok := queries[query]; !ok {
+				queries[query] = 1000 + len(queries)
+			}
+			own := queries[query]
+			mu.Unlock()
+
+			json.NewEncoder(w).Encode(TMDBSearchResponse{Results: []TMDBMovie{
+				{ID: 1, Title: "Shared One", ReleaseDate: "2020-05-01", Rating: 7, GenreIDs: []int{28}},
+				{ID: 2, Title: "Shared Two", ReleaseDate: "2021-05-01", Rating: 6, GenreIDs: []int{28, 35}},
+				{ID: own, Title: "Only " + query, ReleaseDate: "2022-05-01", Rating: 5, GenreIDs: []int{28}},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(queries)
+	}
+}
+
+func TestBuildMovieDatabaseWithMockServer(t *testing.T) {
+	srv, queryCount := newMockTMDB(t)
+	client := NewTMDBClient("test-key")
+	client.BaseURL = srv.URL
+
+	start := time.Now()
+	db, err := buildMovieDatabase(client, 4)
+	if err != nil {
+		t.Fatalf("buildMovieDatabase: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	queries := queryCount()
+	want := 2 + queries
+	if db.TotalCount != want || len(db.Movies) != want {
+		t.Errorf("TotalCount = %d, Movies = %d, want %d for %d queries", db.TotalCount, len(db.Movies), want, queries)
+	}
+
+	// Each movie indexed exactly once despite being found by many workers
+	if got := len(db.Genres["Action"]); got != want {
+		t.Errorf(`Genres["Action"] has %d IDs, want %d`, got, want)
+	}
+	if got := db.Genres["Comedy"]; len(got) != 1 || got[0] != "2" {
+		t.Errorf(`Genres["Comedy"] = %v, want [2]`, got)
+	}
+	if got := len(db.Years[2022]); got != queries {
+		t.Errorf("Years[2022] has %d IDs, want %d", got, queries)
+	}
+	for _, year := range []int{2020, 2021} {
+		if got := len(db.Years[year]); got != 1 {
+			t.Errorf("Years[%d] has %d IDs, want 1", year, got)
+		}
+	}
+
+	// The shared limiter spaces all searches, whatever the worker count
+	if min := time.Duration(queries-1) * requestInterval; elapsed < min {
+		t.Errorf("%d searches took %v, the rate limit allows no less than %v", queries, elapsed, min)
+	}
+}
+
+func TestConcurrentAddAndRead(t *testing.T) {
+	db := NewMovieDatabase()
+	const writers, perWriter = 8, 50
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				db.Add(MovieInfo{
+					ID:       fmt.Sprintf("%d-%d", w, i),
+					Title:    fmt.Sprintf("Movie %d-%d", w, i),
+					Year:     2000 + i%10,
+					Rating:   float64(i % 10),
+					Genres:   []string{"Drama"},
+					Director: fmt.Sprintf("Director %d", w),
+				})
+			}
+		}(w)
+
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				db.Get(fmt.Sprintf("%d-%d", w, i))
+				db.Search("movie")
+				db.GetByGenre("Drama")
+				db.GetByYear(2000 + i%10)
+				db.GetByDirector(fmt.Sprintf("Director %d", w))
+				db.GetByRatingRange(3, 6)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if db.TotalCount != writers*perWriter {
+		t.Errorf("TotalCount = %d, want %d", db.TotalCount, writers*perWriter)
+	}
+	if got := len(db.Genres["Drama"]); got != writers*perWriter {
+		t.Errorf(`Genres["Drama"] has %d IDs, want %d`, got, writers*perWriter)
+	}
+}
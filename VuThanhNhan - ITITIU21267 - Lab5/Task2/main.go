@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	_ "github.com/mattn/go-sqlite3"
@@ -73,19 +76,31 @@ func seedData() {
 
 // ------------------ CRUD ------------------
 
+// ORDER BY clause for each ?sort= value, shared by /books and
+// /books/advanced-search
+var bookSortOrders = map[string]string{
+	"price_asc":  "price ASC",
+	"price_desc": "price DESC",
+	"title":      "title ASC",
+	"year_desc":  "published_year DESC",
+	"id":         "id ASC",
+}
+
+// Sorted, comma-separated ?sort= values for error messages
+func sortOptions() string {
+	keys := make([]string, 0, len(bookSortOrders))
+	for key := range bookSortOrders {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
 // GET /books with optional filters and sorting
 // Example: /books?min_price=20&max_price=50&author=Martin&year=2008&sort=price_desc
 func getBooks(c *gin.Context) {
 	sortBy := c.DefaultQuery("sort", "id")
-	validSorts := map[string]string{
-		"price_asc":  "price ASC",
-		"price_desc": "price DESC",
-		"title":      "title ASC",
-		"year_desc":  "published_year DESC",
-		"id":         "id ASC",
-	}
-
-	orderBy, ok := validSorts[sortBy]
+	orderBy, ok := bookSortOrders[sortBy]
 	if !ok {
 		orderBy = "id ASC"
 	}
@@ -309,6 +324,83 @@ func filterBooks(c *gin.Context) {
 	})
 }
 
+// GET /books/advanced-search?q=go&min_price=20&max_price=50&year=2015&sort=price_asc
+// Text search on title/author combined with the price/year filters
+func advancedSearch(c *gin.Context) {
+	sortBy := c.DefaultQuery("sort", "id")
+	orderBy, ok := bookSortOrders[sortBy]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort, use one of: " + sortOptions()})
+		return
+	}
+
+	query := "SELECT id, title, author, isbn, price, stock, published_year, description, created_at FROM books WHERE 1=1"
+	var args []interface{}
+	criteria := gin.H{"sort": sortBy}
+
+	if q := c.Query("q"); q != "" {
+		query += " AND (LOWER(title) LIKE LOWER(?) OR LOWER(author) LIKE LOWER(?))"
+		args = append(args, "%"+q+"%", "%"+q+"%")
+		criteria["q"] = q
+	}
+	if s := c.Query("min_price"); s != "" {
+		minPrice, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "min_price must be a number"})
+			return
+		}
+		query += " AND price >= ?"
+		args = append(args, minPrice)
+		criteria["min_price"] = minPrice
+	}
+	if s := c.Query("max_price"); s != "" {
+		maxPrice, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_price must be a number"})
+			return
+		}
+		query += " AND price <= ?"
+		args = append(args, maxPrice)
+		criteria["max_price"] = maxPrice
+	}
+	if s := c.Query("year"); s != "" {
+		year, err := strconv.Atoi(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be an integer"})
+			return
+		}
+		query += " AND published_year = ?"
+		args = append(args, year)
+		criteria["year"] = year
+	}
+
+	query += " ORDER BY " + orderBy
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	books := []Book{}
+	for rows.Next() {
+		var b Book
+		if err := rows.Scan(&b.ID, &b.Title, &b.Author, &b.ISBN, &b.Price, &b.Stock,
+			&b.PublishedYear, &b.Description, &b.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		books = append(books, b)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"books":    books,
+		"count":    len(books),
+		"criteria": criteria,
+	})
+}
+
 // Helper: string to int
 func atoi(s string) int {
 	var i int
@@ -338,6 +430,7 @@ func main() {
 	// Advanced queries
 	router.GET("/books/search", searchBooks)
 	router.GET("/books/filter", filterBooks)
+	router.GET("/books/advanced-search", advancedSearch)
 
 	fmt.Println("🚀 Server running on :8080")
 	router.Run(":8080")
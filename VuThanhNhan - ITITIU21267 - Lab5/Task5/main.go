@@ -1,31 +1,60 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mattn/go-sqlite3"
+	"golang.org/x/time/rate"
 )
 
 type Book struct {
-	ID            int     `json:"id"`
-	Title         string  `json:"title" binding:"required,min=3"`
-	Author        string  `json:"author"`
-	AuthorID      *int    `json:"author_id"`
-	ISBN          string  `json:"isbn" binding:"required"`
-	Price         float64 `json:"price" binding:"required,min=0.01,max=1000"`
-	Stock         int     `json:"stock" binding:"gte=0"`
-	PublishedYear int     `json:"published_year"`
-	Description   string  `json:"description"`
-	CreatedAt     string  `json:"created_at"`
+	ID               int     `json:"id"`
+	Title            string  `json:"title" binding:"required,min=3"`
+	Author           string  `json:"author"`
+	AuthorID         *int    `json:"author_id"`
+	ISBN             string  `json:"isbn" binding:"required"`
+	Price            float64 `json:"price" binding:"required,min=0.01,max_price"`
+	Stock            int     `json:"stock" binding:"gte=0"`
+	PublishedYear    int     `json:"published_year"`
+	Description      string  `json:"description"`
+	Genre            string  `json:"genre" binding:"omitempty,genre"`
+	ReorderThreshold *int    `json:"reorder_threshold" binding:"omitempty,gte=0"`
+	Version          int     `json:"version,omitempty"`
+	CreatedAt        string  `json:"created_at"`
 }
 
+// Low-stock level used when a book is created without reorder_threshold
+const defaultReorderThreshold = 10
+
 type Author struct {
 	ID        int    `json:"id"`
 	Name      string `json:"name" binding:"required"`
@@ -36,16 +65,49 @@ type Author struct {
 }
 
 type BookWithAuthor struct {
+	ID             int     `json:"id"`
+	Title          string  `json:"title"`
+	AuthorID       *int    `json:"author_id"`
+	AuthorName     string  `json:"author_name"`
+	ISBN           string  `json:"isbn"`
+	Price          float64 `json:"price"`
+	Stock          int     `json:"stock"`
+	PublishedYear  int     `json:"published_year"`
+	Description    string  `json:"description"`
+	Genre          string  `json:"genre,omitempty"`
+	Version        int     `json:"version,omitempty"`
+	CreatedAt      string  `json:"created_at"`
+	Currency       string  `json:"currency,omitempty"`
+	FormattedPrice string  `json:"formatted_price,omitempty"`
+}
+
+type LowStockBook struct {
+	BookWithAuthor
+	ReorderThreshold int `json:"reorder_threshold"`
+	Shortfall        int `json:"shortfall"`
+}
+
+type ExportRow struct {
 	ID            int     `json:"id"`
 	Title         string  `json:"title"`
-	AuthorID      *int    `json:"author_id"`
 	AuthorName    string  `json:"author_name"`
 	ISBN          string  `json:"isbn"`
 	Price         float64 `json:"price"`
 	Stock         int     `json:"stock"`
 	PublishedYear int     `json:"published_year"`
-	Description   string  `json:"description"`
-	CreatedAt     string  `json:"created_at"`
+}
+
+type ImportResponse struct {
+	Success        int            `json:"success"`
+	Failed         int            `json:"failed"`
+	CreatedBooks   []Book         `json:"created_books"`
+	AuthorsCreated []string       `json:"authors_created,omitempty"`
+	Errors         map[int]string `json:"errors,omitempty"` // keyed by CSV line number
+}
+
+type RelatedBook struct {
+	BookWithAuthor
+	Reason string `json:"reason"`
 }
 
 type PaginationMeta struct {
@@ -62,6 +124,23 @@ type PaginatedBooksResponse struct {
 	Pagination PaginationMeta   `json:"pagination"`
 }
 
+type SearchResult struct {
+	BookWithAuthor
+	MatchField string `json:"match_field"`
+}
+
+type SearchResponse struct {
+	Query      string         `json:"query"`
+	Results    []SearchResult `json:"results"`
+	Pagination PaginationMeta `json:"pagination"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
 type Statistics struct {
 	TotalBooks    int                `json:"total_books"`
 	TotalAuthors  int                `json:"total_authors"`
@@ -72,9 +151,62 @@ type Statistics struct {
 	Cheapest      *BookWithAuthor    `json:"cheapest"`
 	MostStocked   *BookWithAuthor    `json:"most_stocked"`
 	BooksByYear   map[int]int        `json:"books_by_year"`
+	BooksByGenre  map[string]int     `json:"books_by_genre"`
 	AveragePrice  float64            `json:"average_price"`
 }
 
+// BookPatch holds the fields of a partial update; nil means "leave unchanged"
+type BookPatch struct {
+	Title            *string  `json:"title" binding:"omitempty,min=3"`
+	AuthorID         *int     `json:"author_id"`
+	ISBN             *string  `json:"isbn"`
+	Price            *float64 `json:"price" binding:"omitempty,min=0.01,max_price"`
+	Stock            *int     `json:"stock" binding:"omitempty,gte=0"`
+	PublishedYear    *int     `json:"published_year"`
+	Description      *string  `json:"description"`
+	Genre            *string  `json:"genre" binding:"omitempty,genre"`
+	ReorderThreshold *int     `json:"reorder_threshold" binding:"omitempty,gte=0"`
+	Version          *int     `json:"version"` // optional: reject the patch if the book has moved on
+}
+
+type YearCount struct {
+	Year  int `json:"year"`
+	Count int `json:"count"`
+}
+
+type BooksByYearResponse struct {
+	From       int              `json:"from"`
+	To         int              `json:"to"`
+	Books      []BookWithAuthor `json:"books"`
+	Summary    []YearCount      `json:"summary"` // every matching book, not just this page
+	Pagination PaginationMeta   `json:"pagination"`
+}
+
+type InventoryLogEntry struct {
+	ID           int    `json:"id"`
+	BookID       int    `json:"book_id"`
+	Change       int    `json:"change"`
+	Reason       string `json:"reason"`
+	BalanceAfter int    `json:"balance_after"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type AuthorStatistics struct {
+	AuthorID           int     `json:"author_id"`
+	AuthorName         string  `json:"author_name"`
+	TotalBooks         int     `json:"total_books"`
+	TotalValue         float64 `json:"total_value"`
+	AveragePrice       float64 `json:"average_price"`
+	EarliestYear       *int    `json:"earliest_year"`
+	LatestYear         *int    `json:"latest_year"`
+	MostExpensiveTitle *string `json:"most_expensive_title"`
+	CheapestTitle      *string `json:"cheapest_title"`
+}
+
+type MergeAuthorsRequest struct {
+	TargetID int `json:"target_id" binding:"required,gt=0"`
+}
+
 type RestockRequest struct {
 	Quantity int `json:"quantity" binding:"required,gt=0"`
 }
@@ -84,7 +216,8 @@ type SellRequest struct {
 }
 
 type BulkCreateRequest struct {
-	Books []Book `json:"books" binding:"required,min=1,dive"`
+	Books         []Book `json:"books" binding:"required,min=1,dive"`
+	Transactional bool   `json:"transactional"`
 }
 
 type BulkCreateResponse struct {
@@ -96,64 +229,270 @@ type BulkCreateResponse struct {
 
 var db *sql.DB
 
+// Common subset of *sql.DB and *sql.Tx so helpers can run inside a transaction
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 func initDB() error {
 	var err error
-	db, err = sql.Open("sqlite3", "./bookstore.db")
+	// _busy_timeout makes a connection wait up to 5s for a lock instead of failing
+	// with "database is locked"; _txlock=immediate takes the write lock at BEGIN so
+	// two transactions can't deadlock upgrading from read to write.
+	db, err = sql.Open("sqlite3", "./bookstore.db?_busy_timeout=5000&_txlock=immediate")
+	if err != nil {
+		return err
+	}
+
+	// SQLite allows one writer at a time, so a large pool only adds lock contention.
+	// 10 open connections still lets reads proceed in parallel with a write,
+	// 5 idle connections covers normal traffic without reopening the file,
+	// and recycling every 30 minutes keeps long-lived connections from going stale.
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	return runMigrations()
+}
+
+// Schema Migrations
+
+// A numbered schema change. Versions are applied in order, each once, and
+// recorded in schema_migrations so an existing bookstore.db upgrades in place.
+// Statements stay idempotent because databases created before the runner
+// existed already have some of these tables and columns.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// Append new migrations at the end; never renumber or edit applied ones
+var migrations = []migration{
+	{1, "create_authors", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS authors (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			bio TEXT,
+			birth_year INTEGER,
+			country TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);`)
+		return err
+	}},
+	{2, "create_books", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS books (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			title TEXT NOT NULL,
+			author TEXT,
+			author_id INTEGER,
+			isbn TEXT UNIQUE,
+			price REAL NOT NULL CHECK(price > 0),
+			stock INTEGER DEFAULT 0,
+			published_year INTEGER,
+			description TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (author_id) REFERENCES authors(id)
+		);`)
+		return err
+	}},
+	{3, "books_soft_delete", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "books", "deleted_at", "DATETIME")
+	}},
+	{4, "books_reorder_threshold", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "books", "reorder_threshold", "INTEGER DEFAULT 10")
+	}},
+	// Audit trail of stock movements
+	{5, "create_inventory_log", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS inventory_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			book_id INTEGER NOT NULL,
+			change INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			balance_after INTEGER NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (book_id) REFERENCES books(id)
+		);`)
+		return err
+	}},
+	// ALTER TABLE can't use a CURRENT_TIMESTAMP default; readers fall back to created_at
+	{6, "updated_at_columns", func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "books", "updated_at", "DATETIME"); err != nil {
+			return err
+		}
+		return addColumnIfMissing(tx, "authors", "updated_at", "DATETIME")
+	}},
+	{7, "books_version", func(tx *sql.Tx) error {
+		return addColumnIfMissing(tx, "books", "version", "INTEGER DEFAULT 1")
+	}},
+	{8, "books_genre", func(tx *sql.Tx) error {
+		if err := addColumnIfMissing(tx, "books", "genre", "TEXT"); err != nil {
+			return err
+		}
+		// Give the sample books a genre; anything else stays uncategorized.
+		// The pairs are spelled out so later seed edits can't change this migration.
+		sampleGenres := []struct{ isbn, genre string }{
+			{"978-0134190440", "programming"},
+			{"978-0132350884", "software-engineering"},
+			{"978-0201633610", "software-engineering"},
+			{"978-0201616224", "software-engineering"},
+			{"978-0735619678", "software-engineering"},
+		}
+		for _, g := range sampleGenres {
+			if _, err := tx.Exec("UPDATE books SET genre = ? WHERE isbn = ? AND genre IS NULL", g.genre, g.isbn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+}
+
+// Apply every migration newer than the recorded ones, each in its own transaction
+func runMigrations() error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);`)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[int]bool)
+	rows, err := db.Query("SELECT version FROM schema_migrations")
 	if err != nil {
 		return err
 	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	ran := 0
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if err := m.up(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+		log.Printf("Applied migration %d: %s", m.version, m.name)
+		ran++
+	}
+	if ran == 0 {
+		log.Printf("Database schema up to date (version %d)", migrations[len(migrations)-1].version)
+	}
+	return nil
+}
 
-	// Create authors table first
-	createAuthorsSQL := `
-	CREATE TABLE IF NOT EXISTS authors (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL UNIQUE,
-		bio TEXT,
-		birth_year INTEGER,
-		country TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);`
+// Millisecond timestamp for updated_at so rapid successive edits still get distinct ETags
+const sqlNowMillis = `strftime('%Y-%m-%d %H:%M:%f', 'now')`
 
-	_, err = db.Exec(createAuthorsSQL)
+// Helper to add a column to an existing table (CREATE TABLE IF NOT EXISTS won't)
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-	// Create books table with author_id
-	createBooksSQL := `
-	CREATE TABLE IF NOT EXISTS books (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		author TEXT,
-		author_id INTEGER,
-		isbn TEXT UNIQUE,
-		price REAL NOT NULL CHECK(price > 0),
-		stock INTEGER DEFAULT 0,
-		published_year INTEGER,
-		description TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (author_id) REFERENCES authors(id)
-	);`
-
-	_, err = db.Exec(createBooksSQL)
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
-func seedAuthors() {
+// Seed Data
+
+// A book to seed, linked to its author by name
+type seedBook struct {
+	Book
+	AuthorName string `json:"author_name"`
+}
+
+// Authors and books loaded into an empty database; -seed-file reads one from JSON:
+// {"authors": [{"name": ...}], "books": [{"title": ..., "author_name": ...}]}
+type seedFixture struct {
+	Authors []Author   `json:"authors"`
+	Books   []seedBook `json:"books"`
+}
+
+func defaultSeed() seedFixture {
+	return seedFixture{
+		Authors: []Author{
+			{Name: "Robert C. Martin", Bio: "Software engineer and influential author on software craftsmanship", BirthYear: 1952, Country: "USA"},
+			{Name: "Erich Gamma", Bio: "One of the Gang of Four, design patterns pioneer", BirthYear: 1961, Country: "Switzerland"},
+			{Name: "Alan Donovan", Bio: "Go team member at Google, co-author of The Go Programming Language", BirthYear: 0, Country: "USA"},
+			{Name: "Andrew Hunt", Bio: "Co-author of The Pragmatic Programmer, software consultant", BirthYear: 0, Country: "USA"},
+			{Name: "Steve McConnell", Bio: "Software engineering author and consultant", BirthYear: 1962, Country: "USA"},
+		},
+		Books: []seedBook{
+			{Book{Title: "The Go Programming Language", ISBN: "978-0134190440", Price: 39.99, Stock: 15, PublishedYear: 2015, Description: "Complete guide to Go programming", Genre: "programming"}, "Alan Donovan"},
+			{Book{Title: "Clean Code", ISBN: "978-0132350884", Price: 29.99, Stock: 20, PublishedYear: 2008, Description: "A Handbook of Agile Software Craftsmanship", Genre: "software-engineering"}, "Robert C. Martin"},
+			{Book{Title: "Design Patterns", ISBN: "978-0201633610", Price: 49.99, Stock: 10, PublishedYear: 1994, Description: "Elements of Reusable Object-Oriented Software", Genre: "software-engineering"}, "Erich Gamma"},
+			{Book{Title: "The Pragmatic Programmer", ISBN: "978-0201616224", Price: 35.99, Stock: 12, PublishedYear: 1999, Description: "From Journeyman to Master", Genre: "software-engineering"}, "Andrew Hunt"},
+			{Book{Title: "Code Complete", ISBN: "978-0735619678", Price: 38.99, Stock: 8, PublishedYear: 2004, Description: "A Practical Handbook of Software Construction", Genre: "software-engineering"}, "Steve McConnell"},
+		},
+	}
+}
+
+func loadSeedFile(path string) (seedFixture, error) {
+	var fixture seedFixture
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fixture, err
+	}
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return fixture, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+func seedAuthors(authors []Author) {
 	var count int
 	db.QueryRow("SELECT COUNT(*) FROM authors").Scan(&count)
 	if count > 0 {
 		return
 	}
 
-	authors := []Author{
-		{Name: "Robert C. Martin", Bio: "Software engineer and influential author on software craftsmanship", BirthYear: 1952, Country: "USA"},
-		{Name: "Erich Gamma", Bio: "One of the Gang of Four, design patterns pioneer", BirthYear: 1961, Country: "Switzerland"},
-		{Name: "Alan Donovan", Bio: "Go team member at Google, co-author of The Go Programming Language", BirthYear: 0, Country: "USA"},
-		{Name: "Andrew Hunt", Bio: "Co-author of The Pragmatic Programmer, software consultant", BirthYear: 0, Country: "USA"},
-		{Name: "Steve McConnell", Bio: "Software engineering author and consultant", BirthYear: 1962, Country: "USA"},
-	}
-
 	for _, a := range authors {
 		_, err := db.Exec(`INSERT INTO authors (name, bio, birth_year, country) VALUES (?, ?, ?, ?)`,
 			a.Name, a.Bio, a.BirthYear, a.Country)
@@ -163,7 +502,7 @@ func seedAuthors() {
 	}
 }
 
-func seedData() {
+func seedData(books []seedBook) {
 	var count int
 	db.QueryRow("SELECT COUNT(*) FROM books").Scan(&count)
 	if count > 0 {
@@ -181,22 +520,19 @@ func seedData() {
 	}
 	rows.Close()
 
-	sampleBooks := []struct {
-		Book
-		AuthorName string
-	}{
-		{Book{Title: "The Go Programming Language", ISBN: "978-0134190440", Price: 39.99, Stock: 15, PublishedYear: 2015, Description: "Complete guide to Go programming"}, "Alan Donovan"},
-		{Book{Title: "Clean Code", ISBN: "978-0132350884", Price: 29.99, Stock: 20, PublishedYear: 2008, Description: "A Handbook of Agile Software Craftsmanship"}, "Robert C. Martin"},
-		{Book{Title: "Design Patterns", ISBN: "978-0201633610", Price: 49.99, Stock: 10, PublishedYear: 1994, Description: "Elements of Reusable Object-Oriented Software"}, "Erich Gamma"},
-		{Book{Title: "The Pragmatic Programmer", ISBN: "978-0201616224", Price: 35.99, Stock: 12, PublishedYear: 1999, Description: "From Journeyman to Master"}, "Andrew Hunt"},
-		{Book{Title: "Code Complete", ISBN: "978-0735619678", Price: 38.99, Stock: 8, PublishedYear: 2004, Description: "A Practical Handbook of Software Construction"}, "Steve McConnell"},
-	}
-
-	for _, b := range sampleBooks {
-		authorID := authorIDs[b.AuthorName]
+	for _, b := range books {
+		authorID := b.AuthorID
+		if b.AuthorName != "" {
+			id, ok := authorIDs[b.AuthorName]
+			if !ok {
+				log.Println("Failed to seed book:", b.Title, "unknown author", b.AuthorName)
+				continue
+			}
+			authorID = &id
+		}
 		_, err := db.Exec(`INSERT INTO books 
-		(title, author_id, isbn, price, stock, published_year, description) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-			b.Title, authorID, b.ISBN, b.Price, b.Stock, b.PublishedYear, b.Description)
+		(title, author_id, isbn, price, stock, published_year, description, genre) VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))`,
+			b.Title, authorID, b.ISBN, b.Price, b.Stock, b.PublishedYear, b.Description, b.Genre)
 		if err != nil {
 			log.Println("Failed to seed book:", b.Title, err)
 		}
@@ -231,6 +567,198 @@ func validatePublishedYear(year int) error {
 	return nil
 }
 
+// Report validation errors by their JSON names rather than Go field names
+func registerJSONTagNames() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+			name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// Highest accepted book price in USD, set by MAX_PRICE or -max-price
+var maxBookPrice = 1000.0
+
+// "max_price" checks against maxBookPrice at request time, so the cap can
+// change with configuration instead of being fixed in the struct tag
+func registerMaxPriceValidator() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("max_price", func(fl validator.FieldLevel) bool {
+			return fl.Field().Float() <= maxBookPrice
+		})
+	}
+}
+
+// Genres accepted on books, set by -genres; with -strict-genres=false any genre is allowed
+var (
+	allowedGenres = []string{"programming", "software-engineering", "computer-science", "fiction",
+		"non-fiction", "science", "history", "biography", "business"}
+	strictGenres = true
+)
+
+func registerGenreValidator() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterValidation("genre", func(fl validator.FieldLevel) bool {
+			// "" is allowed so PATCH can clear a genre
+			genre := fl.Field().String()
+			return genre == "" || !strictGenres || slices.Contains(allowedGenres, genre)
+		})
+	}
+}
+
+// Convert binding errors into a list of {field, tag, message} objects
+func validationErrors(err error) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		// Malformed JSON, wrong types, empty body...
+		return []FieldError{{Field: "body", Tag: "invalid", Message: err.Error()}}
+	}
+
+	out := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		// Drop the struct name: "BulkCreateRequest.books[0].title" -> "books[0].title"
+		field := fe.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+		out = append(out, FieldError{
+			Field:   field,
+			Tag:     fe.Tag(),
+			Message: validationMessage(field, fe),
+		})
+	}
+	return out
+}
+
+func validationMessage(field string, fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	isList := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map
+
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "min":
+		if isString {
+			return fmt.Sprintf("%s must be at least %s characters", field, fe.Param())
+		}
+		if isList {
+			return fmt.Sprintf("%s must contain at least %s items", field, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("%s must be at most %s characters", field, fe.Param())
+		}
+		if isList {
+			return fmt.Sprintf("%s must contain at most %s items", field, fe.Param())
+		}
+		return fmt.Sprintf("%s must be at most %s", field, fe.Param())
+	case "genre":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.Join(allowedGenres, ", "))
+	case "max_price":
+		return fmt.Sprintf("%s must be at most %.2f (the configured price cap)", field, maxBookPrice)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be greater than or equal to %s", field, fe.Param())
+	default:
+		return fmt.Sprintf("%s failed the '%s' validation", field, fe.Tag())
+	}
+}
+
+// Currency conversion
+
+type currencyInfo struct {
+	Rate   float64 // units per 1 USD
+	Symbol string
+}
+
+// Static exchange-rate table, USD base; stored prices are always USD
+var exchangeRates = map[string]currencyInfo{
+	"USD": {Rate: 1, Symbol: "$"},
+	"EUR": {Rate: 0.92, Symbol: "€"},
+	"GBP": {Rate: 0.79, Symbol: "£"},
+	"JPY": {Rate: 149.50, Symbol: "¥"},
+	"VND": {Rate: 25400, Symbol: "₫"},
+}
+
+// ?currency=EUR, falling back to USD when absent or unknown
+func resolveCurrency(c *gin.Context) string {
+	code := strings.ToUpper(c.Query("currency"))
+	if _, ok := exchangeRates[code]; !ok {
+		return "USD"
+	}
+	return code
+}
+
+func convertPrice(usd float64, code string) float64 {
+	return roundToCents(usd * exchangeRates[code].Rate)
+}
+
+func roundToCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func formatPrice(amount float64, code string) string {
+	return fmt.Sprintf("%s%.2f", exchangeRates[code].Symbol, amount)
+}
+
+// Convert a book's price for the response only
+func applyCurrency(b *BookWithAuthor, code string) {
+	b.Price = convertPrice(b.Price, code)
+	b.Currency = code
+	b.FormattedPrice = formatPrice(b.Price, code)
+}
+
+// Unique constraints
+
+var errDuplicate = errors.New("duplicate ISBN")
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// Describe which book already holds isbn; soft-deleted books keep their
+// ISBN reserved so they can still be restored.
+func duplicateISBNError(ctx context.Context, q queryer, isbn string, cause error) error {
+	var id int
+	var title string
+	var deletedAt sql.NullString
+	err := q.QueryRowContext(ctx, `SELECT id, title, deleted_at FROM books WHERE isbn = ?`, isbn).Scan(&id, &title, &deletedAt)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errDuplicate, cause)
+	}
+	if deletedAt.Valid {
+		return fmt.Errorf("%w: %s belongs to deleted book %d '%s' (restore it instead)", errDuplicate, isbn, id, title)
+	}
+	return fmt.Errorf("%w: %s already belongs to book %d '%s'", errDuplicate, isbn, id, title)
+}
+
+// Build a strong ETag from a resource's identity and last-modified marker
+func makeETag(kind string, id int, version string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s:%d:%s", kind, id, version)))
+	return fmt.Sprintf(`"%x"`, sum[:8])
+}
+
+// Set the ETag header and answer 304 if the client's If-None-Match already has it
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
 // Helper function to parse integer query parameters
 func parseIntQuery(c *gin.Context, key string, defaultValue int) int {
 	valueStr := c.Query(key)
@@ -250,9 +778,12 @@ func parseIntQuery(c *gin.Context, key string, defaultValue int) int {
 
 // GET /authors
 func getAuthors(c *gin.Context) {
-	rows, err := db.Query("SELECT id, name, bio, birth_year, country, created_at FROM authors ORDER BY name")
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT id, name, bio, birth_year, country, created_at FROM authors ORDER BY name")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	defer rows.Close()
@@ -262,7 +793,7 @@ func getAuthors(c *gin.Context) {
 		var a Author
 		err := rows.Scan(&a.ID, &a.Name, &a.Bio, &a.BirthYear, &a.Country, &a.CreatedAt)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 			return
 		}
 		authors = append(authors, a)
@@ -276,65 +807,97 @@ func getAuthors(c *gin.Context) {
 
 // GET /authors/:id
 func getAuthor(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	id := c.Param("id")
 	var a Author
-	err := db.QueryRow(`SELECT id, name, bio, birth_year, country, created_at 
+	var updatedAt string
+	err := db.QueryRowContext(ctx, `SELECT id, name, bio, birth_year, country, created_at,
+	COALESCE(updated_at, created_at)
 	FROM authors WHERE id = ?`, id).Scan(
-		&a.ID, &a.Name, &a.Bio, &a.BirthYear, &a.Country, &a.CreatedAt,
+		&a.ID, &a.Name, &a.Bio, &a.BirthYear, &a.Country, &a.CreatedAt, &updatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 		}
 		return
 	}
+	if notModified(c, makeETag("author", a.ID, updatedAt)) {
+		return
+	}
 	c.JSON(http.StatusOK, a)
 }
 
 // POST /authors
 func createAuthor(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	var a Author
 	if err := c.ShouldBindJSON(&a); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": validationErrors(err)})
 		return
 	}
 
-	result, err := db.Exec(`INSERT INTO authors (name, bio, birth_year, country) VALUES (?, ?, ?, ?)`,
+	result, err := db.ExecContext(ctx, `INSERT INTO authors (name, bio, birth_year, country) VALUES (?, ?, ?, ?)`,
 		a.Name, a.Bio, a.BirthYear, a.Country)
+	if isUniqueViolation(err) {
+		var existingID int
+		if db.QueryRowContext(ctx, `SELECT id FROM authors WHERE name = ?`, a.Name).Scan(&existingID) == nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":       "Author already exists",
+				"details":     fmt.Sprintf("Author '%s' already exists with ID %d", a.Name, existingID),
+				"existing_id": existingID,
+			})
+			return
+		}
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	a.ID = int(id)
-	err = db.QueryRow(`SELECT created_at FROM authors WHERE id = ?`, a.ID).Scan(&a.CreatedAt)
+	err = db.QueryRowContext(ctx, `SELECT created_at FROM authors WHERE id = ?`, a.ID).Scan(&a.CreatedAt)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, a)
 }
 
-// PUT /authors/:id
+// PUT /authors/:id - also keeps the denormalized books.author column in sync
 func updateAuthor(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	id := c.Param("id")
 	var a Author
 	if err := c.ShouldBindJSON(&a); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": validationErrors(err)})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		internalError(c, err)
 		return
 	}
+	defer tx.Rollback()
 
-	res, err := db.Exec(`UPDATE authors SET name=?, bio=?, birth_year=?, country=? WHERE id=?`,
+	res, err := tx.ExecContext(ctx, `UPDATE authors SET name=?, bio=?, birth_year=?, country=?, updated_at=`+sqlNowMillis+` WHERE id=?`,
 		a.Name, a.Bio, a.BirthYear, a.Country, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 
@@ -344,19 +907,44 @@ func updateAuthor(c *gin.Context) {
 		return
 	}
 
+	// Cascade the new name to books that cache it as text
+	res, err = tx.ExecContext(ctx, `UPDATE books SET author = ? WHERE author_id = ?`, a.Name, id)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	booksUpdated, _ := res.RowsAffected()
+
+	err = tx.QueryRowContext(ctx, `SELECT created_at FROM authors WHERE id = ?`, id).Scan(&a.CreatedAt)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		internalError(c, err)
+		return
+	}
+
 	a.ID = atoi(id)
-	c.JSON(http.StatusOK, a)
+	c.JSON(http.StatusOK, struct {
+		Author
+		BooksUpdated int64 `json:"books_updated"`
+	}{a, booksUpdated})
 }
 
 // DELETE /authors/:id
 func deleteAuthor(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	id := c.Param("id")
 
 	// Check if author has books
 	var bookCount int
-	err := db.QueryRow("SELECT COUNT(*) FROM books WHERE author_id = ?", id).Scan(&bookCount)
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books WHERE author_id = ?", id).Scan(&bookCount)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 
@@ -368,9 +956,9 @@ func deleteAuthor(c *gin.Context) {
 		return
 	}
 
-	res, err := db.Exec("DELETE FROM authors WHERE id=?", id)
+	res, err := db.ExecContext(ctx, "DELETE FROM authors WHERE id=?", id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	rowsAffected, _ := res.RowsAffected()
@@ -381,13 +969,87 @@ func deleteAuthor(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Author deleted successfully"})
 }
 
+// POST /authors/:id/merge - move every book of :id to target_id, then delete :id
+func mergeAuthor(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	sourceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid author ID"})
+		return
+	}
+	var req MergeAuthorsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data", "details": validationErrors(err)})
+		return
+	}
+	if req.TargetID == sourceID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge an author into itself"})
+		return
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer tx.Rollback()
+
+	var sourceName, targetName string
+	if err := tx.QueryRowContext(ctx, "SELECT name FROM authors WHERE id = ?", sourceID).Scan(&sourceName); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Author not found", "author_id": sourceID})
+			return
+		}
+		internalError(c, err)
+		return
+	}
+	if err := tx.QueryRowContext(ctx, "SELECT name FROM authors WHERE id = ?", req.TargetID).Scan(&targetName); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Target author not found", "author_id": req.TargetID})
+			return
+		}
+		internalError(c, err)
+		return
+	}
+
+	// Soft-deleted books move too so a later restore doesn't point at a missing author
+	res, err := tx.ExecContext(ctx, `UPDATE books SET author_id = ?, author = ?, updated_at = `+sqlNowMillis+`, version = version + 1
+		WHERE author_id = ?`, req.TargetID, targetName, sourceID)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	booksMoved, _ := res.RowsAffected()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM authors WHERE id = ?", sourceID); err != nil {
+		internalError(c, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		internalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     fmt.Sprintf("Merged '%s' into '%s'", sourceName, targetName),
+		"source_id":   sourceID,
+		"target_id":   req.TargetID,
+		"books_moved": booksMoved,
+	})
+}
+
 // GET /authors/:id/books
 func getAuthorBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	authorID := c.Param("id")
 
 	// Get author details
 	var author Author
-	err := db.QueryRow(`SELECT id, name, bio, birth_year, country, created_at 
+	err := db.QueryRowContext(ctx, `SELECT id, name, bio, birth_year, country, created_at 
 	FROM authors WHERE id = ?`, authorID).Scan(
 		&author.ID, &author.Name, &author.Bio, &author.BirthYear, &author.Country, &author.CreatedAt,
 	)
@@ -395,16 +1057,16 @@ func getAuthorBooks(c *gin.Context) {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 		}
 		return
 	}
 
 	// Get author's books
-	rows, err := db.Query(`SELECT id, title, author_id, isbn, price, stock, published_year, description, created_at 
-	FROM books WHERE author_id = ? ORDER BY published_year DESC`, authorID)
+	rows, err := db.QueryContext(ctx, `SELECT id, title, author_id, isbn, price, stock, published_year, description, created_at 
+	FROM books WHERE author_id = ? AND deleted_at IS NULL ORDER BY published_year DESC`, authorID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	defer rows.Close()
@@ -414,7 +1076,7 @@ func getAuthorBooks(c *gin.Context) {
 		var b Book
 		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &b.ISBN, &b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 			return
 		}
 		books = append(books, b)
@@ -429,8 +1091,31 @@ func getAuthorBooks(c *gin.Context) {
 
 // Modified Book Endpoints
 
-// GET /books - with pagination and author information
+// Whitelisted sort keys for getBooks
+var bookSortOrders = map[string]string{
+	"id":         "b.id ASC",
+	"price_asc":  "b.price ASC, b.id",
+	"price_desc": "b.price DESC, b.id",
+	"title":      "b.title ASC, b.id",
+	"year_desc":  "b.published_year DESC, b.id",
+}
+
+// Accepted sort values, alphabetically, for error messages
+func sortOptions(orders map[string]string) string {
+	keys := make([]string, 0, len(orders))
+	for k := range orders {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ", ")
+}
+
+// GET /books - with pagination, filters and author information
+// Example: /books?page=1&limit=20&author=Martin&min_price=20&max_price=50&year=2008&sort=price_desc
 func getBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	// Parse pagination parameters
 	page := parseIntQuery(c, "page", 1)
 	limit := parseIntQuery(c, "limit", 20)
@@ -443,31 +1128,90 @@ func getBooks(c *gin.Context) {
 		limit = 20
 	}
 
-	// Calculate offset
-	offset := (page - 1) * limit
-
-	// Get total count
-	var total int
-	err := db.QueryRow("SELECT COUNT(*) FROM books").Scan(&total)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to count books",
+	sortBy := c.DefaultQuery("sort", "id")
+	orderBy, ok := bookSortOrders[sortBy]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid sort",
+			"details": "sort must be one of " + sortOptions(bookSortOrders),
 		})
 		return
 	}
 
-	// Query books with LIMIT and OFFSET
-	query := `
-	SELECT b.id, b.title, b.author_id, a.name as author_name,
-	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
-	FROM books b
-	LEFT JOIN authors a ON b.author_id = a.id
-	ORDER BY b.id
-	LIMIT ? OFFSET ?`
+	// Prices are filtered in stored USD, then converted for display
+	currency := resolveCurrency(c)
 
-	rows, err := db.Query(query, limit, offset)
+	// Build filters with placeholders
+	where := " WHERE b.deleted_at IS NULL"
+	var args []interface{}
+
+	if author := c.Query("author"); author != "" {
+		where += " AND LOWER(a.name) LIKE LOWER(?)"
+		args = append(args, "%"+author+"%")
+	}
+	for _, f := range []struct{ param, clause string }{
+		{"min_price", " AND b.price >= ?"},
+		{"max_price", " AND b.price <= ?"},
+	} {
+		if v := c.Query(f.param); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a number", f.param)})
+				return
+			}
+			where += f.clause
+			args = append(args, price)
+		}
+	}
+	if v := c.Query("year"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "year must be an integer"})
+			return
+		}
+		where += " AND b.published_year = ?"
+		args = append(args, year)
+	}
+	if genre := c.Query("genre"); genre != "" {
+		if strictGenres && !slices.Contains(allowedGenres, genre) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid genre",
+				"details": "genre must be one of: " + strings.Join(allowedGenres, ", "),
+			})
+			return
+		}
+		where += " AND b.genre = ?"
+		args = append(args, genre)
+	}
+
+	// Calculate offset
+	offset := (page - 1) * limit
+
+	// Get total count of filtered books
+	var total int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books b LEFT JOIN authors a ON b.author_id = a.id"+where, args...).Scan(&total)
+	if err != nil {
+		if queryTimedOut(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to count books",
+		})
+		return
+	}
+
+	// Query books with LIMIT and OFFSET
+	query := `
+	SELECT b.id, b.title, b.author_id, a.name as author_name,
+	       b.isbn, b.price, b.stock, b.published_year, b.description, COALESCE(b.genre, ''), b.created_at
+	FROM books b
+	LEFT JOIN authors a ON b.author_id = a.id` + where + `
+	ORDER BY ` + orderBy + `
+	LIMIT ? OFFSET ?`
+
+	rows, err := db.QueryContext(ctx, query, append(args, limit, offset)...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	defer rows.Close()
@@ -476,14 +1220,15 @@ func getBooks(c *gin.Context) {
 	for rows.Next() {
 		var b BookWithAuthor
 		var authorName sql.NullString
-		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN, &b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
+		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN, &b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.Genre, &b.CreatedAt)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 			return
 		}
 		if authorName.Valid {
 			b.AuthorName = authorName.String
 		}
+		applyCurrency(&b, currency)
 		books = append(books, b)
 	}
 
@@ -508,40 +1253,55 @@ func getBooks(c *gin.Context) {
 
 // GET /books/:id - with author information
 func getBook(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	id := c.Param("id")
 	var b BookWithAuthor
 	var authorName sql.NullString
+	var bookUpdatedAt, authorUpdatedAt string
 
-	err := db.QueryRow(`SELECT b.id, b.title, b.author_id, a.name as author_name,
-	b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
+	// The author's timestamp is part of the ETag because the body includes author_name
+	err := db.QueryRowContext(ctx, `SELECT b.id, b.title, b.author_id, a.name as author_name,
+	b.isbn, b.price, b.stock, b.published_year, b.description, COALESCE(b.genre, ''), b.created_at, b.version,
+	COALESCE(b.updated_at, b.created_at), COALESCE(a.updated_at, a.created_at, '')
 	FROM books b
 	LEFT JOIN authors a ON b.author_id = a.id
-	WHERE b.id = ?`, id).Scan(
-		&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN, &b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt,
+	WHERE b.id = ? AND b.deleted_at IS NULL`, id).Scan(
+		&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN, &b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.Genre, &b.CreatedAt, &b.Version,
+		&bookUpdatedAt, &authorUpdatedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 		}
 		return
 	}
 	if authorName.Valid {
 		b.AuthorName = authorName.String
 	}
+	currency := resolveCurrency(c)
+	if notModified(c, makeETag("book", b.ID, bookUpdatedAt+"|"+authorUpdatedAt+"|"+currency)) {
+		return
+	}
+	applyCurrency(&b, currency)
 	c.JSON(http.StatusOK, b)
 }
 
 // POST /books - with enhanced validation
 func createBook(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	var b Book
 	
 	// Bind JSON with standard validation
 	if err := c.ShouldBindJSON(&b); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Validation failed",
-			"details": err.Error(),
+			"details": validationErrors(err),
 		})
 		return
 	}
@@ -566,7 +1326,7 @@ func createBook(c *gin.Context) {
 	// Validate author_id if provided
 	if b.AuthorID != nil {
 		var authorExists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM authors WHERE id = ?)", *b.AuthorID).Scan(&authorExists)
+		err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM authors WHERE id = ?)", *b.AuthorID).Scan(&authorExists)
 		if err != nil || !authorExists {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Author not found",
@@ -576,31 +1336,93 @@ func createBook(c *gin.Context) {
 		}
 	}
 
+	if b.ReorderThreshold == nil {
+		threshold := defaultReorderThreshold
+		b.ReorderThreshold = &threshold
+	}
+
+	// Same title by the same author is usually another edition; warn, or refuse with ?strict=true
+	duplicateIDs, err := findTitleDuplicates(ctx, b.Title, b.AuthorID)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	if len(duplicateIDs) > 0 && c.Query("strict") == "true" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "Possible duplicate book",
+			"details":       "A book with the same title and author already exists",
+			"duplicate_ids": duplicateIDs,
+		})
+		return
+	}
+
 	// Insert book into database
-	result, err := db.Exec(`INSERT INTO books 
-	(title, author_id, isbn, price, stock, published_year, description) VALUES (?, ?, ?, ?, ?, ?, ?)`,
-		b.Title, b.AuthorID, b.ISBN, b.Price, b.Stock, b.PublishedYear, b.Description)
+	result, err := db.ExecContext(ctx, `INSERT INTO books 
+	(title, author_id, isbn, price, stock, published_year, description, genre, reorder_threshold) VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)`,
+		b.Title, b.AuthorID, b.ISBN, b.Price, b.Stock, b.PublishedYear, b.Description, b.Genre, *b.ReorderThreshold)
+	if isUniqueViolation(err) {
+		err = duplicateISBNError(ctx, db, b.ISBN, err)
+		c.JSON(http.StatusConflict, gin.H{"error": "Duplicate ISBN", "details": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	b.ID = int(id)
-	err = db.QueryRow(`SELECT created_at FROM books WHERE id = ?`, b.ID).Scan(&b.CreatedAt)
+	err = db.QueryRowContext(ctx, `SELECT created_at, version FROM books WHERE id = ?`, b.ID).Scan(&b.CreatedAt, &b.Version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
-	c.JSON(http.StatusCreated, b)
+
+	var warnings []string
+	for _, dupID := range duplicateIDs {
+		warnings = append(warnings, fmt.Sprintf("possible duplicate of book id %d", dupID))
+	}
+	c.JSON(http.StatusCreated, struct {
+		Book
+		Warnings []string `json:"warnings,omitempty"`
+	}{b, warnings})
+}
+
+// IDs of live books with the same title (ignoring case and outer spaces) and author
+func findTitleDuplicates(ctx context.Context, title string, authorID *int) ([]int, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id FROM books
+		WHERE LOWER(TRIM(title)) = LOWER(TRIM(?)) AND author_id IS ? AND deleted_at IS NULL
+		ORDER BY id`, title, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
 }
 
-// PUT /books/:id - with enhanced validation
+// PUT /books/:id - with enhanced validation and optimistic concurrency
+//
+// Clients send back the "version" they got from GET /books/:id. The update
+// only applies if the stored version still matches, and bumps it by one.
+// If someone else updated the book in between, the versions differ and the
+// request fails with 409 Conflict; the client should re-fetch and retry.
 func updateBook(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	id := c.Param("id")
 	var b Book
 	
@@ -608,7 +1430,7 @@ func updateBook(c *gin.Context) {
 	if err := c.ShouldBindJSON(&b); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Validation failed",
-			"details": err.Error(),
+			"details": validationErrors(err),
 		})
 		return
 	}
@@ -633,7 +1455,7 @@ func updateBook(c *gin.Context) {
 	// Validate author_id if provided
 	if b.AuthorID != nil {
 		var authorExists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM authors WHERE id = ?)", *b.AuthorID).Scan(&authorExists)
+		err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM authors WHERE id = ?)", *b.AuthorID).Scan(&authorExists)
 		if err != nil || !authorExists {
 			c.JSON(http.StatusBadRequest, gin.H{
 				"error":   "Author not found",
@@ -643,29 +1465,205 @@ func updateBook(c *gin.Context) {
 		}
 	}
 
-	res, err := db.Exec(`UPDATE books SET title=?, author_id=?, isbn=?, price=?, stock=?, published_year=?, description=? WHERE id=?`,
-		b.Title, b.AuthorID, b.ISBN, b.Price, b.Stock, b.PublishedYear, b.Description, id)
+	if b.Version < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Version required",
+			"details": "send the version returned by GET /books/:id",
+		})
+		return
+	}
+
+	// reorder_threshold is kept as-is when omitted
+	res, err := db.ExecContext(ctx, `UPDATE books SET title=?, author_id=?, isbn=?, price=?, stock=?, published_year=?, description=?,
+	genre=NULLIF(?, ''), reorder_threshold=COALESCE(?, reorder_threshold), updated_at=`+sqlNowMillis+`, version=version+1
+	WHERE id=? AND version=? AND deleted_at IS NULL`,
+		b.Title, b.AuthorID, b.ISBN, b.Price, b.Stock, b.PublishedYear, b.Description, b.Genre, b.ReorderThreshold, id, b.Version)
+	if isUniqueViolation(err) {
+		err = duplicateISBNError(ctx, db, b.ISBN, err)
+		c.JSON(http.StatusConflict, gin.H{"error": "Duplicate ISBN", "details": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 
 	rowsAffected, _ := res.RowsAffected()
 	if rowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		versionConflict(ctx, c, id)
 		return
 	}
 
 	b.ID = atoi(id)
+	b.Version++
 	c.JSON(http.StatusOK, b)
 }
 
-// DELETE /books/:id
+// Respond to an update that matched no row: 404 if the book is gone, 409 if its version moved on
+func versionConflict(ctx context.Context, c *gin.Context, id string) {
+	var currentVersion int
+	err := db.QueryRowContext(ctx, "SELECT version FROM books WHERE id = ? AND deleted_at IS NULL", id).Scan(&currentVersion)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error":           "Book was modified by another request",
+		"current_version": currentVersion,
+	})
+}
+
+// PATCH /books/:id - update only the fields present in the body
+func patchBook(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	id := c.Param("id")
+	var p BookPatch
+
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Validation failed",
+			"details": validationErrors(err),
+		})
+		return
+	}
+
+	// Build SET clause from provided fields only
+	var sets []string
+	var args []interface{}
+
+	if p.Title != nil {
+		sets = append(sets, "title = ?")
+		args = append(args, *p.Title)
+	}
+	if p.AuthorID != nil {
+		var authorExists bool
+		err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM authors WHERE id = ?)", *p.AuthorID).Scan(&authorExists)
+		if err != nil || !authorExists {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Author not found",
+				"details": fmt.Sprintf("Author with ID %d does not exist", *p.AuthorID),
+			})
+			return
+		}
+		sets = append(sets, "author_id = ?")
+		args = append(args, *p.AuthorID)
+	}
+	if p.ISBN != nil {
+		if err := validateISBN(*p.ISBN); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid ISBN",
+				"details": err.Error(),
+			})
+			return
+		}
+		sets = append(sets, "isbn = ?")
+		args = append(args, *p.ISBN)
+	}
+	if p.Price != nil {
+		sets = append(sets, "price = ?")
+		args = append(args, *p.Price)
+	}
+	if p.Stock != nil {
+		sets = append(sets, "stock = ?")
+		args = append(args, *p.Stock)
+	}
+	if p.PublishedYear != nil {
+		if err := validatePublishedYear(*p.PublishedYear); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid published year",
+				"details": err.Error(),
+			})
+			return
+		}
+		sets = append(sets, "published_year = ?")
+		args = append(args, *p.PublishedYear)
+	}
+	if p.Description != nil {
+		sets = append(sets, "description = ?")
+		args = append(args, *p.Description)
+	}
+	if p.Genre != nil {
+		// "" clears the genre
+		sets = append(sets, "genre = NULLIF(?, '')")
+		args = append(args, *p.Genre)
+	}
+	if p.ReorderThreshold != nil {
+		sets = append(sets, "reorder_threshold = ?")
+		args = append(args, *p.ReorderThreshold)
+	}
+
+	// An empty body must not wipe anything
+	if len(sets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	sets = append(sets, "updated_at = "+sqlNowMillis, "version = version + 1")
+	query := "UPDATE books SET " + strings.Join(sets, ", ") + " WHERE id = ? AND deleted_at IS NULL"
+	args = append(args, id)
+	if p.Version != nil {
+		query += " AND version = ?"
+		args = append(args, *p.Version)
+	}
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if isUniqueViolation(err) {
+		// isbn is the only unique column a patch can set
+		err = duplicateISBNError(ctx, db, *p.ISBN, err)
+		c.JSON(http.StatusConflict, gin.H{"error": "Duplicate ISBN", "details": err.Error()})
+		return
+	}
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		versionConflict(ctx, c, id)
+		return
+	}
+
+	// Return the fully merged book
+	book, err := getBookWithAuthor(ctx, id)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, book)
+}
+
+// DELETE /books/:id - soft delete by default, ?hard=true removes the row permanently
 func deleteBook(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	id := c.Param("id")
-	res, err := db.Exec("DELETE FROM books WHERE id=?", id)
+
+	if c.Query("hard") == "true" {
+		res, err := db.ExecContext(ctx, "DELETE FROM books WHERE id=?", id)
+		if err != nil {
+			internalError(c, err)
+			return
+		}
+		rowsAffected, _ := res.RowsAffected()
+		if rowsAffected == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Book permanently deleted"})
+		return
+	}
+
+	res, err := db.ExecContext(ctx, "UPDATE books SET deleted_at = CURRENT_TIMESTAMP WHERE id=? AND deleted_at IS NULL", id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	rowsAffected, _ := res.RowsAffected()
@@ -676,40 +1674,64 @@ func deleteBook(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Book deleted successfully"})
 }
 
+// POST /books/:id/restore - undo a soft delete
+func restoreBook(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	id := c.Param("id")
+	res, err := db.ExecContext(ctx, "UPDATE books SET deleted_at = NULL WHERE id=? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	rowsAffected, _ := res.RowsAffected()
+	if rowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Deleted book not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Book restored successfully"})
+}
+
 // Statistics Endpoints
 
 // GET /stats
 func getStatistics(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	var stats Statistics
 	stats.BooksByYear = make(map[int]int)
+	stats.BooksByGenre = make(map[string]int)
 
 	// Count total books
-	db.QueryRow("SELECT COUNT(*) FROM books").Scan(&stats.TotalBooks)
+	db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books WHERE deleted_at IS NULL").Scan(&stats.TotalBooks)
 
 	// Count total authors
-	db.QueryRow("SELECT COUNT(*) FROM authors").Scan(&stats.TotalAuthors)
+	db.QueryRowContext(ctx, "SELECT COUNT(*) FROM authors").Scan(&stats.TotalAuthors)
 
 	// Calculate total inventory value
 	var totalValue sql.NullFloat64
-	db.QueryRow("SELECT SUM(price * stock) FROM books").Scan(&totalValue)
+	db.QueryRowContext(ctx, "SELECT SUM(price * stock) FROM books WHERE deleted_at IS NULL").Scan(&totalValue)
 	if totalValue.Valid {
 		stats.TotalValue = totalValue.Float64
 	}
 
-	// Count low stock books (stock < 10 and > 0)
-	db.QueryRow("SELECT COUNT(*) FROM books WHERE stock < 10 AND stock > 0").Scan(&stats.LowStock)
+	// Count low stock books (in stock but below their own reorder threshold)
+	db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books WHERE stock > 0 AND stock < reorder_threshold AND deleted_at IS NULL").Scan(&stats.LowStock)
 
 	// Count out of stock books
-	db.QueryRow("SELECT COUNT(*) FROM books WHERE stock = 0").Scan(&stats.OutOfStock)
+	db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books WHERE stock = 0 AND deleted_at IS NULL").Scan(&stats.OutOfStock)
 
 	// Get most expensive book
 	var mostExpensive BookWithAuthor
 	var authorName sql.NullString
-	err := db.QueryRow(`
+	err := db.QueryRowContext(ctx, `
 		SELECT b.id, b.title, b.author_id, a.name as author_name,
 		       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
 		FROM books b
 		LEFT JOIN authors a ON b.author_id = a.id
+		WHERE b.deleted_at IS NULL
 		ORDER BY b.price DESC
 		LIMIT 1
 	`).Scan(&mostExpensive.ID, &mostExpensive.Title, &mostExpensive.AuthorID, &authorName,
@@ -724,11 +1746,12 @@ func getStatistics(c *gin.Context) {
 
 	// Get cheapest book
 	var cheapest BookWithAuthor
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT b.id, b.title, b.author_id, a.name as author_name,
 		       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
 		FROM books b
 		LEFT JOIN authors a ON b.author_id = a.id
+		WHERE b.deleted_at IS NULL
 		ORDER BY b.price ASC
 		LIMIT 1
 	`).Scan(&cheapest.ID, &cheapest.Title, &cheapest.AuthorID, &authorName,
@@ -743,11 +1766,12 @@ func getStatistics(c *gin.Context) {
 
 	// Get most stocked book
 	var mostStocked BookWithAuthor
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT b.id, b.title, b.author_id, a.name as author_name,
 		       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
 		FROM books b
 		LEFT JOIN authors a ON b.author_id = a.id
+		WHERE b.deleted_at IS NULL
 		ORDER BY b.stock DESC
 		LIMIT 1
 	`).Scan(&mostStocked.ID, &mostStocked.Title, &mostStocked.AuthorID, &authorName,
@@ -761,7 +1785,7 @@ func getStatistics(c *gin.Context) {
 	}
 
 	// Get books by year distribution
-	rows, err := db.Query("SELECT published_year, COUNT(*) FROM books GROUP BY published_year")
+	rows, err := db.QueryContext(ctx, "SELECT published_year, COUNT(*) FROM books WHERE deleted_at IS NULL GROUP BY published_year")
 	if err == nil {
 		defer rows.Close()
 		for rows.Next() {
@@ -772,92 +1796,207 @@ func getStatistics(c *gin.Context) {
 		}
 	}
 
+	// Get books by genre distribution
+	genreRows, err := db.QueryContext(ctx, "SELECT COALESCE(genre, 'uncategorized'), COUNT(*) FROM books WHERE deleted_at IS NULL GROUP BY 1")
+	if err == nil {
+		defer genreRows.Close()
+		for genreRows.Next() {
+			var genre string
+			var count int
+			if err := genreRows.Scan(&genre, &count); err == nil {
+				stats.BooksByGenre[genre] = count
+			}
+		}
+	}
+
 	// Calculate average price
 	var avgPrice sql.NullFloat64
-	db.QueryRow("SELECT AVG(price) FROM books").Scan(&avgPrice)
+	db.QueryRowContext(ctx, "SELECT AVG(price) FROM books WHERE deleted_at IS NULL").Scan(&avgPrice)
 	if avgPrice.Valid {
 		stats.AveragePrice = avgPrice.Float64
 	}
 
+	// The queries above ignore errors, so check whether they were cut short
+	if queryTimedOut(c, ctx.Err()) {
+		return
+	}
 	c.JSON(http.StatusOK, stats)
 }
 
-// Top Books Endpoints
+// Search Endpoints
 
-// GET /books/top/expensive?limit=5
-func getTopExpensive(c *gin.Context) {
-	limit := parseIntQuery(c, "limit", 5)
+// GET /books/search?q=query&page=1&limit=20
+// Title matches rank first, then author, ISBN and description
+func searchBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	q := c.Query("q")
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Search query required"})
+		return
+	}
+
+	page := parseIntQuery(c, "page", 1)
+	limit := parseIntQuery(c, "limit", 20)
+	if page < 1 {
+		page = 1
+	}
 	if limit < 1 || limit > 100 {
-		limit = 5
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	pattern := "%" + q + "%"
+	where := `
+	WHERE b.deleted_at IS NULL AND (
+		LOWER(b.title) LIKE LOWER(?) OR LOWER(a.name) LIKE LOWER(?) OR
+		b.isbn LIKE ? OR LOWER(b.description) LIKE LOWER(?)
+	)`
+	whereArgs := []interface{}{pattern, pattern, pattern, pattern}
+
+	// Get total count of matches
+	var total int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM books b LEFT JOIN authors a ON b.author_id = a.id`+where,
+		whereArgs...).Scan(&total)
+	if err != nil {
+		if queryTimedOut(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count search results"})
+		return
 	}
 
+	// Rank by the first field that matched
 	query := `
 	SELECT b.id, b.title, b.author_id, a.name as author_name,
-	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
+	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at,
+	       CASE
+	           WHEN LOWER(b.title) LIKE LOWER(?) THEN 'title'
+	           WHEN LOWER(a.name) LIKE LOWER(?) THEN 'author'
+	           WHEN b.isbn LIKE ? THEN 'isbn'
+	           ELSE 'description'
+	       END AS match_field,
+	       CASE
+	           WHEN LOWER(b.title) LIKE LOWER(?) THEN 1
+	           WHEN LOWER(a.name) LIKE LOWER(?) THEN 2
+	           WHEN b.isbn LIKE ? THEN 3
+	           ELSE 4
+	       END AS match_rank
 	FROM books b
-	LEFT JOIN authors a ON b.author_id = a.id
-	ORDER BY b.price DESC
-	LIMIT ?`
+	LEFT JOIN authors a ON b.author_id = a.id` + where + `
+	ORDER BY match_rank, b.title
+	LIMIT ? OFFSET ?`
+
+	args := []interface{}{pattern, pattern, pattern, pattern, pattern, pattern}
+	args = append(args, whereArgs...)
+	args = append(args, limit, offset)
 
-	rows, err := db.Query(query, limit)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	defer rows.Close()
 
-	books := []BookWithAuthor{}
+	results := []SearchResult{}
 	for rows.Next() {
-		var b BookWithAuthor
+		var r SearchResult
 		var authorName sql.NullString
-		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN,
-			&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
+		var rank int
+		err := rows.Scan(&r.ID, &r.Title, &r.AuthorID, &authorName, &r.ISBN, &r.Price, &r.Stock,
+			&r.PublishedYear, &r.Description, &r.CreatedAt, &r.MatchField, &rank)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 			return
 		}
 		if authorName.Valid {
-			b.AuthorName = authorName.String
+			r.AuthorName = authorName.String
 		}
-		books = append(books, b)
+		results = append(results, r)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"books": books,
-		"count": len(books),
+	totalPages := (total + limit - 1) / limit
+
+	c.JSON(http.StatusOK, SearchResponse{
+		Query:   q,
+		Results: results,
+		Pagination: PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
 	})
 }
 
-// GET /books/top/stocked?limit=5
-func getTopStocked(c *gin.Context) {
+// GET /books/:id/related?limit=5
+// Same-author books come first, then books published within 3 years of the source
+func getRelatedBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	id := c.Param("id")
 	limit := parseIntQuery(c, "limit", 5)
 	if limit < 1 || limit > 100 {
 		limit = 5
 	}
 
+	var authorID sql.NullInt64
+	var year int
+	err := db.QueryRowContext(ctx, "SELECT author_id, published_year FROM books WHERE id = ? AND deleted_at IS NULL", id).
+		Scan(&authorID, &year)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		} else {
+			internalError(c, err)
+		}
+		return
+	}
+
+	// The second branch skips same-author books so nothing appears twice.
+	// An authorless source has no same-author books, so nothing is skipped then.
 	query := `
+	SELECT b.id AS id, b.title, b.author_id, a.name as author_name,
+	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at,
+	       'same_author' AS reason, 1 AS priority, ABS(b.published_year - ?) AS year_distance
+	FROM books b
+	LEFT JOIN authors a ON b.author_id = a.id
+	WHERE b.deleted_at IS NULL AND b.id != ? AND b.author_id = ?
+	UNION ALL
 	SELECT b.id, b.title, b.author_id, a.name as author_name,
-	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
+	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at,
+	       'similar_year' AS reason, 2 AS priority, ABS(b.published_year - ?) AS year_distance
 	FROM books b
 	LEFT JOIN authors a ON b.author_id = a.id
-	ORDER BY b.stock DESC
+	WHERE b.deleted_at IS NULL AND b.id != ? AND (? IS NULL OR b.author_id IS NOT ?)
+	      AND b.published_year BETWEEN ? AND ?
+	ORDER BY priority, year_distance, id
 	LIMIT ?`
 
-	rows, err := db.Query(query, limit)
+	rows, err := db.QueryContext(ctx, query,
+		year, id, authorID,
+		year, id, authorID, authorID, year-3, year+3,
+		limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	defer rows.Close()
 
-	books := []BookWithAuthor{}
+	books := []RelatedBook{}
 	for rows.Next() {
-		var b BookWithAuthor
+		var b RelatedBook
 		var authorName sql.NullString
+		var priority, yearDistance int
 		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN,
-			&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
+			&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt,
+			&b.Reason, &priority, &yearDistance)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 			return
 		}
 		if authorName.Valid {
@@ -872,36 +2011,196 @@ func getTopStocked(c *gin.Context) {
 	})
 }
 
-// GET /books/top/recent?limit=10
-func getRecentBooks(c *gin.Context) {
-	limit := parseIntQuery(c, "limit", 10)
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
+// GET /authors/:id/stats
+func getAuthorStatistics(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
 
-	query := `
-	SELECT b.id, b.title, b.author_id, a.name as author_name,
-	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
-	FROM books b
-	LEFT JOIN authors a ON b.author_id = a.id
-	ORDER BY b.created_at DESC
-	LIMIT ?`
+	id := c.Param("id")
 
-	rows, err := db.Query(query, limit)
+	stats := AuthorStatistics{}
+	err := db.QueryRowContext(ctx, "SELECT id, name FROM authors WHERE id = ?", id).Scan(&stats.AuthorID, &stats.AuthorName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Author not found"})
+		} else {
+			internalError(c, err)
+		}
 		return
 	}
-	defer rows.Close()
 
-	books := []BookWithAuthor{}
-	for rows.Next() {
-		var b BookWithAuthor
-		var authorName sql.NullString
-		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN,
-			&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
+	// All aggregates in one pass; the title subqueries reuse the same author filter
+	var avgPrice sql.NullFloat64
+	var earliest, latest sql.NullInt64
+	var mostExpensive, cheapest sql.NullString
+	err = db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(price * stock), 0), AVG(price),
+		       MIN(published_year), MAX(published_year),
+		       (SELECT title FROM books WHERE author_id = ? AND deleted_at IS NULL ORDER BY price DESC, id LIMIT 1),
+		       (SELECT title FROM books WHERE author_id = ? AND deleted_at IS NULL ORDER BY price ASC, id LIMIT 1)
+		FROM books
+		WHERE author_id = ? AND deleted_at IS NULL`, id, id, id).Scan(
+		&stats.TotalBooks, &stats.TotalValue, &avgPrice, &earliest, &latest, &mostExpensive, &cheapest,
+	)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	// Authors without books leave these as null
+	if avgPrice.Valid {
+		stats.AveragePrice = avgPrice.Float64
+	}
+	if earliest.Valid {
+		year := int(earliest.Int64)
+		stats.EarliestYear = &year
+	}
+	if latest.Valid {
+		year := int(latest.Int64)
+		stats.LatestYear = &year
+	}
+	if mostExpensive.Valid {
+		stats.MostExpensiveTitle = &mostExpensive.String
+	}
+	if cheapest.Valid {
+		stats.CheapestTitle = &cheapest.String
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// Top Books Endpoints
+
+// GET /books/top/expensive?limit=5
+func getTopExpensive(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	limit := parseIntQuery(c, "limit", 5)
+	if limit < 1 || limit > 100 {
+		limit = 5
+	}
+
+	query := `
+	SELECT b.id, b.title, b.author_id, a.name as author_name,
+	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
+	FROM books b
+	LEFT JOIN authors a ON b.author_id = a.id
+	WHERE b.deleted_at IS NULL
+	ORDER BY b.price DESC
+	LIMIT ?`
+
+	rows, err := db.QueryContext(ctx, query, limit)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	books := []BookWithAuthor{}
+	for rows.Next() {
+		var b BookWithAuthor
+		var authorName sql.NullString
+		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN,
+			&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
+		if err != nil {
+			internalError(c, err)
+			return
+		}
+		if authorName.Valid {
+			b.AuthorName = authorName.String
+		}
+		books = append(books, b)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"books": books,
+		"count": len(books),
+	})
+}
+
+// GET /books/top/stocked?limit=5
+func getTopStocked(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	limit := parseIntQuery(c, "limit", 5)
+	if limit < 1 || limit > 100 {
+		limit = 5
+	}
+
+	query := `
+	SELECT b.id, b.title, b.author_id, a.name as author_name,
+	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
+	FROM books b
+	LEFT JOIN authors a ON b.author_id = a.id
+	WHERE b.deleted_at IS NULL
+	ORDER BY b.stock DESC
+	LIMIT ?`
+
+	rows, err := db.QueryContext(ctx, query, limit)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	books := []BookWithAuthor{}
+	for rows.Next() {
+		var b BookWithAuthor
+		var authorName sql.NullString
+		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN,
+			&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
+		if err != nil {
+			internalError(c, err)
+			return
+		}
+		if authorName.Valid {
+			b.AuthorName = authorName.String
+		}
+		books = append(books, b)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"books": books,
+		"count": len(books),
+	})
+}
+
+// GET /books/top/recent?limit=10
+func getRecentBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	limit := parseIntQuery(c, "limit", 10)
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	query := `
+	SELECT b.id, b.title, b.author_id, a.name as author_name,
+	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
+	FROM books b
+	LEFT JOIN authors a ON b.author_id = a.id
+	WHERE b.deleted_at IS NULL
+	ORDER BY b.created_at DESC
+	LIMIT ?`
+
+	rows, err := db.QueryContext(ctx, query, limit)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	books := []BookWithAuthor{}
+	for rows.Next() {
+		var b BookWithAuthor
+		var authorName sql.NullString
+		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN,
+			&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			internalError(c, err)
 			return
 		}
 		if authorName.Valid {
@@ -918,22 +2217,165 @@ func getRecentBooks(c *gin.Context) {
 
 // Inventory Management
 
+// Orderings accepted by GET /books/by-year
+var yearRangeSortOrders = map[string]string{
+	"year":       "b.published_year ASC, b.id",
+	"year_desc":  "b.published_year DESC, b.id",
+	"title":      "b.title ASC, b.id",
+	"price_asc":  "b.price ASC, b.id",
+	"price_desc": "b.price DESC, b.id",
+}
+
+// GET /books/by-year?from=2000&to=2010&sort=year&page=1&limit=20
+// Books published in the inclusive range plus a per-year count
+func getBooksByYearRange(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	from, to := 1800, time.Now().Year()
+	for _, p := range []struct {
+		param string
+		value *int
+	}{{"from", &from}, {"to", &to}} {
+		v := c.Query(p.param)
+		if v == "" {
+			continue
+		}
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be an integer", p.param)})
+			return
+		}
+		if err := validatePublishedYear(year); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %v", p.param, err)})
+			return
+		}
+		*p.value = year
+	}
+	if from > to {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	sortBy := c.DefaultQuery("sort", "year")
+	orderBy, ok := yearRangeSortOrders[sortBy]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid sort",
+			"details": "sort must be one of " + sortOptions(yearRangeSortOrders),
+		})
+		return
+	}
+
+	page := parseIntQuery(c, "page", 1)
+	limit := parseIntQuery(c, "limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	where := " WHERE b.deleted_at IS NULL AND b.published_year BETWEEN ? AND ?"
+
+	// Per-year counts over the whole range; their sum is the total
+	summaryRows, err := db.QueryContext(ctx, "SELECT b.published_year, COUNT(*) FROM books b"+where+
+		" GROUP BY b.published_year ORDER BY b.published_year", from, to)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer summaryRows.Close()
+
+	summary := []YearCount{}
+	total := 0
+	for summaryRows.Next() {
+		var yc YearCount
+		if err := summaryRows.Scan(&yc.Year, &yc.Count); err != nil {
+			internalError(c, err)
+			return
+		}
+		total += yc.Count
+		summary = append(summary, yc)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT b.id, b.title, b.author_id, a.name as author_name,
+	       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
+	FROM books b
+	LEFT JOIN authors a ON b.author_id = a.id`+where+`
+	ORDER BY `+orderBy+`
+	LIMIT ? OFFSET ?`, from, to, limit, offset)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	books := []BookWithAuthor{}
+	for rows.Next() {
+		var b BookWithAuthor
+		var authorName sql.NullString
+		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN, &b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt)
+		if err != nil {
+			internalError(c, err)
+			return
+		}
+		if authorName.Valid {
+			b.AuthorName = authorName.String
+		}
+		books = append(books, b)
+	}
+
+	totalPages := (total + limit - 1) / limit
+
+	c.JSON(http.StatusOK, BooksByYearResponse{
+		From:    from,
+		To:      to,
+		Books:   books,
+		Summary: summary,
+		Pagination: PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
+	})
+}
+
 // POST /books/:id/restock
 func restockBook(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	id := c.Param("id")
 
 	var req RestockRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request",
-			"details": err.Error(),
+			"details": validationErrors(err),
 		})
 		return
 	}
 
+	// Stock update and log entry must commit together
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer tx.Rollback()
+
 	// Update stock
-	result, err := db.Exec("UPDATE books SET stock = stock + ? WHERE id = ?", req.Quantity, id)
+	result, err := tx.ExecContext(ctx, "UPDATE books SET stock = stock + ?, updated_at = "+sqlNowMillis+", version = version + 1 WHERE id = ? AND deleted_at IS NULL", req.Quantity, id)
 	if err != nil {
+		if queryTimedOut(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to restock",
 		})
@@ -949,10 +2391,20 @@ func restockBook(c *gin.Context) {
 		return
 	}
 
+	if err := logInventoryChange(ctx, tx, id, req.Quantity, "restock"); err != nil {
+		internalError(c, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		internalError(c, err)
+		return
+	}
+
 	// Get updated book
 	var book BookWithAuthor
 	var authorName sql.NullString
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT b.id, b.title, b.author_id, a.name as author_name,
 		       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
 		FROM books b
@@ -962,7 +2414,7 @@ func restockBook(c *gin.Context) {
 		&book.Price, &book.Stock, &book.PublishedYear, &book.Description, &book.CreatedAt,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	if authorName.Valid {
@@ -977,33 +2429,57 @@ func restockBook(c *gin.Context) {
 
 // POST /books/:id/sell
 func sellBook(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
 	id := c.Param("id")
 
 	var req SellRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Invalid request",
-			"details": err.Error(),
+			"details": validationErrors(err),
 		})
 		return
 	}
 
-	// Check current stock first
-	var currentStock int
-	err := db.QueryRow("SELECT stock FROM books WHERE id = ?", id).Scan(&currentStock)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Book not found",
-		})
+	// Stock update and log entry must commit together
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		internalError(c, err)
 		return
 	}
+	defer tx.Rollback()
+
+	// Check and decrement in one statement, so two concurrent sales can't
+	// both pass the stock check and oversell
+	res, err := tx.ExecContext(ctx, "UPDATE books SET stock = stock - ?, updated_at = "+sqlNowMillis+", version = version + 1 WHERE id = ? AND deleted_at IS NULL AND stock >= ?",
+		req.Quantity, id, req.Quantity)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if queryTimedOut(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to sell book",
+		})
 		return
 	}
 
-	// Check if sufficient stock
-	if currentStock < req.Quantity {
+	// Nothing updated: either the book is gone or there isn't enough stock
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		var currentStock int
+		err = tx.QueryRowContext(ctx, "SELECT stock FROM books WHERE id = ? AND deleted_at IS NULL", id).Scan(&currentStock)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Book not found",
+			})
+			return
+		}
+		if err != nil {
+			internalError(c, err)
+			return
+		}
+
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":     "Insufficient stock",
 			"available": currentStock,
@@ -1012,19 +2488,20 @@ func sellBook(c *gin.Context) {
 		return
 	}
 
-	// Update stock
-	_, err = db.Exec("UPDATE books SET stock = stock - ? WHERE id = ?", req.Quantity, id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to sell book",
-		})
+	if err := logInventoryChange(ctx, tx, id, -req.Quantity, "sale"); err != nil {
+		internalError(c, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		internalError(c, err)
 		return
 	}
 
 	// Get updated book
 	var book BookWithAuthor
 	var authorName sql.NullString
-	err = db.QueryRow(`
+	err = db.QueryRowContext(ctx, `
 		SELECT b.id, b.title, b.author_id, a.name as author_name,
 		       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at
 		FROM books b
@@ -1034,123 +2511,1454 @@ func sellBook(c *gin.Context) {
 		&book.Price, &book.Stock, &book.PublishedYear, &book.Description, &book.CreatedAt,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		internalError(c, err)
 		return
 	}
 	if authorName.Valid {
 		book.AuthorName = authorName.String
 	}
 
+	checkInventoryValue(ctx, c, book, req.Quantity)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Book sold successfully",
 		"book":    book,
 	})
 }
 
-// Bulk Operations
+// Inventory Value Alerts
 
-// POST /books/bulk
-func createBulkBooks(c *gin.Context) {
-	var req BulkCreateRequest
+// Set from -alert-threshold and -alert-webhook; alerts are off while either is unset
+var inventoryAlert struct {
+	threshold float64
+	webhook   string
+}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request",
-			"details": err.Error(),
-		})
+const (
+	alertTimeout    = 5 * time.Second
+	alertRetryDelay = 2 * time.Second
+)
+
+// JSON body POSTed to the webhook
+type InventoryAlert struct {
+	Event      string  `json:"event"`
+	TotalValue float64 `json:"total_value"`
+	Threshold  float64 `json:"threshold"`
+	BookID     int     `json:"book_id"`
+	Title      string  `json:"title"`
+	Quantity   int     `json:"quantity"`
+	RequestID  string  `json:"request_id"`
+	Time       string  `json:"time"`
+}
+
+var alertClient = &http.Client{Timeout: alertTimeout}
+
+// After a sale, alert when it took the total inventory value below the threshold.
+// The value before the sale is the new total plus what was just sold, so only
+// the sale that crosses the line alerts, not every sale below it.
+func checkInventoryValue(ctx context.Context, c *gin.Context, book BookWithAuthor, quantity int) {
+	if inventoryAlert.webhook == "" || inventoryAlert.threshold <= 0 {
 		return
 	}
 
-	var response BulkCreateResponse
-
-	// Loop through books and create each one
-	for _, book := range req.Books {
-		// Custom validations
-		if err := validateISBN(book.ISBN); err != nil {
+	var total sql.NullFloat64
+	if err := db.QueryRowContext(ctx, "SELECT SUM(price * stock) FROM books WHERE deleted_at IS NULL").Scan(&total); err != nil {
+		log.Println("Inventory alert: failed to compute total value:", err)
+		return
+	}
+	before := total.Float64 + book.Price*float64(quantity)
+	if before < inventoryAlert.threshold || total.Float64 >= inventoryAlert.threshold {
+		return
+	}
+
+	alert := InventoryAlert{
+		Event:      "inventory_value_below_threshold",
+		TotalValue: roundToCents(total.Float64),
+		Threshold:  inventoryAlert.threshold,
+		BookID:     book.ID,
+		Title:      book.Title,
+		Quantity:   quantity,
+		RequestID:  requestID(c),
+		Time:       time.Now().UTC().Format(time.RFC3339),
+	}
+	// Delivered in the background so a slow or broken webhook never delays the sale
+	go sendInventoryAlert(inventoryAlert.webhook, alert)
+}
+
+// POST the alert, retrying once; failures are only logged
+func sendInventoryAlert(url string, alert InventoryAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Println("Inventory alert: failed to encode:", err)
+		return
+	}
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if attempt > 1 {
+			time.Sleep(alertRetryDelay)
+		}
+		resp, err := alertClient.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Inventory alert: attempt %d failed: %v", attempt, err)
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return
+		}
+		log.Printf("Inventory alert: attempt %d got status %d", attempt, resp.StatusCode)
+	}
+	log.Printf("Inventory alert: giving up on %s (total value %.2f)", url, alert.TotalValue)
+}
+
+// GET /books/low-stock - books below their reorder threshold, furthest below first
+func getLowStockBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT b.id, b.title, b.author_id, a.name as author_name,
+		       b.isbn, b.price, b.stock, b.published_year, b.description, b.created_at,
+		       b.reorder_threshold
+		FROM books b
+		LEFT JOIN authors a ON b.author_id = a.id
+		WHERE b.deleted_at IS NULL AND b.stock < b.reorder_threshold
+		ORDER BY (b.reorder_threshold - b.stock) DESC, b.id`)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	books := []LowStockBook{}
+	for rows.Next() {
+		var b LowStockBook
+		var authorName sql.NullString
+		err := rows.Scan(&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN,
+			&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.CreatedAt, &b.ReorderThreshold)
+		if err != nil {
+			internalError(c, err)
+			return
+		}
+		if authorName.Valid {
+			b.AuthorName = authorName.String
+		}
+		b.Shortfall = b.ReorderThreshold - b.Stock
+		books = append(books, b)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"books": books,
+		"count": len(books),
+	})
+}
+
+// Record a stock movement along with the resulting balance
+func logInventoryChange(ctx context.Context, q queryer, bookID string, change int, reason string) error {
+	var balance int
+	if err := q.QueryRowContext(ctx, "SELECT stock FROM books WHERE id = ?", bookID).Scan(&balance); err != nil {
+		return err
+	}
+	_, err := q.ExecContext(ctx, `INSERT INTO inventory_log (book_id, change, reason, balance_after) VALUES (?, ?, ?, ?)`,
+		bookID, change, reason, balance)
+	return err
+}
+
+// GET /books/:id/history?page=1&limit=20 - inventory movements, newest first
+func getInventoryHistory(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	id := c.Param("id")
+
+	page := parseIntQuery(c, "page", 1)
+	limit := parseIntQuery(c, "limit", 20)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	var bookExists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM books WHERE id = ? AND deleted_at IS NULL)", id).Scan(&bookExists)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	if !bookExists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Book not found"})
+		return
+	}
+
+	var total int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM inventory_log WHERE book_id = ?", id).Scan(&total)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT id, book_id, change, reason, balance_after, created_at
+	FROM inventory_log WHERE book_id = ?
+	ORDER BY created_at DESC, id DESC
+	LIMIT ? OFFSET ?`, id, limit, offset)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	entries := []InventoryLogEntry{}
+	for rows.Next() {
+		var e InventoryLogEntry
+		if err := rows.Scan(&e.ID, &e.BookID, &e.Change, &e.Reason, &e.BalanceAfter, &e.CreatedAt); err != nil {
+			internalError(c, err)
+			return
+		}
+		entries = append(entries, e)
+	}
+
+	totalPages := (total + limit - 1) / limit
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": entries,
+		"pagination": PaginationMeta{
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: totalPages,
+			HasNext:    page < totalPages,
+			HasPrev:    page > 1,
+		},
+	})
+}
+
+// Export
+
+// GET /books/export?format=csv|json - rows are streamed straight from the cursor
+func exportBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or json"})
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT b.id, b.title, a.name as author_name, b.isbn, b.price, b.stock, b.published_year
+		FROM books b
+		LEFT JOIN authors a ON b.author_id = a.id
+		WHERE b.deleted_at IS NULL
+		ORDER BY b.id`)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer rows.Close()
+
+	// Headers are sent with the first write, so later errors can only be logged
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=books.csv")
+		c.Status(http.StatusOK)
+
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"id", "title", "author_name", "isbn", "price", "stock", "published_year"})
+		for rows.Next() {
+			r, err := scanExportRow(rows)
+			if err != nil {
+				log.Println("CSV export failed:", err)
+				break
+			}
+			w.Write([]string{
+				strconv.Itoa(r.ID), r.Title, r.AuthorName, r.ISBN,
+				strconv.FormatFloat(r.Price, 'f', 2, 64), strconv.Itoa(r.Stock), strconv.Itoa(r.PublishedYear),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			log.Println("CSV export failed:", err)
+		}
+		return
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	c.Writer.WriteString("[")
+	first := true
+	for rows.Next() {
+		r, err := scanExportRow(rows)
+		if err != nil {
+			log.Println("JSON export failed:", err)
+			break
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			log.Println("JSON export failed:", err)
+			break
+		}
+		if !first {
+			c.Writer.WriteString(",")
+		}
+		c.Writer.Write(data)
+		first = false
+	}
+	c.Writer.WriteString("]")
+}
+
+// Largest CSV file accepted by importBooks
+const maxImportSize = 5 << 20 // 5MB
+
+// POST /books/import - multipart "file" with the same columns as the CSV export.
+// Set form field create_missing_authors=true to create unknown authors on the fly.
+func importBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize+1024)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File must be 5MB or smaller"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file required", "details": err.Error()})
+		return
+	}
+	if fileHeader.Size > maxImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File must be 5MB or smaller"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+	defer file.Close()
+
+	createMissing := c.PostForm("create_missing_authors") == "true"
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // column count is checked per row
+
+	// Skip header row
+	if _, err := reader.Read(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid CSV", "details": err.Error()})
+		return
+	}
+
+	response := ImportResponse{Errors: map[int]string{}}
+	authorIDs := map[string]int{}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// FieldPos panics when the record failed to parse, so take the line from the error
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid CSV", "details": err.Error()})
+				return
+			}
+			response.Failed++
+			response.Errors[parseErr.StartLine] = err.Error()
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		book, authorName, err := parseImportRecord(record)
+		if err == nil && authorName != "" {
+			var id int
+			id, err = resolveAuthor(ctx, authorName, createMissing, authorIDs, &response)
+			book.AuthorID = &id
+		}
+		if err == nil {
+			err = insertBulkBook(ctx, db, &book)
+		}
+		if err != nil {
 			response.Failed++
-			response.Errors = append(response.Errors,
-				fmt.Sprintf("Book '%s': %v", book.Title, err))
+			response.Errors[line] = err.Error()
 			continue
 		}
+		response.CreatedBooks = append(response.CreatedBooks, book)
+		response.Success++
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Turn one CSV row (id,title,author_name,isbn,price,stock,published_year) into a Book
+func parseImportRecord(record []string) (Book, string, error) {
+	var b Book
+	if len(record) != 7 {
+		return b, "", fmt.Errorf("expected 7 columns, got %d", len(record))
+	}
+
+	b.Title = strings.TrimSpace(record[1])
+	authorName := strings.TrimSpace(record[2])
+	b.ISBN = strings.TrimSpace(record[3])
+
+	if len(b.Title) < 3 {
+		return b, "", fmt.Errorf("title must be at least 3 characters")
+	}
+
+	price, err := strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+	if err != nil {
+		return b, "", fmt.Errorf("invalid price %q", record[4])
+	}
+	if price < 0.01 || price > maxBookPrice {
+		return b, "", fmt.Errorf("price must be between 0.01 and %.2f", maxBookPrice)
+	}
+	b.Price = price
+
+	stock, err := strconv.Atoi(strings.TrimSpace(record[5]))
+	if err != nil || stock < 0 {
+		return b, "", fmt.Errorf("invalid stock %q", record[5])
+	}
+	b.Stock = stock
+
+	year, err := strconv.Atoi(strings.TrimSpace(record[6]))
+	if err != nil {
+		return b, "", fmt.Errorf("invalid published year %q", record[6])
+	}
+	b.PublishedYear = year
+
+	// Check these before the author lookup so a bad row never creates an author
+	if err := validateISBN(b.ISBN); err != nil {
+		return b, "", err
+	}
+	if err := validatePublishedYear(b.PublishedYear); err != nil {
+		return b, "", err
+	}
+
+	return b, authorName, nil
+}
+
+// Look up an author by name, creating it when allowed; cache holds ids seen in this import
+func resolveAuthor(ctx context.Context, name string, createMissing bool, cache map[string]int, response *ImportResponse) (int, error) {
+	if id, ok := cache[name]; ok {
+		return id, nil
+	}
+
+	var id int
+	err := db.QueryRowContext(ctx, "SELECT id FROM authors WHERE name = ?", name).Scan(&id)
+	if err == sql.ErrNoRows {
+		if !createMissing {
+			return 0, fmt.Errorf("author '%s' does not exist", name)
+		}
+		result, err := db.ExecContext(ctx, "INSERT INTO authors (name) VALUES (?)", name)
+		if err != nil {
+			return 0, err
+		}
+		newID, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		id = int(newID)
+		response.AuthorsCreated = append(response.AuthorsCreated, name)
+	} else if err != nil {
+		return 0, err
+	}
+
+	cache[name] = id
+	return id, nil
+}
+
+func scanExportRow(rows *sql.Rows) (ExportRow, error) {
+	var r ExportRow
+	var authorName sql.NullString
+	err := rows.Scan(&r.ID, &r.Title, &authorName, &r.ISBN, &r.Price, &r.Stock, &r.PublishedYear)
+	if authorName.Valid {
+		r.AuthorName = authorName.String
+	}
+	return r, err
+}
+
+// Bulk Operations
+
+// POST /books/bulk
+// With "transactional": true every book is inserted in one transaction and
+// the whole batch is rolled back if any book fails; otherwise best-effort.
+// An Idempotency-Key header makes retries safe: a repeated key returns the
+// first response (with Idempotent-Replayed: true) for idempotencyTTL.
+func createBulkBooks(c *gin.Context) {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	var req BulkCreateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": validationErrors(err),
+		})
+		return
+	}
+
+	key := c.GetHeader("Idempotency-Key")
+	if key != "" {
+		body, _ := json.Marshal(req)
+		fingerprint := fmt.Sprintf("%x", sha1.Sum(body))
+
+		cached, err := bulkIdempotency.begin(key, fingerprint)
+		switch {
+		case errors.Is(err, errIdempotencyInFlight):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, errIdempotencyMismatch):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case cached != nil:
+			c.Header("Idempotent-Replayed", "true")
+			c.JSON(http.StatusCreated, cached)
+			return
+		}
+	}
+
+	var response *BulkCreateResponse
+	if req.Transactional {
+		response = createBulkBooksTx(c, req.Books)
+	} else {
+		response = &BulkCreateResponse{}
+
+		// Loop through books and create each one
+		for _, book := range req.Books {
+			if err := insertBulkBook(ctx, db, &book); err != nil {
+				response.Failed++
+				response.Errors = append(response.Errors,
+					fmt.Sprintf("Book '%s': %v", book.Title, err))
+				continue
+			}
+			response.CreatedBooks = append(response.CreatedBooks, book)
+			response.Success++
+		}
+
+		c.JSON(http.StatusCreated, response)
+	}
+
+	// Only a request that changed the database is remembered; a rolled back
+	// one can be retried with the same key
+	if key != "" {
+		if response != nil {
+			bulkIdempotency.complete(key, response)
+		} else {
+			bulkIdempotency.release(key)
+		}
+	}
+}
+
+// All-or-nothing variant of createBulkBooks; returns nil if nothing was
+// committed (the error response has already been written)
+func createBulkBooksTx(c *gin.Context, books []Book) *BulkCreateResponse {
+	ctx, cancel := queryContext(c)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		internalError(c, err)
+		return nil
+	}
+	defer tx.Rollback() // no-op after a successful Commit
+
+	var response BulkCreateResponse
+	for i, book := range books {
+		if err := insertBulkBook(ctx, tx, &book); err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, errDuplicate) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{
+				"error":        "Bulk create rolled back",
+				"failed_index": i,
+				"details":      fmt.Sprintf("Book '%s': %v", book.Title, err),
+			})
+			return nil
+		}
+		response.CreatedBooks = append(response.CreatedBooks, book)
+		response.Success++
+	}
+
+	if err := tx.Commit(); err != nil {
+		internalError(c, err)
+		return nil
+	}
+	c.JSON(http.StatusCreated, response)
+	return &response
+}
+
+// Validate and insert one book of a bulk request, filling in ID and CreatedAt
+func insertBulkBook(ctx context.Context, q queryer, book *Book) error {
+	// Custom validations
+	if err := validateISBN(book.ISBN); err != nil {
+		return err
+	}
+	if err := validatePublishedYear(book.PublishedYear); err != nil {
+		return err
+	}
+
+	// Validate author_id if provided
+	if book.AuthorID != nil {
+		var authorExists bool
+		err := q.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM authors WHERE id = ?)", *book.AuthorID).Scan(&authorExists)
+		if err != nil || !authorExists {
+			return fmt.Errorf("Author with ID %d does not exist", *book.AuthorID)
+		}
+	}
+
+	if book.ReorderThreshold == nil {
+		threshold := defaultReorderThreshold
+		book.ReorderThreshold = &threshold
+	}
+
+	// Insert book
+	result, err := q.ExecContext(ctx,
+		"INSERT INTO books (title, author_id, isbn, price, stock, published_year, description, genre, reorder_threshold) VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)",
+		book.Title, book.AuthorID, book.ISBN, book.Price, book.Stock, book.PublishedYear, book.Description, book.Genre, *book.ReorderThreshold,
+	)
+	if isUniqueViolation(err) {
+		return duplicateISBNError(ctx, q, book.ISBN, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	book.ID = int(id)
+	return q.QueryRowContext(ctx, "SELECT created_at, version FROM books WHERE id = ?", book.ID).Scan(&book.CreatedAt, &book.Version)
+}
+
+// Authentication
+
+const tokenTTL = time.Hour
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Admin credentials and signing key come from the environment, with dev defaults
+var (
+	adminUsername = getEnv("ADMIN_USERNAME", "admin")
+	adminPassword = getEnv("ADMIN_PASSWORD", "admin123")
+	jwtSecret     = []byte(getEnv("JWT_SECRET", "dev-secret-change-me"))
+)
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// POST /login - exchange admin credentials for a signed token
+func login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": validationErrors(err)})
+		return
+	}
+
+	userOK := subtle.ConstantTimeCompare([]byte(req.Username), []byte(adminUsername)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(req.Password), []byte(adminPassword)) == 1
+	if !userOK || !passOK {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		return
+	}
+
+	expiresAt := time.Now().Add(tokenTTL)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   req.Username,
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	})
+	signed, err := token.SignedString(jwtSecret)
+	if err != nil {
+		internalError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      signed,
+		"token_type": "Bearer",
+		"expires_at": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// Middleware: reads are public, every other method needs a valid Bearer token
+func authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if c.FullPath() == "/login" {
+			c.Next()
+			return
+		}
 
-		if err := validatePublishedYear(book.PublishedYear); err != nil {
-			response.Failed++
-			response.Errors = append(response.Errors,
-				fmt.Sprintf("Book '%s': %v", book.Title, err))
-			continue
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenString == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
 		}
 
-		// Validate author_id if provided
-		if book.AuthorID != nil {
-			var authorExists bool
-			err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM authors WHERE id = ?)", *book.AuthorID).Scan(&authorExists)
-			if err != nil || !authorExists {
-				response.Failed++
-				response.Errors = append(response.Errors,
-					fmt.Sprintf("Book '%s': Author with ID %d does not exist", book.Title, *book.AuthorID))
-				continue
+		token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(t *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		if err != nil {
+			message := "Invalid token"
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				message = "Token expired"
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": message})
+			return
+		}
+
+		if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok {
+			c.Set("user", claims.Subject)
+		}
+		c.Next()
+	}
+}
+
+// Rate Limiting
+
+const (
+	requestsPerSecond    = 10              // sustained requests per second per client IP
+	requestBurst         = 20              // short bursts allowed above the sustained rate
+	limiterIdleTTL       = 3 * time.Minute // forget IPs that have been quiet this long
+	limiterSweepInterval = time.Minute
+)
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Token bucket per client IP
+type ipRateLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*clientLimiter
+}
+
+func newIPRateLimiter() *ipRateLimiter {
+	l := &ipRateLimiter{clients: make(map[string]*clientLimiter)}
+	go l.evictStale()
+	return l
+}
+
+func (l *ipRateLimiter) get(ip string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cl, ok := l.clients[ip]
+	if !ok {
+		cl = &clientLimiter{limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), requestBurst)}
+		l.clients[ip] = cl
+	}
+	cl.lastSeen = time.Now()
+	return cl.limiter
+}
+
+// Periodically drop idle IPs so the map doesn't grow forever
+func (l *ipRateLimiter) evictStale() {
+	ticker := time.NewTicker(limiterSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		for ip, cl := range l.clients {
+			if time.Since(cl.lastSeen) > limiterIdleTTL {
+				delete(l.clients, ip)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// Middleware: 429 with Retry-After once a client runs out of tokens
+func rateLimitMiddleware(l *ipRateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := l.get(c.ClientIP()).Reserve()
+		if delay := r.Delay(); delay > 0 {
+			// Don't consume the token, the request is rejected
+			r.Cancel()
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many requests",
+				"retry_after": retryAfter,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Idempotency
+
+// Retries of POST /books/bulk carrying the same Idempotency-Key header get
+// the first response back instead of inserting the books again. Keys are
+// remembered for idempotencyTTL after the first request succeeds.
+const (
+	idempotencyTTL           = 24 * time.Hour
+	idempotencySweepInterval = 10 * time.Minute
+)
+
+var (
+	errIdempotencyInFlight = errors.New("a request with this Idempotency-Key is still being processed")
+	errIdempotencyMismatch = errors.New("this Idempotency-Key was already used with a different request body")
+)
+
+type idempotencyEntry struct {
+	fingerprint string              // hash of the request, a reused key must match it
+	response    *BulkCreateResponse // nil while the first request is in flight
+	expiresAt   time.Time
+}
+
+type idempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]*idempotencyEntry
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	s := &idempotencyStore{entries: make(map[string]*idempotencyEntry)}
+	go s.evictExpired()
+	return s
+}
+
+// begin claims key for a new request. It returns the cached response when
+// the key already completed, or an error when it is in flight or was used
+// for a different request.
+func (s *idempotencyStore) begin(key, fingerprint string) (*BulkCreateResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && time.Now().Before(e.expiresAt) {
+		if e.fingerprint != fingerprint {
+			return nil, errIdempotencyMismatch
+		}
+		if e.response == nil {
+			return nil, errIdempotencyInFlight
+		}
+		return e.response, nil
+	}
+
+	s.entries[key] = &idempotencyEntry{
+		fingerprint: fingerprint,
+		expiresAt:   time.Now().Add(idempotencyTTL),
+	}
+	return nil, nil
+}
+
+// complete stores the response for key, starting its TTL
+func (s *idempotencyStore) complete(key string, response *BulkCreateResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.response = response
+		e.expiresAt = time.Now().Add(idempotencyTTL)
+	}
+}
+
+// release forgets key after a failed request so the client can retry it
+func (s *idempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && e.response == nil {
+		delete(s.entries, key)
+	}
+}
+
+// Periodically drop expired keys so the map doesn't grow forever
+func (s *idempotencyStore) evictExpired() {
+	ticker := time.NewTicker(idempotencySweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mu.Lock()
+		for key, e := range s.entries {
+			if time.Now().After(e.expiresAt) {
+				delete(s.entries, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+var bulkIdempotency = newIdempotencyStore()
+
+// Request Logging
+
+const requestIDHeader = "X-Request-ID"
+
+// Random version 4 UUID
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// Request ID assigned by requestLogger, empty outside it
+func requestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
+// Adds "request_id" to JSON error bodies so a failure can be matched to its log line
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+}
+
+func (w *requestIDWriter) Write(data []byte) (int, error) {
+	if w.Status() < http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return w.ResponseWriter.Write(data)
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(data, &body); err != nil {
+		return w.ResponseWriter.Write(data)
+	}
+	body["request_id"], _ = json.Marshal(w.requestID)
+	out, err := json.Marshal(body)
+	if err != nil {
+		return w.ResponseWriter.Write(data)
+	}
+	if _, err := w.ResponseWriter.Write(out); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Middleware: tags every request with an ID (kept if the client sent one)
+// and logs one JSON line per request once the handlers have finished
+func requestLogger() gin.HandlerFunc {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+		c.Set("request_id", id)
+		c.Header(requestIDHeader, id)
+		c.Writer = &requestIDWriter{ResponseWriter: c.Writer, requestID: id}
+
+		c.Next()
+
+		attrs := []any{
+			"request_id", id,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"bytes", c.Writer.Size(),
+			"client_ip", c.ClientIP(),
+		}
+		if user := c.GetString("user"); user != "" {
+			attrs = append(attrs, "user", user)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		level := slog.LevelInfo
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Health Checks
+
+// How long /readyz waits for the database before reporting it unreachable
+const readinessTimeout = 2 * time.Second
+
+// GET /healthz - liveness: the process is up and serving
+func healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /readyz - readiness: 503 unless the database answers a ping in time
+func readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	stats := db.Stats()
+	dbStats := gin.H{
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+		"idle":             stats.Idle,
+		"max_open":         stats.MaxOpenConnections,
+		"wait_count":       stats.WaitCount,
+	}
+	if err := db.PingContext(ctx); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":   "unavailable",
+			"error":    "Database unreachable: " + err.Error(),
+			"database": dbStats,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "database": dbStats})
+}
+
+// Route Table
+
+// One entry per endpoint. main registers routes from this list and both
+// GET / and GET /openapi.json are generated from it, so the docs can't
+// drift from what is actually served.
+type apiRoute struct {
+	method   string
+	path     string // gin syntax, e.g. /books/:id
+	group    string // section in the docs
+	summary  string
+	handler  gin.HandlerFunc
+	request  interface{} // JSON body type, nil if the route takes none
+	response interface{} // success body type, nil for an ad-hoc object
+	statuses []int       // success status first, then the errors it can return
+	probe    bool        // registered ahead of the rate limiter and auth
+}
+
+func apiRoutes() []apiRoute {
+	return []apiRoute{
+		// Health checks
+		{method: http.MethodGet, path: "/healthz", group: "health", summary: "Liveness probe", handler: healthz,
+			statuses: []int{http.StatusOK}, probe: true},
+		{method: http.MethodGet, path: "/readyz", group: "health", summary: "Readiness probe (503 if the database is unreachable)", handler: readyz,
+			statuses: []int{http.StatusOK, http.StatusServiceUnavailable}, probe: true},
+
+		// Authentication
+		{method: http.MethodPost, path: "/login", group: "authentication", summary: "Exchange credentials for a bearer token", handler: login,
+			request: LoginRequest{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError}},
+
+		// Documentation
+		{method: http.MethodGet, path: "/", group: "documentation", summary: "API documentation", handler: getAPIDocumentation,
+			statuses: []int{http.StatusOK}},
+		{method: http.MethodGet, path: "/openapi.json", group: "documentation", summary: "OpenAPI 3.0 description of this API", handler: getOpenAPISpec,
+			statuses: []int{http.StatusOK}},
+
+		// Author routes
+		{method: http.MethodGet, path: "/authors", group: "authors", summary: "List all authors", handler: getAuthors,
+			statuses: []int{http.StatusOK, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/authors/:id", group: "authors", summary: "Get author by ID", handler: getAuthor,
+			response: Author{}, statuses: []int{http.StatusOK, http.StatusNotModified, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodPost, path: "/authors", group: "authors", summary: "Create new author", handler: createAuthor,
+			request: Author{}, response: Author{}, statuses: []int{http.StatusCreated, http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError}},
+		{method: http.MethodPut, path: "/authors/:id", group: "authors", summary: "Update author", handler: updateAuthor,
+			request: Author{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodDelete, path: "/authors/:id", group: "authors", summary: "Delete author", handler: deleteAuthor,
+			statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodPost, path: "/authors/:id/merge", group: "authors", summary: "Merge a duplicate author into target_id, moving its books", handler: mergeAuthor,
+			request: MergeAuthorsRequest{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/authors/:id/books", group: "authors", summary: "Get author's books", handler: getAuthorBooks,
+			statuses: []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/authors/:id/stats", group: "authors", summary: "Get statistics for an author's books", handler: getAuthorStatistics,
+			response: AuthorStatistics{}, statuses: []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError}},
+
+		// Book routes (with pagination and enhanced validation)
+		{method: http.MethodGet, path: "/books", group: "books", summary: "List all books (with pagination)", handler: getBooks,
+			response: PaginatedBooksResponse{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/books/:id", group: "books", summary: "Get book by ID", handler: getBook,
+			response: BookWithAuthor{}, statuses: []int{http.StatusOK, http.StatusNotModified, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodPost, path: "/books", group: "books", summary: "Create new book (warns on a same title and author, ?strict=true refuses with 409)", handler: createBook,
+			request: Book{}, response: Book{}, statuses: []int{http.StatusCreated, http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError}},
+		{method: http.MethodPut, path: "/books/:id", group: "books", summary: "Update book (requires current version)", handler: updateBook,
+			request: Book{}, response: Book{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError}},
+		{method: http.MethodPatch, path: "/books/:id", group: "books", summary: "Partially update book", handler: patchBook,
+			request: BookPatch{}, response: BookWithAuthor{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError}},
+		{method: http.MethodDelete, path: "/books/:id", group: "books", summary: "Delete book (soft delete, ?hard=true to remove permanently)", handler: deleteBook,
+			statuses: []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodPost, path: "/books/:id/restore", group: "books", summary: "Restore soft-deleted book", handler: restoreBook,
+			statuses: []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError}},
+
+		// Statistics
+		{method: http.MethodGet, path: "/stats", group: "statistics", summary: "Get bookstore statistics", handler: getStatistics,
+			response: Statistics{}, statuses: []int{http.StatusOK, http.StatusGatewayTimeout}},
+
+		// Search
+		{method: http.MethodGet, path: "/books/search", group: "books", summary: "Search title, author, ISBN and description", handler: searchBooks,
+			response: SearchResponse{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/books/:id/related", group: "books", summary: "Books by the same author or from similar years", handler: getRelatedBooks,
+			statuses: []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError}},
+
+		// Top books
+		{method: http.MethodGet, path: "/books/top/expensive", group: "books", summary: "Most expensive books", handler: getTopExpensive,
+			statuses: []int{http.StatusOK, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/books/top/stocked", group: "books", summary: "Most stocked books", handler: getTopStocked,
+			statuses: []int{http.StatusOK, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/books/top/recent", group: "books", summary: "Recently added books", handler: getRecentBooks,
+			statuses: []int{http.StatusOK, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/books/by-year", group: "books", summary: "Books published in a year range (?from=2000&to=2010&sort=year)", handler: getBooksByYearRange,
+			response: BooksByYearResponse{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError}},
+
+		// Inventory management
+		{method: http.MethodPost, path: "/books/:id/restock", group: "books", summary: "Restock book", handler: restockBook,
+			request: RestockRequest{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodPost, path: "/books/:id/sell", group: "books", summary: "Sell book", handler: sellBook,
+			request: SellRequest{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/books/:id/history", group: "books", summary: "Inventory movements (with pagination)", handler: getInventoryHistory,
+			statuses: []int{http.StatusOK, http.StatusNotFound, http.StatusInternalServerError}},
+		{method: http.MethodGet, path: "/books/low-stock", group: "books", summary: "Books below their reorder threshold", handler: getLowStockBooks,
+			statuses: []int{http.StatusOK, http.StatusInternalServerError}},
+
+		// Export
+		{method: http.MethodGet, path: "/books/export", group: "books", summary: "Export catalog (?format=csv or ?format=json)", handler: exportBooks,
+			response: []ExportRow{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusInternalServerError}},
+		{method: http.MethodPost, path: "/books/import", group: "books", summary: "Import books from a CSV upload", handler: importBooks,
+			response: ImportResponse{}, statuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusInternalServerError}},
+
+		// Bulk operations
+		{method: http.MethodPost, path: "/books/bulk", group: "books", summary: "Create multiple books", handler: createBulkBooks,
+			request: BulkCreateRequest{}, response: BulkCreateResponse{}, statuses: []int{http.StatusCreated, http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError}},
+	}
+}
+
+// Writes need a bearer token; see authMiddleware
+func (r apiRoute) needsAuth() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return r.path != "/login"
+}
+
+// OpenAPI
+
+// GET /openapi.json - minimal OpenAPI 3.0 document built from apiRoutes
+func getOpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, buildOpenAPISpec(apiRoutes()))
+}
+
+func buildOpenAPISpec(routes []apiRoute) gin.H {
+	schemas := gin.H{}
+	schemas["Error"] = gin.H{
+		"type": "object",
+		"properties": gin.H{
+			"error":      gin.H{"type": "string"},
+			"request_id": gin.H{"type": "string"},
+			// Field-level validation errors, or a plain explanation
+			"details": gin.H{"oneOf": []gin.H{
+				{"type": "array", "items": jsonSchema(reflect.TypeOf(FieldError{}), schemas)},
+				{"type": "string"},
+			}},
+		},
+	}
+
+	paths := gin.H{}
+	for _, r := range routes {
+		path, params := openAPIPath(r.path)
+		op := gin.H{
+			"summary":   r.summary,
+			"tags":      []string{r.group},
+			"responses": openAPIResponses(r, schemas),
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if r.request != nil {
+			op["requestBody"] = gin.H{
+				"required": true,
+				"content": gin.H{
+					"application/json": gin.H{"schema": jsonSchema(reflect.TypeOf(r.request), schemas)},
+				},
+			}
+		}
+		if r.needsAuth() {
+			op["security"] = []gin.H{{"bearerAuth": []string{}}}
+		}
+
+		item, ok := paths[path].(gin.H)
+		if !ok {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(r.method)] = op
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "Bookstore API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": gin.H{
+			"schemas": schemas,
+			"securitySchemes": gin.H{
+				"bearerAuth": gin.H{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+}
+
+// Convert /books/:id to /books/{id} and describe its path parameters
+func openAPIPath(ginPath string) (string, []gin.H) {
+	segments := strings.Split(ginPath, "/")
+	var params []gin.H
+	for i, seg := range segments {
+		if name, ok := strings.CutPrefix(seg, ":"); ok {
+			segments[i] = "{" + name + "}"
+			params = append(params, gin.H{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   gin.H{"type": "string"},
+			})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func openAPIResponses(r apiRoute, schemas gin.H) gin.H {
+	errorBody := gin.H{
+		"application/json": gin.H{"schema": gin.H{"$ref": "#/components/schemas/Error"}},
+	}
+	statuses := r.statuses
+	// Handlers that can fail on the database answer 504 when a query times out
+	if slices.Contains(statuses, http.StatusInternalServerError) {
+		statuses = append(statuses, http.StatusGatewayTimeout)
+	}
+	if !r.probe {
+		statuses = append(statuses, http.StatusTooManyRequests)
+	}
+	if r.needsAuth() {
+		statuses = append(statuses, http.StatusUnauthorized)
+	}
+
+	responses := gin.H{}
+	for i, status := range statuses {
+		resp := gin.H{"description": http.StatusText(status)}
+		switch {
+		case i == 0:
+			schema := gin.H{"type": "object"}
+			if r.response != nil {
+				schema = jsonSchema(reflect.TypeOf(r.response), schemas)
 			}
+			resp["content"] = gin.H{"application/json": gin.H{"schema": schema}}
+		case status >= http.StatusBadRequest:
+			resp["content"] = errorBody
+		}
+		responses[strconv.Itoa(status)] = resp
+	}
+	return responses
+}
+
+// Schema for a Go type; named structs go into schemas and are referenced
+func jsonSchema(t reflect.Type, schemas gin.H) gin.H {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = gin.H{} // placeholder in case the type refers to itself
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return gin.H{"$ref": "#/components/schemas/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return gin.H{"type": "array", "items": jsonSchema(t.Elem(), schemas)}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": jsonSchema(t.Elem(), schemas)}
+	case reflect.String:
+		return gin.H{"type": "string"}
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gin.H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	}
+	return gin.H{}
+}
+
+// Object schema from json tags, with constraints taken from binding tags
+func structSchema(t reflect.Type, schemas gin.H) gin.H {
+	properties := gin.H{}
+	var required []string
+	collectFields(t, schemas, properties, &required)
+
+	schema := gin.H{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func collectFields(t reflect.Type, schemas, properties gin.H, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			// Embedded structs are flattened by encoding/json
+			collectFields(f.Type, schemas, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
 		}
 
-		// Insert book
-		result, err := db.Exec(
-			"INSERT INTO books (title, author_id, isbn, price, stock, published_year, description) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			book.Title, book.AuthorID, book.ISBN, book.Price, book.Stock, book.PublishedYear, book.Description,
-		)
+		prop := jsonSchema(f.Type, schemas)
+		if f.Type.Kind() == reflect.Ptr && prop["$ref"] == nil {
+			prop["nullable"] = true
+		}
+		if applyBindingRules(f.Tag.Get("binding"), prop) {
+			*required = append(*required, name)
+		}
+		properties[name] = prop
+	}
+}
 
+// Translate validator rules into schema keywords; reports whether the field is required
+func applyBindingRules(tag string, prop gin.H) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		if key == "dive" {
+			break // later rules apply to the elements
+		}
+		if key == "required" {
+			required = true
+			continue
+		}
+		if key == "max_price" {
+			prop["maximum"] = maxBookPrice
+			continue
+		}
+		if key == "genre" && strictGenres {
+			prop["enum"] = allowedGenres
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
 		if err != nil {
-			response.Failed++
-			response.Errors = append(response.Errors,
-				fmt.Sprintf("Book '%s': %v", book.Title, err))
 			continue
 		}
+		switch prop["type"] {
+		case "string":
+			switch key {
+			case "min":
+				prop["minLength"] = n
+			case "max":
+				prop["maxLength"] = n
+			}
+		case "array":
+			switch key {
+			case "min":
+				prop["minItems"] = n
+			case "max":
+				prop["maxItems"] = n
+			}
+		case "integer", "number":
+			switch key {
+			case "min", "gte":
+				prop["minimum"] = n
+			case "max", "lte":
+				prop["maximum"] = n
+			case "gt":
+				prop["minimum"] = n
+				prop["exclusiveMinimum"] = true
+			case "lt":
+				prop["maximum"] = n
+				prop["exclusiveMaximum"] = true
+			}
+		}
+	}
+	return required
+}
 
-		// Get ID and add to success list
-		id, _ := result.LastInsertId()
-		book.ID = int(id)
-		db.QueryRow("SELECT created_at FROM books WHERE id = ?", book.ID).Scan(&book.CreatedAt)
-		response.CreatedBooks = append(response.CreatedBooks, book)
-		response.Success++
+// Timeouts
+
+const (
+	requestTimeout = 10 * time.Second // whole request, set by timeoutMiddleware
+	queryTimeout   = 5 * time.Second  // database work within one handler
+)
+
+// Middleware: bound every request with a deadline that handlers inherit
+func timeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
 	}
+}
 
-	c.JSON(http.StatusCreated, response)
+// Context for a handler's queries: canceled when the client disconnects,
+// and expiring after queryTimeout or the request deadline, whichever is first
+func queryContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), queryTimeout)
+}
+
+// Answer 504 if err came from an expired deadline; reports whether it did
+func queryTimedOut(c *gin.Context, err error) bool {
+	if !errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Database query timed out"})
+	return true
+}
+
+// 504 for timeouts, 500 with the error otherwise
+func internalError(c *gin.Context, err error) {
+	if queryTimedOut(c, err) {
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 // API Documentation
 
 // GET / - API Documentation
 func getAPIDocumentation(c *gin.Context) {
+	endpoints := make(map[string][]string)
+	for _, r := range apiRoutes() {
+		endpoints[r.group] = append(endpoints[r.group], fmt.Sprintf("%s %s - %s", r.method, r.path, r.summary))
+	}
+
 	docs := gin.H{
-		"name":    "Bookstore API",
-		"version": "1.0.0",
-		"endpoints": gin.H{
-			"books": []string{
-				"GET /books - List all books (with pagination)",
-				"GET /books/:id - Get book by ID",
-				"POST /books - Create new book",
-				"PUT /books/:id - Update book",
-				"DELETE /books/:id - Delete book",
-				"GET /books/top/expensive - Most expensive books",
-				"GET /books/top/stocked - Most stocked books",
-				"GET /books/top/recent - Recently added books",
-				"POST /books/:id/restock - Restock book",
-				"POST /books/:id/sell - Sell book",
-				"POST /books/bulk - Create multiple books",
-			},
-			"authors": []string{
-				"GET /authors - List all authors",
-				"GET /authors/:id - Get author by ID",
-				"POST /authors - Create new author",
-				"PUT /authors/:id - Update author",
-				"DELETE /authors/:id - Delete author",
-				"GET /authors/:id/books - Get author's books",
-			},
-			"statistics": []string{
-				"GET /stats - Get bookstore statistics",
-			},
-		},
+		"name":           "Bookstore API",
+		"version":        "1.0.0",
+		"authentication": "POST /login for a token, then send 'Authorization: Bearer <token>' on POST/PUT/PATCH/DELETE",
+		"request_id":     "Every response carries an X-Request-ID header (a client-sent one is kept); error bodies include it as request_id",
+		"alerts":         "Start with -alert-threshold=<USD> -alert-webhook=<url> to get a JSON POST when a sale takes total inventory value below the threshold",
+		"timeouts":       "Requests are limited to 10s and their database work to 5s; a query cut off by a timeout answers 504",
+		"idempotency":    "Send 'Idempotency-Key: <unique id>' on POST /books/bulk; a retry with the same key within 24h returns the first response instead of inserting again",
+		"endpoints":      endpoints,
 		"query_parameters": gin.H{
 			"pagination": "?page=1&limit=20",
+			"filters":    "?author=Martin&min_price=20&max_price=50&year=2008&genre=programming&sort=id|price_asc|price_desc|title|year_desc",
+			"search":     "?q=term&page=1&limit=20",
+			"currency":   "?currency=USD|EUR|GBP|JPY|VND (GET /books and GET /books/:id)",
 			"limit":      "?limit=5 (for top endpoints)",
 		},
 	}
@@ -1158,6 +3966,25 @@ func getAPIDocumentation(c *gin.Context) {
 	c.JSON(http.StatusOK, docs)
 }
 
+// Helper to load a single non-deleted book joined with its author name
+func getBookWithAuthor(ctx context.Context, id string) (BookWithAuthor, error) {
+	var b BookWithAuthor
+	var authorName sql.NullString
+	err := db.QueryRowContext(ctx, `
+		SELECT b.id, b.title, b.author_id, a.name as author_name,
+		       b.isbn, b.price, b.stock, b.published_year, b.description, COALESCE(b.genre, ''), b.created_at, b.version
+		FROM books b
+		LEFT JOIN authors a ON b.author_id = a.id
+		WHERE b.id = ? AND b.deleted_at IS NULL`, id).Scan(
+		&b.ID, &b.Title, &b.AuthorID, &authorName, &b.ISBN,
+		&b.Price, &b.Stock, &b.PublishedYear, &b.Description, &b.Genre, &b.CreatedAt, &b.Version,
+	)
+	if authorName.Valid {
+		b.AuthorName = authorName.String
+	}
+	return b, err
+}
+
 // helper
 func atoi(s string) int {
 	var i int
@@ -1166,50 +3993,103 @@ func atoi(s string) int {
 }
 
 func main() {
+	seed := flag.Bool("seed", true, "seed an empty database with sample authors and books")
+	seedFile := flag.String("seed-file", "", "JSON fixture with authors and books to seed instead of the built-in samples")
+	if v := os.Getenv("MAX_PRICE"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Fatalf("Invalid MAX_PRICE %q: %v", v, err)
+		}
+		maxBookPrice = price
+	}
+	flag.Float64Var(&maxBookPrice, "max-price", maxBookPrice, "highest accepted book price in USD; MAX_PRICE sets the default")
+	genres := flag.String("genres", strings.Join(allowedGenres, ","), "comma-separated list of accepted book genres")
+	flag.BoolVar(&strictGenres, "strict-genres", strictGenres, "reject genres outside -genres with 400")
+	flag.Float64Var(&inventoryAlert.threshold, "alert-threshold", 0, "alert when a sale takes total inventory value below this amount (USD)")
+	flag.StringVar(&inventoryAlert.webhook, "alert-webhook", "", "URL that receives inventory value alerts as a JSON POST")
+	flag.Parse()
+	if maxBookPrice < 0.01 {
+		log.Fatalf("Max price must be at least 0.01, got %v", maxBookPrice)
+	}
+	allowedGenres = nil
+	for _, g := range strings.Split(*genres, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			allowedGenres = append(allowedGenres, g)
+		}
+	}
+
 	if err := initDB(); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
-	defer db.Close()
 
-	seedAuthors()
-	seedData()
+	if *seed {
+		fixture := defaultSeed()
+		if *seedFile != "" {
+			var err error
+			if fixture, err = loadSeedFile(*seedFile); err != nil {
+				log.Fatal("Failed to load seed file:", err)
+			}
+		}
+		seedAuthors(fixture.Authors)
+		seedData(fixture.Books)
+	}
+
+	registerJSONTagNames()
+	registerMaxPriceValidator()
+	registerGenreValidator()
+	// gin.Default's logger is replaced by requestLogger; it runs first so
+	// even rate-limited and unauthorized requests get an ID and a log line
+	router := gin.New()
+	router.Use(requestLogger(), gin.Recovery(), timeoutMiddleware(requestTimeout))
+
+	// Probes are registered before the rate limiter and auth so a load
+	// balancer polling them is never throttled or rejected
+	routes := apiRoutes()
+	for _, r := range routes {
+		if r.probe {
+			router.Handle(r.method, r.path, r.handler)
+		}
+	}
 
-	router := gin.Default()
+	router.Use(rateLimitMiddleware(newIPRateLimiter()))
+	router.Use(authMiddleware())
 
-	// Documentation
-	router.GET("/", getAPIDocumentation)
+	for _, r := range routes {
+		if !r.probe {
+			router.Handle(r.method, r.path, r.handler)
+		}
+	}
 
-	// Author routes
-	router.GET("/authors", getAuthors)
-	router.GET("/authors/:id", getAuthor)
-	router.POST("/authors", createAuthor)
-	router.PUT("/authors/:id", updateAuthor)
-	router.DELETE("/authors/:id", deleteAuthor)
-	router.GET("/authors/:id/books", getAuthorBooks)
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
+	}
 
-	// Book routes (with pagination and enhanced validation)
-	router.GET("/books", getBooks)
-	router.GET("/books/:id", getBook)
-	router.POST("/books", createBook)
-	router.PUT("/books/:id", updateBook)
-	router.DELETE("/books/:id", deleteBook)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server error:", err)
+		}
+	}()
 
-	// Statistics
-	router.GET("/stats", getStatistics)
+	fmt.Println("🚀 Complete Bookstore API started on :8080")
+	fmt.Println("📚 Visit http://localhost:8080/ for API documentation")
 
-	// Top books
-	router.GET("/books/top/expensive", getTopExpensive)
-	router.GET("/books/top/stocked", getTopStocked)
-	router.GET("/books/top/recent", getRecentBooks)
+	// Wait for Ctrl+C or a termination signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
 
-	// Inventory management
-	router.POST("/books/:id/restock", restockBook)
-	router.POST("/books/:id/sell", sellBook)
+	fmt.Println("🛑 Shutting down server...")
 
-	// Bulk operations
-	router.POST("/books/bulk", createBulkBooks)
+	// Give in-flight requests up to 5 seconds to finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
 
-	fmt.Println("🚀 Complete Bookstore API started on :8080")
-	fmt.Println("📚 Visit http://localhost:8080/ for API documentation")
-	router.Run(":8080")
+	if err := db.Close(); err != nil {
+		log.Println("Failed to close database:", err)
+	}
+	fmt.Println("👋 Server stopped")
 }
\ No newline at end of file
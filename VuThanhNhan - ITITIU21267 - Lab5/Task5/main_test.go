@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// initDB opens ./bookstore.db, so the tests run from a scratch directory
+// to leave the checked-in database alone
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "bookstore-test")
+	if err != nil {
+		log.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		log.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	if err := initDB(); err != nil {
+		log.Fatal(err)
+	}
+	registerJSONTagNames()
+	registerMaxPriceValidator()
+	registerGenreValidator()
+
+	code := m.Run()
+
+	db.Close()
+	os.Chdir(wd)
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+var testBookCount int
+
+// Insert a book with the given stock and a fresh ISBN, and return its ID
+func insertTestBook(t *testing.T, title string, stock int) int64 {
+	t.Helper()
+	testBookCount++
+	isbn := fmt.Sprintf("978-%010d", testBookCount)
+	res, err := db.Exec(`INSERT INTO books (title, isbn, price, stock, published_year, description) VALUES (?, ?, ?, ?, ?, ?)`,
+		title, isbn, 10.0, stock, 2020, "Test book")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestSellBookConcurrentNoOversell(t *testing.T) {
+	const initialStock = 10
+	const sellers = 30
+
+	id := insertTestBook(t, "Concurrent Sales", initialStock)
+
+	router := gin.New()
+	router.POST("/books/:id/sell", sellBook)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	statuses := make(map[int]int)
+	for i := 0; i < sellers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/books/%d/sell", id), bytes.NewBufferString(`{"quantity": 1}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			mu.Lock()
+			statuses[w.Code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sold := statuses[http.StatusOK]
+	if sold+statuses[http.StatusBadRequest] != sellers {
+		t.Fatalf("unexpected statuses %v, want only 200 and 400", statuses)
+	}
+	if sold > initialStock {
+		t.Errorf("sold %d copies, only %d were in stock", sold, initialStock)
+	}
+
+	var stock, logged int
+	if err := db.QueryRow("SELECT stock FROM books WHERE id = ?", id).Scan(&stock); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("SELECT COALESCE(-SUM(change), 0) FROM inventory_log WHERE book_id = ? AND reason = 'sale'", id).Scan(&logged); err != nil {
+		t.Fatal(err)
+	}
+
+	if stock < 0 {
+		t.Errorf("final stock = %d, want >= 0", stock)
+	}
+	if stock != initialStock-sold {
+		t.Errorf("final stock = %d, want %d after %d sales", stock, initialStock-sold, sold)
+	}
+	if logged != sold {
+		t.Errorf("inventory log records %d sold, want %d", logged, sold)
+	}
+	// Every seller wanted one copy and there were more sellers than copies
+	if sold != initialStock {
+		t.Errorf("sold %d copies, want all %d", sold, initialStock)
+	}
+}
+
+func TestConvertPriceRoundsToCents(t *testing.T) {
+	tests := []struct {
+		usd  float64
+		code string
+		want float64
+	}{
+		{39.99, "USD", 39.99},
+		{39.99, "EUR", 36.79},  // 36.7908
+		{45.95, "EUR", 42.27},  // 42.274
+		{19.99, "GBP", 15.79},  // 15.7921
+		{1.25, "EUR", 1.15},    // 1.15
+		{0.99, "GBP", 0.78},    // 0.7821
+		{10.01, "JPY", 1496.5}, // 1496.495
+		{12.50, "VND", 317500},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v %s", tt.usd, tt.code), func(t *testing.T) {
+			if got := convertPrice(tt.usd, tt.code); got != tt.want {
+				t.Errorf("convertPrice(%v, %q) = %v, want %v", tt.usd, tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundToCents(t *testing.T) {
+	tests := []struct {
+		in, want float64
+	}{
+		{1.234, 1.23},
+		{1.235, 1.24}, // halves round away from zero
+		{1.2349, 1.23},
+		{2.5, 2.5},
+		{0.004, 0},
+		{0.005, 0.01},
+		{99.999, 100},
+	}
+	for _, tt := range tests {
+		if got := roundToCents(tt.in); got != tt.want {
+			t.Errorf("roundToCents(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestApplyCurrency(t *testing.T) {
+	tests := []struct {
+		code      string
+		price     float64
+		formatted string
+	}{
+		{"USD", 39.99, "$39.99"},
+		{"EUR", 36.79, "€36.79"},
+		{"GBP", 31.59, "£31.59"},
+		{"VND", 1015746, "₫1015746.00"},
+	}
+	for _, tt := range tests {
+		b := BookWithAuthor{}
+		b.Price = 39.99
+		applyCurrency(&b, tt.code)
+		if b.Price != tt.price || b.Currency != tt.code || b.FormattedPrice != tt.formatted {
+			t.Errorf("%s: got price %v, currency %q, formatted %q; want %v, %q, %q",
+				tt.code, b.Price, b.Currency, b.FormattedPrice, tt.price, tt.code, tt.formatted)
+		}
+	}
+}
+
+func TestGetBookCurrencyParam(t *testing.T) {
+	id := insertTestBook(t, "Priced Book", 1)
+
+	router := gin.New()
+	router.GET("/books/:id", getBook)
+
+	tests := []struct {
+		query    string
+		currency string
+		price    float64
+	}{
+		{"", "USD", 10},
+		{"?currency=eur", "EUR", 9.2},
+		{"?currency=XYZ", "USD", 10}, // unknown falls back to USD
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/books/%d%s", id, tt.query), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", tt.query, w.Code, w.Body)
+		}
+
+		var book BookWithAuthor
+		if err := json.Unmarshal(w.Body.Bytes(), &book); err != nil {
+			t.Fatal(err)
+		}
+		if book.Currency != tt.currency || book.Price != tt.price {
+			t.Errorf("GET %s: got %v %s, want %v %s", tt.query, book.Price, book.Currency, tt.price, tt.currency)
+		}
+	}
+
+	// The stored price stays in USD
+	var stored float64
+	if err := db.QueryRow("SELECT price FROM books WHERE id = ?", id).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != 10 {
+		t.Errorf("stored price = %v, want 10", stored)
+	}
+}
+
+func TestUpdateBookDuplicateISBNConflict(t *testing.T) {
+	first := insertTestBook(t, "First Book", 1)
+	second := insertTestBook(t, "Second Book", 1)
+	var takenISBN string
+	if err := db.QueryRow("SELECT isbn FROM books WHERE id = ?", first).Scan(&takenISBN); err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.PUT("/books/:id", updateBook)
+	router.PATCH("/books/:id", patchBook)
+
+	bodies := map[string]string{
+		http.MethodPut:   fmt.Sprintf(`{"title": "Second Book", "isbn": %q, "price": 10, "stock": 1, "published_year": 2020, "version": 1}`, takenISBN),
+		http.MethodPatch: fmt.Sprintf(`{"isbn": %q}`, takenISBN),
+	}
+	for method, body := range bodies {
+		req := httptest.NewRequest(method, fmt.Sprintf("/books/%d", second), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s: status %d, want 409: %s", method, w.Code, w.Body)
+			continue
+		}
+		var resp struct {
+			Error   string `json:"error"`
+			Details string `json:"details"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Error != "Duplicate ISBN" || !strings.Contains(resp.Details, fmt.Sprintf("book %d", first)) {
+			t.Errorf("%s: got %+v, want Duplicate ISBN naming book %d", method, resp, first)
+		}
+	}
+}
+
+func TestGetBooksInvalidSortListsOptions(t *testing.T) {
+	router := gin.New()
+	router.GET("/books", getBooks)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/books?sort=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", w.Code)
+	}
+
+	var resp struct {
+		Details string `json:"details"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	for key := range bookSortOrders {
+		if !strings.Contains(resp.Details, key) {
+			t.Errorf("details %q do not mention sort %q", resp.Details, key)
+		}
+	}
+}
+
+func TestGetRelatedBooksWithoutAuthor(t *testing.T) {
+	source := insertTestBook(t, "Authorless Source", 1)
+	other := insertTestBook(t, "Authorless Neighbour", 1)
+
+	router := gin.New()
+	router.GET("/books/:id/related", getRelatedBooks)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/books/%d/related?limit=100", source), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+
+	var resp struct {
+		Books []RelatedBook `json:"books"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range resp.Books {
+		if int64(b.ID) == other {
+			if b.Reason != "similar_year" {
+				t.Errorf("reason = %q, want similar_year", b.Reason)
+			}
+			return
+		}
+	}
+	t.Errorf("authorless book %d from the same year missing from related books %+v", other, resp.Books)
+}
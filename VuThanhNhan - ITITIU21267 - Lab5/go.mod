@@ -2,6 +2,11 @@ module lab5
 
 go 1.24.1
 
+require (
+	github.com/golang-jwt/jwt/v5 v5.2.2
+	golang.org/x/time v0.12.0
+)
+
 require (
 	github.com/bytedance/sonic v1.14.0 // indirect
 	github.com/bytedance/sonic/loader v0.3.0 // indirect
@@ -83,4 +83,76 @@ func main() {
 	for i, h := range hist.Calculations {
 		fmt.Printf("%d. %s\n", i+1, h)
 	}
+
+	// Test 7: Power
+	fmt.Println("\n=== Test 7: Power ===")
+	resp, err = client.Calculate(ctx, &pb.CalculateRequest{
+		A:         2,
+		B:         10,
+		Operation: "power",
+	})
+	if err == nil {
+		fmt.Printf("Result: %.2f ^ %.2f = %.2f\n", 2.0, 10.0, resp.Result)
+	}
+
+	// Test 8: Power overflow
+	fmt.Println("\n=== Test 8: Power Overflow ===")
+	_, err = client.Calculate(ctx, &pb.CalculateRequest{
+		A:         10,
+		B:         100,
+		Operation: "power",
+	})
+	if err != nil {
+		st, _ := status.FromError(err)
+		fmt.Printf("Expected error: %s\n", st.Message())
+	}
+
+	// Test 9: Clear history
+	fmt.Println("\n=== Test 9: Clear History ===")
+	cleared, err := client.ClearHistory(ctx, &pb.ClearHistoryRequest{})
+	if err == nil {
+		fmt.Printf("Cleared %d calculations\n", cleared.Cleared)
+	}
+	hist, _ = client.GetHistory(ctx, &pb.HistoryRequest{})
+	fmt.Printf("Calculations after clear: %d\n", hist.Count)
+
+	// Test 10: History limit
+	fmt.Println("\n=== Test 10: History Limit ===")
+	for i := 1; i <= 5; i++ {
+		client.Calculate(ctx, &pb.CalculateRequest{A: float32(i), B: 2, Operation: "multiply"})
+	}
+	hist, _ = client.GetHistory(ctx, &pb.HistoryRequest{Limit: 2})
+	fmt.Printf("Last %d calculations:\n", hist.Count)
+	for i, h := range hist.Calculations {
+		fmt.Printf("%d. %s\n", i+1, h)
+	}
+
+	// Test 11: Streaming average
+	fmt.Println("\n=== Test 11: Streaming Average ===")
+	stream, err := client.ComputeAverage(ctx)
+	if err != nil {
+		log.Fatalf("Failed to open stream: %v", err)
+	}
+	for _, n := range []float32{4, 8, 15, 16, 23, 42} {
+		fmt.Printf("Sending %.2f\n", n)
+		if err := stream.Send(&pb.NumberRequest{Number: n}); err != nil {
+			log.Fatalf("Failed to send: %v", err)
+		}
+	}
+	avg, err := stream.CloseAndRecv()
+	if err == nil {
+		fmt.Printf("Average of %d numbers: %.2f\n", avg.Count, avg.Average)
+	}
+
+	// Test 12: Empty stream
+	fmt.Println("\n=== Test 12: Empty Stream ===")
+	stream, err = client.ComputeAverage(ctx)
+	if err != nil {
+		log.Fatalf("Failed to open stream: %v", err)
+	}
+	_, err = stream.CloseAndRecv()
+	if err != nil {
+		st, _ := status.FromError(err)
+		fmt.Printf("Expected error: %s\n", st.Message())
+	}
 }
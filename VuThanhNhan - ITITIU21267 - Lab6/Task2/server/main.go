@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"net"
+	"sync"
 
 	pb "book-catalog-grpc/proto"
+	"book-catalog-grpc/servertls"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -15,9 +19,27 @@ import (
 
 type calculatorServer struct {
 	pb.UnimplementedCalculatorServer // Embed and ensure code update safety
+
+	// Each RPC runs in its own goroutine, so history is guarded by mu
+	mu      sync.Mutex
 	history []string
 }
 
+// Only the most recent calculations are kept
+const maxHistory = 100
+
+func (s *calculatorServer) addHistory(entry string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.history) == maxHistory {
+		// Drop the oldest entry in place so the slice never grows past the cap
+		copy(s.history, s.history[1:])
+		s.history = s.history[:maxHistory-1]
+	}
+	s.history = append(s.history, entry)
+}
+
 func (s *calculatorServer) Calculate(ctx context.Context, req *pb.CalculateRequest) (*pb.CalculateResponse, error) {
 	log.Printf("Calculate: %.2f %s %.2f", req.A, req.Operation, req.B)
 
@@ -35,12 +57,27 @@ func (s *calculatorServer) Calculate(ctx context.Context, req *pb.CalculateReque
 			return nil, status.Errorf(codes.InvalidArgument, "cannot divide by zero")
 		}
 		result = req.A / req.B
+	case "power":
+		if req.A == 0 && req.B < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot raise zero to a negative power")
+		}
+		pow := math.Pow(float64(req.A), float64(req.B))
+		if math.IsNaN(pow) {
+			// e.g. a negative base with a fractional exponent
+			return nil, status.Errorf(codes.InvalidArgument,
+				"%.2f ^ %.2f is not a real number", req.A, req.B)
+		}
+		if math.Abs(pow) > math.MaxFloat32 {
+			return nil, status.Errorf(codes.OutOfRange,
+				"%.2f ^ %.2f overflows the result type", req.A, req.B)
+		}
+		result = float32(pow)
 	default:
 		return nil, status.Errorf(codes.InvalidArgument, "unknown operation: %s", req.Operation)
 	}
 
 	entry := fmt.Sprintf("%.2f %s %.2f = %.2f", req.A, req.Operation, req.B, result)
-	s.history = append(s.history, entry)
+	s.addHistory(entry)
 
 	return &pb.CalculateResponse{
 		Result:    result,
@@ -59,7 +96,7 @@ func (s *calculatorServer) SquareRoot(ctx context.Context, req *pb.SquareRootReq
 	result := float32(math.Sqrt(float64(req.Number)))
 
 	entry := fmt.Sprintf("sqrt(%.2f) = %.2f", req.Number, result)
-	s.history = append(s.history, entry)
+	s.addHistory(entry)
 
 	return &pb.SquareRootResponse{
 		Result: result,
@@ -67,20 +104,88 @@ func (s *calculatorServer) SquareRoot(ctx context.Context, req *pb.SquareRootReq
 }
 
 func (s *calculatorServer) GetHistory(ctx context.Context, req *pb.HistoryRequest) (*pb.HistoryResponse, error) {
-	log.Println("GetHistory called")
+	log.Printf("GetHistory: limit=%d", req.Limit)
+
+	if req.Limit < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "limit cannot be negative: %d", req.Limit)
+	}
+
+	// Copy so the response isn't marshalled while another call appends
+	s.mu.Lock()
+	recent := s.history
+	if req.Limit > 0 && int(req.Limit) < len(recent) {
+		recent = recent[len(recent)-int(req.Limit):]
+	}
+	calculations := append([]string(nil), recent...)
+	s.mu.Unlock()
+
 	return &pb.HistoryResponse{
-		Calculations: s.history,
-		Count:        int32(len(s.history)),
+		Calculations: calculations,
+		Count:        int32(len(calculations)),
 	}, nil
 }
 
+func (s *calculatorServer) ClearHistory(ctx context.Context, req *pb.ClearHistoryRequest) (*pb.ClearHistoryResponse, error) {
+	s.mu.Lock()
+	cleared := len(s.history)
+	s.history = nil
+	s.mu.Unlock()
+
+	log.Printf("ClearHistory: removed %d entries", cleared)
+	return &pb.ClearHistoryResponse{
+		Cleared: int32(cleared),
+	}, nil
+}
+
+// Client streaming: numbers arrive one by one and the mean is sent back
+// once the client closes its side of the stream
+func (s *calculatorServer) ComputeAverage(stream pb.Calculator_ComputeAverageServer) error {
+	var sum float64
+	var count int32
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		sum += float64(req.Number)
+		count++
+	}
+
+	log.Printf("ComputeAverage: %d numbers", count)
+	if count == 0 {
+		return status.Errorf(codes.InvalidArgument, "cannot average an empty stream")
+	}
+
+	average := float32(sum / float64(count))
+	s.addHistory(fmt.Sprintf("average of %d numbers = %.2f", count, average))
+
+	return stream.SendAndClose(&pb.AverageResponse{
+		Average: average,
+		Count:   count,
+	})
+}
+
 func main() {
+	certFile := flag.String("tls-cert", "", "TLS certificate file; serves TLS together with -tls-key")
+	keyFile := flag.String("tls-key", "", "TLS private key file")
+	flag.Parse()
+
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	// Plaintext unless a certificate/key pair is given
+	opts, err := servertls.Options(*certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("Failed to load TLS credentials: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterCalculatorServer(grpcServer, &calculatorServer{})
 
 	log.Println("🚀 Calculator gRPC server listening on :50051")
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+
+	pb "book-catalog-grpc/proto"
+)
+
+func TestMain(m *testing.M) {
+	// Every RPC logs a line; keep the test output readable
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// Run with go test -race: Calculate and GetHistory share s.history
+func TestCalculateConcurrentHistoryCapped(t *testing.T) {
+	const callers = 20
+	const callsEach = 50
+
+	s := &calculatorServer{}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < callsEach; j++ {
+				req := &pb.CalculateRequest{A: float32(i), B: float32(j), Operation: "add"}
+				resp, err := s.Calculate(ctx, req)
+				if err != nil {
+					t.Errorf("Calculate(%v): %v", req, err)
+					return
+				}
+				if resp.Result != float32(i+j) {
+					t.Errorf("%d + %d = %v", i, j, resp.Result)
+				}
+				// Read concurrently with the writers
+				if _, err := s.GetHistory(ctx, &pb.HistoryRequest{Limit: 5}); err != nil {
+					t.Errorf("GetHistory: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	resp, err := s.GetHistory(ctx, &pb.HistoryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Count != maxHistory || len(resp.Calculations) != maxHistory {
+		t.Errorf("history holds %d entries (count %d) after %d calls, want %d",
+			len(resp.Calculations), resp.Count, callers*callsEach, maxHistory)
+	}
+}
+
+func TestAddHistoryDropsOldest(t *testing.T) {
+	s := &calculatorServer{}
+	for i := 0; i < maxHistory+5; i++ {
+		s.addHistory(string(rune('a' + i%26)))
+	}
+	if len(s.history) != maxHistory {
+		t.Fatalf("len(history) = %d, want %d", len(s.history), maxHistory)
+	}
+	// Entries 0-4 were dropped, so the oldest kept is entry 5
+	if s.history[0] != "f" {
+		t.Errorf("oldest entry = %q, want %q", s.history[0], "f")
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"time"
 
@@ -74,4 +75,35 @@ func main() {
 
 	p2, _ := client.ListBooks(ctx, &pb.ListBooksRequest{Page: 2, PageSize: 3})
 	fmt.Println("Page 2:", len(p2.Books), "books")
+
+	// --- Stream Books ---
+	fmt.Println("\n=== Test 7: Stream Books ===")
+	stream, err := client.StreamBooks(ctx, &pb.StreamBooksRequest{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	count := 0
+	for {
+		b, err := stream.Recv()
+		if err == io.EOF {
+			break // server finished sending
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		count++
+		fmt.Printf("%d. %s by %s - $%.2f\n", count, b.Title, b.Author, b.Price)
+	}
+	fmt.Println("Streamed", count, "books")
+
+	// --- Statistics ---
+	fmt.Println("\n=== Test 8: Get Statistics ===")
+	stats, err := client.GetStats(ctx, &pb.GetStatsRequest{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Total books: %d\n", stats.TotalBooks)
+	fmt.Printf("Average price: $%.2f\n", stats.AveragePrice)
+	fmt.Printf("Total stock: %d\n", stats.TotalStock)
+	fmt.Printf("Year range: %d - %d\n", stats.EarliestYear, stats.LatestYear)
 }
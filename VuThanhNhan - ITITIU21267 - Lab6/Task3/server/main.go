@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"net"
 
 	pb "book-catalog-grpc/proto/proto"
+	"book-catalog-grpc/servertls"
 	_ "github.com/mattn/go-sqlite3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -158,6 +160,71 @@ func (s *bookCatalogServer) ListBooks(ctx context.Context, req *pb.ListBooksRequ
 	}, nil
 }
 
+// ======================== StreamBooks (Server Streaming) ============================
+
+// StreamBooks sends every book as its own message. Unlike ListBooks the
+// client doesn't need one round trip per page, and neither side has to hold
+// the whole catalog in memory: rows are sent as they are scanned, and OFFSET
+// paging can't skip or repeat rows when books are added mid-listing.
+func (s *bookCatalogServer) StreamBooks(req *pb.StreamBooksRequest, stream pb.BookCatalog_StreamBooksServer) error {
+	ctx := stream.Context()
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, title, author, isbn, price, stock, published_year FROM books ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		// Stop early if the client cancelled or the deadline passed
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		var b pb.Book
+		if err := rows.Scan(&b.Id, &b.Title, &b.Author, &b.Isbn, &b.Price, &b.Stock, &b.PublishedYear); err != nil {
+			return err
+		}
+		if err := stream.Send(&b); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ======================== GetStats ============================
+
+func (s *bookCatalogServer) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
+	// COUNT is never NULL, but the other aggregates are NULL on an empty table
+	var totalBooks int32
+	var avgPrice sql.NullFloat64
+	var totalStock, earliest, latest sql.NullInt64
+	err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*), AVG(price), SUM(stock), MIN(published_year), MAX(published_year) FROM books",
+	).Scan(&totalBooks, &avgPrice, &totalStock, &earliest, &latest)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to compute stats: %v", err)
+	}
+
+	resp := &pb.GetStatsResponse{TotalBooks: totalBooks}
+	if avgPrice.Valid {
+		resp.AveragePrice = float32(avgPrice.Float64)
+	}
+	if totalStock.Valid {
+		resp.TotalStock = int32(totalStock.Int64)
+	}
+	if earliest.Valid {
+		resp.EarliestYear = int32(earliest.Int64)
+	}
+	if latest.Valid {
+		resp.LatestYear = int32(latest.Int64)
+	}
+
+	return resp, nil
+}
+
 // ===================== DB Initialization =======================
 
 func initDB() (*sql.DB, error) {
@@ -202,6 +269,10 @@ func initDB() (*sql.DB, error) {
 // ============================ main =============================
 
 func main() {
+	certFile := flag.String("tls-cert", "", "TLS certificate file; serves TLS together with -tls-key")
+	keyFile := flag.String("tls-key", "", "TLS private key file")
+	flag.Parse()
+
 	db, err := initDB()
 	if err != nil {
 		log.Fatal("DB init error:", err)
@@ -212,7 +283,13 @@ func main() {
 		log.Fatal(err)
 	}
 
-	s := grpc.NewServer()
+	// Plaintext unless a certificate/key pair is given
+	opts, err := servertls.Options(*certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("Failed to load TLS credentials: %v", err)
+	}
+
+	s := grpc.NewServer(opts...)
 	pb.RegisterBookCatalogServer(s, &bookCatalogServer{db: db})
 
 	fmt.Println("📚 Book Catalog gRPC server running on :50052")
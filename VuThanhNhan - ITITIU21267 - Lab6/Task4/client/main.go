@@ -13,15 +13,41 @@ import (
 
 )
 
+const serverAddr = "localhost:50052"
+
+// Retry calls that fail with UNAVAILABLE (server restarting, connection
+// dropped) with exponential backoff before giving up
+const retryServiceConfig = `{
+	"methodConfig": [{
+		"name": [{"service": "bookservice.BookCatalog"}],
+		"retryPolicy": {
+			"maxAttempts": 4,
+			"initialBackoff": "0.2s",
+			"maxBackoff": "2s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// How long to wait for the server to come up before giving up
+const readyTimeout = 10 * time.Second
+
 func main() {
-	conn, err := grpc.Dial("localhost:50052",
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// WaitForReady makes calls wait for the connection instead of failing
+	// fast while the server is still starting
+	conn, err := grpc.NewClient(serverAddr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(retryServiceConfig),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)))
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer conn.Close()
 
 	client := pb.NewBookCatalogClient(conn)
+	waitForServer(client)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -114,6 +140,17 @@ func doStats(ctx context.Context, client pb.BookCatalogClient) {
 	fmt.Printf("Year range: %d - %d\n", resp.EarliestYear, resp.LatestYear)
 }
 
+// Readiness check: ping GetStats so an unreachable server is reported
+// up front instead of as a failure in the first test
+func waitForServer(client pb.BookCatalogClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+
+	if _, err := client.GetStats(ctx, &pb.GetStatsRequest{}); err != nil {
+		log.Fatalf("Book catalog server at %s is unreachable after %v: %v", serverAddr, readyTimeout, err)
+	}
+}
+
 // Helper: clean gRPC error to match required output
 func printGrpcError(err error) {
 	if err == nil {
@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"net"
 	"strings"
 
 	pb "book-catalog-grpc/proto/proto"
+	"book-catalog-grpc/servertls"
 
 	_ "github.com/mattn/go-sqlite3"
 	"google.golang.org/grpc"
@@ -376,6 +378,10 @@ func initDB() (*sql.DB, error) {
 
 // ============================ main =============================
 func main() {
+	certFile := flag.String("tls-cert", "", "TLS certificate file; serves TLS together with -tls-key")
+	keyFile := flag.String("tls-key", "", "TLS private key file")
+	flag.Parse()
+
 	db, err := initDB()
 	if err != nil {
 		log.Fatal("DB init error:", err)
@@ -386,7 +392,13 @@ func main() {
 		log.Fatal(err)
 	}
 
-	s := grpc.NewServer()
+	// Plaintext unless a certificate/key pair is given
+	opts, err := servertls.Options(*certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("Failed to load TLS credentials: %v", err)
+	}
+
+	s := grpc.NewServer(opts...)
 	pb.RegisterBookCatalogServer(s, &bookCatalogServer{db: db})
 
 	fmt.Println("📚 Book Catalog gRPC server running on :50052")
@@ -3,13 +3,18 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"log"
 	"net"
+	"time"
 	
+	"book-catalog-grpc/Task5/interceptor"
 	authorpb "book-catalog-grpc/proto/proto"
 	bookpb "book-catalog-grpc/proto/proto"
+	"book-catalog-grpc/servertls"
 	_ "github.com/mattn/go-sqlite3"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -29,8 +34,6 @@ func newServer(db *sql.DB, bookClient bookpb.BookCatalogClient) *authorCatalogSe
 }
 
 func (s *authorCatalogServer) GetAuthor(ctx context.Context, req *authorpb.GetAuthorRequest) (*authorpb.GetAuthorResponse, error) {
-	log.Printf("GetAuthor: id=%d", req.Id)
-	
 	var author authorpb.Author
 	err := s.db.QueryRowContext(ctx,
 		"SELECT id, name, bio, birth_year, country FROM authors WHERE id = ?",
@@ -50,8 +53,6 @@ func (s *authorCatalogServer) GetAuthor(ctx context.Context, req *authorpb.GetAu
 }
 
 func (s *authorCatalogServer) CreateAuthor(ctx context.Context, req *authorpb.CreateAuthorRequest) (*authorpb.CreateAuthorResponse, error) {
-	log.Printf("CreateAuthor: name=%s", req.Name)
-	
 	// Validate input
 	if req.Name == "" {
 		return nil, status.Error(codes.InvalidArgument, "name is required")
@@ -84,8 +85,6 @@ func (s *authorCatalogServer) CreateAuthor(ctx context.Context, req *authorpb.Cr
 }
 
 func (s *authorCatalogServer) ListAuthors(ctx context.Context, req *authorpb.ListAuthorsRequest) (*authorpb.ListAuthorsResponse, error) {
-	log.Printf("ListAuthors: page=%d, page_size=%d", req.Page, req.PageSize)
-	
 	// Set defaults
 	if req.Page <= 0 {
 		req.Page = 1
@@ -131,8 +130,6 @@ func (s *authorCatalogServer) ListAuthors(ctx context.Context, req *authorpb.Lis
 }
 
 func (s *authorCatalogServer) GetAuthorBooks(ctx context.Context, req *authorpb.GetAuthorBooksRequest) (*authorpb.GetAuthorBooksResponse, error) {
-	log.Printf("GetAuthorBooks: author_id=%d", req.AuthorId)
-	
 	// Get author from database
 	var author authorpb.Author
 	err := s.db.QueryRowContext(ctx,
@@ -149,11 +146,23 @@ func (s *authorCatalogServer) GetAuthorBooks(ctx context.Context, req *authorpb.
 	
 	// Call Book service to get books by this author
 	// This demonstrates service-to-service communication!
-	bookResp, err := s.bookClient.GetBooksByAuthor(ctx, &bookpb.GetBooksByAuthorRequest{
+	// The call gets its own short deadline so a slow book service can't
+	// use up the caller's whole budget
+	bookCtx, cancel := context.WithTimeout(ctx, bookCallTimeout)
+	defer cancel()
+	bookResp, err := s.bookClient.GetBooksByAuthor(bookCtx, &bookpb.GetBooksByAuthorRequest{
 		AuthorId: req.AuthorId,
 	})
 	if err != nil {
 		log.Printf("Failed to get books: %v", err)
+		if req.Strict {
+			switch status.Code(err) {
+			case codes.Unavailable, codes.DeadlineExceeded:
+				return nil, status.Errorf(codes.Unavailable, "book service is unreachable, books for author %d unknown", req.AuthorId)
+			default:
+				return nil, status.Errorf(codes.Internal, "book service error: %s", status.Convert(err).Message())
+			}
+		}
 		// Continue even if book service fails
 		return &authorpb.GetAuthorBooksResponse{
 			Author:    &author,
@@ -180,9 +189,25 @@ func (s *authorCatalogServer) GetAuthorBooks(ctx context.Context, req *authorpb.
 	}, nil
 }
 
-func connectToBookService() (bookpb.BookCatalogClient, error) {
-	conn, err := grpc.Dial("localhost:50051",
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+// Deadline for each call to the Book service
+const bookCallTimeout = 2 * time.Second
+
+// NewClient doesn't connect until the first call, and the ClientConn
+// reconnects with backoff on its own, so the Book service may start later
+// or restart without breaking this client. With caFile set the Book service
+// is expected to serve TLS with a certificate signed by (or equal to) it.
+func connectToBookService(caFile string) (bookpb.BookCatalogClient, error) {
+	creds := insecure.NewCredentials()
+	if caFile != "" {
+		var err error
+		creds, err = credentials.NewClientTLSFromFile(caFile, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := grpc.NewClient("localhost:50051",
+		grpc.WithTransportCredentials(creds))
 	if err != nil {
 		return nil, err
 	}
@@ -232,6 +257,11 @@ func initDB() (*sql.DB, error) {
 }
 
 func main() {
+	certFile := flag.String("tls-cert", "", "TLS certificate file; serves TLS together with -tls-key")
+	keyFile := flag.String("tls-key", "", "TLS private key file")
+	bookCA := flag.String("book-ca", "", "certificate to trust when the Book service uses TLS")
+	flag.Parse()
+
 	// Initialize database
 	db, err := initDB()
 	if err != nil {
@@ -240,7 +270,7 @@ func main() {
 	defer db.Close()
 	
 	// Connect to Book service
-	bookClient, err := connectToBookService()
+	bookClient, err := connectToBookService(*bookCA)
 	if err != nil {
 		log.Fatalf("Failed to connect to Book service: %v", err)
 	}
@@ -251,8 +281,14 @@ func main() {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 	
-	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	// Create gRPC server; every unary call is logged by the interceptor.
+	// Plaintext unless a certificate/key pair is given
+	opts, err := servertls.Options(*certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("Failed to load TLS credentials: %v", err)
+	}
+	opts = append(opts, grpc.UnaryInterceptor(interceptor.UnaryLogging))
+	grpcServer := grpc.NewServer(opts...)
 	
 	// Register service
 	authorpb.RegisterAuthorCatalogServer(grpcServer, newServer(db, bookClient))
@@ -3,14 +3,21 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"strings"
+	"time"
 
+	"book-catalog-grpc/Task5/interceptor"
 	pb "book-catalog-grpc/proto/proto"
+	"book-catalog-grpc/servertls"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -21,16 +28,89 @@ type bookCatalogServer struct {
 	db *sql.DB
 }
 
+// Oldest year accepted for published_year (Gutenberg's press)
+const minPublishedYear = 1450
+
+// Check the fields shared by create, update and upsert; returns an
+// InvalidArgument status describing the first bad field
+func validateBook(title, author, isbn string, price float32, year int32) error {
+	if strings.TrimSpace(title) == "" || strings.TrimSpace(author) == "" {
+		return status.Error(codes.InvalidArgument, "title and author are required")
+	}
+	if strings.TrimSpace(isbn) == "" {
+		return status.Error(codes.InvalidArgument, "isbn is required")
+	}
+	if price <= 0 {
+		return status.Errorf(codes.InvalidArgument, "price must be greater than 0, got %.2f", price)
+	}
+	if maxYear := int32(time.Now().Year() + 1); year < minPublishedYear || year > maxYear {
+		return status.Errorf(codes.InvalidArgument, "published_year must be between %d and %d, got %d", minPublishedYear, maxYear, year)
+	}
+	return nil
+}
+
+// Map a failed insert/update to a gRPC status; a UNIQUE violation on
+// isbn becomes AlreadyExists
+func writeError(err error, isbn, action string) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return status.Errorf(codes.AlreadyExists, "a book with isbn %q already exists", isbn)
+	}
+	return status.Errorf(codes.Internal, "failed to %s book: %v", action, err)
+}
+
+// Columns read by scanBook, in order; tags are stored as a JSON array
+const bookColumns = "id, title, author, isbn, price, stock, published_year, COALESCE(author_id, 0), COALESCE(category, ''), COALESCE(tags, '[]')"
+
+// Implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Scan one row selected with bookColumns
+func scanBook(row rowScanner) (*pb.Book, error) {
+	var b pb.Book
+	var tags string
+	if err := row.Scan(&b.Id, &b.Title, &b.Author, &b.Isbn, &b.Price, &b.Stock,
+		&b.PublishedYear, &b.AuthorId, &b.Category, &tags); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tags), &b.Tags); err != nil {
+		return nil, fmt.Errorf("bad tags for book %d: %w", b.Id, err)
+	}
+	return &b, nil
+}
+
+// Lowercase and trim a category or tag so matching is case-insensitive
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// Normalize tags, dropping blanks and duplicates; returns the JSON stored
+// in the tags column alongside the cleaned list
+func encodeTags(tags []string) (string, []string) {
+	clean := []string{}
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		t = normalizeLabel(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		clean = append(clean, t)
+	}
+	data, _ := json.Marshal(clean)
+	return string(data), clean
+}
+
 // ======================== GetBook ============================
 func (s *bookCatalogServer) GetBook(ctx context.Context, req *pb.GetBookRequest) (*pb.GetBookResponse, error) {
 	row := s.db.QueryRowContext(ctx,
-		"SELECT id, title, author, isbn, price, stock, published_year, author_id FROM books WHERE id = ?",
+		"SELECT "+bookColumns+" FROM books WHERE id = ?",
 		req.Id,
 	)
 
-	var book pb.Book
-	err := row.Scan(&book.Id, &book.Title, &book.Author, &book.Isbn,
-		&book.Price, &book.Stock, &book.PublishedYear, &book.AuthorId)
+	book, err := scanBook(row)
 
 	if err == sql.ErrNoRows {
 		return nil, status.Errorf(codes.NotFound, "book not found: id=%d", req.Id)
@@ -39,16 +119,48 @@ func (s *bookCatalogServer) GetBook(ctx context.Context, req *pb.GetBookRequest)
 		return nil, status.Errorf(codes.Internal, "db error: %v", err)
 	}
 
-	return &pb.GetBookResponse{Book: &book}, nil
+	return &pb.GetBookResponse{Book: book}, nil
 }
 
 // ======================== GetBooksByAuthor ============================
+// ORDER BY can't take a placeholder, so sort_by is mapped through this
+// whitelist instead of being put into the query
+var authorBookOrder = map[string]string{
+	"":      "id",
+	"price": "price, id",
+	"year":  "published_year, id",
+	"title": "title COLLATE NOCASE, id",
+}
+
 func (s *bookCatalogServer) GetBooksByAuthor(ctx context.Context, req *pb.GetBooksByAuthorRequest) (*pb.GetBooksByAuthorResponse, error) {
-	log.Printf("GetBooksByAuthor: author_id=%d", req.AuthorId)
-	
+	orderBy, ok := authorBookOrder[req.SortBy]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown sort_by %q (want price, year or title)", req.SortBy)
+	}
+	if req.Page < 0 || req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page and page_size must not be negative")
+	}
+	if req.Page == 0 {
+		req.Page = 1
+	}
+
+	var total int32
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM books WHERE author_id = ?", req.AuthorId,
+	).Scan(&total); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to count books: %v", err)
+	}
+
+	// page_size 0 keeps the old behaviour of returning everything
+	// (LIMIT -1 means no limit in SQLite)
+	limit, offset := int32(-1), int32(0)
+	if req.PageSize > 0 {
+		limit, offset = req.PageSize, (req.Page-1)*req.PageSize
+	}
+
 	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, title, author, isbn, price, stock, published_year, author_id FROM books WHERE author_id = ?",
-		req.AuthorId,
+		"SELECT "+bookColumns+" FROM books WHERE author_id = ? ORDER BY "+orderBy+" LIMIT ? OFFSET ?",
+		req.AuthorId, limit, offset,
 	)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "db error: %v", err)
@@ -57,15 +169,48 @@ func (s *bookCatalogServer) GetBooksByAuthor(ctx context.Context, req *pb.GetBoo
 
 	books := []*pb.Book{}
 	for rows.Next() {
-		var b pb.Book
-		if err := rows.Scan(&b.Id, &b.Title, &b.Author, &b.Isbn,
-			&b.Price, &b.Stock, &b.PublishedYear, &b.AuthorId); err != nil {
+		b, err := scanBook(rows)
+		if err != nil {
 			return nil, status.Errorf(codes.Internal, "scan error: %v", err)
 		}
-		books = append(books, &b)
+		books = append(books, b)
 	}
 
 	return &pb.GetBooksByAuthorResponse{
+		Books:    books,
+		Count:    int32(len(books)),
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}, nil
+}
+
+// ======================== GetBooksByCategory ============================
+func (s *bookCatalogServer) GetBooksByCategory(ctx context.Context, req *pb.GetBooksByCategoryRequest) (*pb.GetBooksByCategoryResponse, error) {
+	category := normalizeLabel(req.Category)
+	if category == "" {
+		return nil, status.Error(codes.InvalidArgument, "category is required")
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT "+bookColumns+" FROM books WHERE category = ?",
+		category,
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "db error: %v", err)
+	}
+	defer rows.Close()
+
+	books := []*pb.Book{}
+	for rows.Next() {
+		b, err := scanBook(rows)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "scan error: %v", err)
+		}
+		books = append(books, b)
+	}
+
+	return &pb.GetBooksByCategoryResponse{
 		Books: books,
 		Count: int32(len(books)),
 	}, nil
@@ -73,18 +218,19 @@ func (s *bookCatalogServer) GetBooksByAuthor(ctx context.Context, req *pb.GetBoo
 
 // ======================== CreateBook ============================
 func (s *bookCatalogServer) CreateBook(ctx context.Context, req *pb.CreateBookRequest) (*pb.CreateBookResponse, error) {
-	log.Printf("CreateBook: title=%s, author_id=%d", req.Title, req.AuthorId)
-	
-	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.Author) == "" {
-		return nil, status.Error(codes.InvalidArgument, "title and author are required")
+	if err := validateBook(req.Title, req.Author, req.Isbn, req.Price, req.PublishedYear); err != nil {
+		return nil, err
 	}
 
+	category := normalizeLabel(req.Category)
+	tagsJSON, tags := encodeTags(req.Tags)
+
 	res, err := s.db.ExecContext(ctx,
-		"INSERT INTO books (title, author, isbn, price, stock, published_year, author_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		req.Title, req.Author, req.Isbn, req.Price, req.Stock, req.PublishedYear, req.AuthorId)
+		"INSERT INTO books (title, author, isbn, price, stock, published_year, author_id, category, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		req.Title, req.Author, req.Isbn, req.Price, req.Stock, req.PublishedYear, req.AuthorId, category, tagsJSON)
 
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create book: %v", err)
+		return nil, writeError(err, req.Isbn, "create")
 	}
 
 	id, _ := res.LastInsertId()
@@ -99,18 +245,27 @@ func (s *bookCatalogServer) CreateBook(ctx context.Context, req *pb.CreateBookRe
 			Stock:         req.Stock,
 			PublishedYear: req.PublishedYear,
 			AuthorId:      req.AuthorId,
+			Category:      category,
+			Tags:          tags,
 		},
 	}, nil
 }
 
 // ======================== UpdateBook ============================
 func (s *bookCatalogServer) UpdateBook(ctx context.Context, req *pb.UpdateBookRequest) (*pb.UpdateBookResponse, error) {
+	if err := validateBook(req.Title, req.Author, req.Isbn, req.Price, req.PublishedYear); err != nil {
+		return nil, err
+	}
+
+	category := normalizeLabel(req.Category)
+	tagsJSON, tags := encodeTags(req.Tags)
+
 	res, err := s.db.ExecContext(ctx,
-		`UPDATE books SET title=?, author=?, isbn=?, price=?, stock=?, published_year=?, author_id=? WHERE id=?`,
-		req.Title, req.Author, req.Isbn, req.Price, req.Stock, req.PublishedYear, req.AuthorId, req.Id)
+		`UPDATE books SET title=?, author=?, isbn=?, price=?, stock=?, published_year=?, author_id=?, category=?, tags=? WHERE id=?`,
+		req.Title, req.Author, req.Isbn, req.Price, req.Stock, req.PublishedYear, req.AuthorId, category, tagsJSON, req.Id)
 
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to update book: %v", err)
+		return nil, writeError(err, req.Isbn, "update")
 	}
 
 	rows, _ := res.RowsAffected()
@@ -128,6 +283,8 @@ func (s *bookCatalogServer) UpdateBook(ctx context.Context, req *pb.UpdateBookRe
 			Stock:         req.Stock,
 			PublishedYear: req.PublishedYear,
 			AuthorId:      req.AuthorId,
+			Category:      category,
+			Tags:          tags,
 		},
 	}, nil
 }
@@ -172,7 +329,7 @@ func (s *bookCatalogServer) ListBooks(ctx context.Context, req *pb.ListBooksRequ
 
 	// Query with pagination
 	rows, err := s.db.QueryContext(ctx,
-		"SELECT id, title, author, isbn, price, stock, published_year, COALESCE(author_id, 0) FROM books LIMIT ? OFFSET ?",
+		"SELECT "+bookColumns+" FROM books LIMIT ? OFFSET ?",
 		req.PageSize, offset)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "query failed: %v", err)
@@ -181,11 +338,11 @@ func (s *bookCatalogServer) ListBooks(ctx context.Context, req *pb.ListBooksRequ
 
 	books := []*pb.Book{}
 	for rows.Next() {
-		var b pb.Book
-		if err := rows.Scan(&b.Id, &b.Title, &b.Author, &b.Isbn, &b.Price, &b.Stock, &b.PublishedYear, &b.AuthorId); err != nil {
+		b, err := scanBook(rows)
+		if err != nil {
 			return nil, status.Errorf(codes.Internal, "scan failed: %v", err)
 		}
-		books = append(books, &b)
+		books = append(books, b)
 	}
 
 	return &pb.ListBooksResponse{
@@ -198,13 +355,6 @@ func (s *bookCatalogServer) ListBooks(ctx context.Context, req *pb.ListBooksRequ
 
 // ======================== SearchBooks ============================
 func (s *bookCatalogServer) SearchBooks(ctx context.Context, req *pb.SearchBooksRequest) (*pb.SearchBooksResponse, error) {
-	logPrefix := "SearchBooks"
-	logf := func(msg string, args ...interface{}) {
-		log.Printf("%s: %s", logPrefix, fmt.Sprintf(msg, args...))
-	}
-
-	logf("query=%q, field=%q", req.Query, req.Field)
-
 	if strings.TrimSpace(req.Query) == "" {
 		return nil, status.Error(codes.InvalidArgument, "search query required")
 	}
@@ -217,16 +367,16 @@ func (s *bookCatalogServer) SearchBooks(ctx context.Context, req *pb.SearchBooks
 
 	switch field {
 	case "title":
-		sqlQuery = "SELECT id, title, author, isbn, price, stock, published_year, COALESCE(author_id, 0) FROM books WHERE title LIKE ?"
+		sqlQuery = "SELECT " + bookColumns + " FROM books WHERE title LIKE ?"
 		args = []interface{}{searchPattern}
 	case "author":
-		sqlQuery = "SELECT id, title, author, isbn, price, stock, published_year, COALESCE(author_id, 0) FROM books WHERE author LIKE ?"
+		sqlQuery = "SELECT " + bookColumns + " FROM books WHERE author LIKE ?"
 		args = []interface{}{searchPattern}
 	case "isbn":
-		sqlQuery = "SELECT id, title, author, isbn, price, stock, published_year, COALESCE(author_id, 0) FROM books WHERE isbn = ?"
+		sqlQuery = "SELECT " + bookColumns + " FROM books WHERE isbn = ?"
 		args = []interface{}{req.Query}
 	case "all", "":
-		sqlQuery = `SELECT id, title, author, isbn, price, stock, published_year, COALESCE(author_id, 0)
+		sqlQuery = "SELECT " + bookColumns + `
 		            FROM books 
 		            WHERE title LIKE ? OR author LIKE ? OR isbn LIKE ?`
 		args = []interface{}{searchPattern, searchPattern, searchPattern}
@@ -242,11 +392,11 @@ func (s *bookCatalogServer) SearchBooks(ctx context.Context, req *pb.SearchBooks
 
 	books := []*pb.Book{}
 	for rows.Next() {
-		var b pb.Book
-		if err := rows.Scan(&b.Id, &b.Title, &b.Author, &b.Isbn, &b.Price, &b.Stock, &b.PublishedYear, &b.AuthorId); err != nil {
+		b, err := scanBook(rows)
+		if err != nil {
 			return nil, status.Errorf(codes.Internal, "scan failed: %v", err)
 		}
-		books = append(books, &b)
+		books = append(books, b)
 	}
 
 	return &pb.SearchBooksResponse{
@@ -258,8 +408,6 @@ func (s *bookCatalogServer) SearchBooks(ctx context.Context, req *pb.SearchBooks
 
 // ======================== FilterBooks ============================
 func (s *bookCatalogServer) FilterBooks(ctx context.Context, req *pb.FilterBooksRequest) (*pb.FilterBooksResponse, error) {
-	log.Printf("FilterBooks: price[%.2f-%.2f], year[%d-%d]", req.MinPrice, req.MaxPrice, req.MinYear, req.MaxYear)
-
 	if req.MinPrice < 0 || req.MaxPrice < 0 {
 		return nil, status.Error(codes.InvalidArgument, "price cannot be negative")
 	}
@@ -270,7 +418,7 @@ func (s *bookCatalogServer) FilterBooks(ctx context.Context, req *pb.FilterBooks
 		return nil, status.Error(codes.InvalidArgument, "min_year cannot be greater than max_year")
 	}
 
-	query := "SELECT id, title, author, isbn, price, stock, published_year, COALESCE(author_id, 0) FROM books WHERE 1=1"
+	query := "SELECT " + bookColumns + " FROM books WHERE 1=1"
 	var args []interface{}
 
 	if req.MinPrice > 0 {
@@ -289,6 +437,10 @@ func (s *bookCatalogServer) FilterBooks(ctx context.Context, req *pb.FilterBooks
 		query += " AND published_year <= ?"
 		args = append(args, req.MaxYear)
 	}
+	if tag := normalizeLabel(req.Tag); tag != "" {
+		query += " AND EXISTS (SELECT 1 FROM json_each(books.tags) WHERE value = ?)"
+		args = append(args, tag)
+	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -298,11 +450,11 @@ func (s *bookCatalogServer) FilterBooks(ctx context.Context, req *pb.FilterBooks
 
 	books := []*pb.Book{}
 	for rows.Next() {
-		var b pb.Book
-		if err := rows.Scan(&b.Id, &b.Title, &b.Author, &b.Isbn, &b.Price, &b.Stock, &b.PublishedYear, &b.AuthorId); err != nil {
+		b, err := scanBook(rows)
+		if err != nil {
 			return nil, status.Errorf(codes.Internal, "scan failed: %v", err)
 		}
-		books = append(books, &b)
+		books = append(books, b)
 	}
 
 	return &pb.FilterBooksResponse{
@@ -313,8 +465,6 @@ func (s *bookCatalogServer) FilterBooks(ctx context.Context, req *pb.FilterBooks
 
 // ======================== GetStats ============================
 func (s *bookCatalogServer) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
-	log.Println("GetStats called")
-
 	var totalBooks int32
 	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books").Scan(&totalBooks); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to count books: %v", err)
@@ -362,6 +512,96 @@ func (s *bookCatalogServer) GetStats(ctx context.Context, req *pb.GetStatsReques
 	return resp, nil
 }
 
+// ======================== BulkUpsert (Bidirectional Streaming) ============================
+// All upserts run in one transaction, committed when the client closes its
+// side of the stream. The tx is bound to the stream context, so a cancelled
+// or timed out stream rolls everything back. Ids in the results only stick
+// if the RPC itself finishes with OK.
+func (s *bookCatalogServer) BulkUpsert(stream pb.BookCatalog_BulkUpsertServer) error {
+	ctx := stream.Context()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	var created, updated, failed int
+	for index := int32(0); ; index++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			if err := tx.Commit(); err != nil {
+				return status.Errorf(codes.Internal, "failed to commit: %v", err)
+			}
+			log.Printf("BulkUpsert: %d created, %d updated, %d failed", created, updated, failed)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := upsertBook(ctx, tx, req)
+		result.Index = index
+		switch result.Status {
+		case "created":
+			created++
+		case "updated":
+			updated++
+		default:
+			failed++
+		}
+
+		if err := stream.Send(result); err != nil {
+			return err
+		}
+	}
+}
+
+// Insert or update one book by ISBN; problems are reported in the result
+// rather than returned so one bad entry doesn't abort the stream
+func upsertBook(ctx context.Context, tx *sql.Tx, req *pb.UpsertBookRequest) *pb.UpsertBookResult {
+	result := &pb.UpsertBookResult{Isbn: req.Isbn}
+	fail := func(format string, args ...interface{}) *pb.UpsertBookResult {
+		result.Status = "error"
+		result.Error = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	if err := validateBook(req.Title, req.Author, req.Isbn, req.Price, req.PublishedYear); err != nil {
+		return fail("%s", status.Convert(err).Message())
+	}
+	if req.Stock < 0 {
+		return fail("stock cannot be negative")
+	}
+
+	var id int64
+	err := tx.QueryRowContext(ctx, "SELECT id FROM books WHERE isbn = ?", req.Isbn).Scan(&id)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO books (title, author, isbn, price, stock, published_year, author_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			req.Title, req.Author, req.Isbn, req.Price, req.Stock, req.PublishedYear, req.AuthorId)
+		if err != nil {
+			return fail("insert failed: %v", err)
+		}
+		id, _ = res.LastInsertId()
+		result.Status = "created"
+	case err != nil:
+		return fail("lookup failed: %v", err)
+	default:
+		_, err := tx.ExecContext(ctx,
+			`UPDATE books SET title=?, author=?, price=?, stock=?, published_year=?, author_id=? WHERE id=?`,
+			req.Title, req.Author, req.Price, req.Stock, req.PublishedYear, req.AuthorId, id)
+		if err != nil {
+			return fail("update failed: %v", err)
+		}
+		result.Status = "updated"
+	}
+
+	result.Id = int32(id)
+	return result
+}
+
 // ===================== DB Initialization =======================
 func initDB() (*sql.DB, error) {
 	db, err := sql.Open("sqlite3", "./books.db")
@@ -374,17 +614,42 @@ func initDB() (*sql.DB, error) {
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
 			title TEXT,
 			author TEXT,
-			isbn TEXT,
+			isbn TEXT UNIQUE,
 			price REAL,
 			stock INTEGER,
 			published_year INTEGER,
-			author_id INTEGER DEFAULT 0
+			author_id INTEGER DEFAULT 0,
+			category TEXT DEFAULT '',
+			tags TEXT DEFAULT '[]'
 		);
 	`)
 	if err != nil {
 		return nil, err
 	}
 
+	// Older databases predate category/tags
+	added, err := addCategoryColumns(db)
+	if err != nil {
+		return nil, err
+	}
+	if added {
+		tagSampleBooks(db)
+	}
+
+	// Tables created before isbn was UNIQUE get the constraint as an index.
+	// Existing duplicates are never deleted here; the operator has to resolve them.
+	dups, err := duplicateISBNs(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(dups) > 0 {
+		return nil, fmt.Errorf("cannot enforce unique ISBNs, books.db has duplicates (isbn: ids): %s; "+
+			"remove or fix the extra rows and restart", strings.Join(dups, "; "))
+	}
+	if _, err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn ON books(isbn)`); err != nil {
+		return nil, err
+	}
+
 	// Seed only when empty
 	var count int
 	db.QueryRow("SELECT COUNT(*) FROM books").Scan(&count)
@@ -399,13 +664,102 @@ func initDB() (*sql.DB, error) {
 			('Deep Work','Cal Newport','9781455586691',29.99,20,2016),
 			('Learning Go','Jon Bodner','9781492077213',31.50,10,2021);
 		`)
+		tagSampleBooks(db)
 	}
 
 	return db, nil
 }
 
+// ISBNs held by more than one book, as "isbn: id1,id2". NULL ISBNs don't
+// count because a UNIQUE index allows any number of them.
+func duplicateISBNs(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT isbn, GROUP_CONCAT(id) FROM books
+		WHERE isbn IS NOT NULL GROUP BY isbn HAVING COUNT(*) > 1 ORDER BY isbn`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dups []string
+	for rows.Next() {
+		var isbn, ids string
+		if err := rows.Scan(&isbn, &ids); err != nil {
+			return nil, err
+		}
+		dups = append(dups, isbn+": "+ids)
+	}
+	return dups, rows.Err()
+}
+
+// Add the category and tags columns if the table doesn't have them yet;
+// reports whether anything was added
+func addCategoryColumns(db *sql.DB) (bool, error) {
+	rows, err := db.Query("PRAGMA table_info(books)")
+	if err != nil {
+		return false, err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return false, err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	added := false
+	for _, col := range []string{"category TEXT DEFAULT ''", "tags TEXT DEFAULT '[]'"} {
+		name := strings.Fields(col)[0]
+		if existing[name] {
+			continue
+		}
+		if _, err := db.Exec("ALTER TABLE books ADD COLUMN " + col); err != nil {
+			return false, err
+		}
+		added = true
+	}
+	return added, nil
+}
+
+// Sample categories and tags for the seeded books, matched by ISBN
+func tagSampleBooks(db *sql.DB) {
+	samples := []struct {
+		isbn, category string
+		tags           []string
+	}{
+		{"9780134190440", "programming", []string{"go", "reference"}},
+		{"9780132350884", "software-engineering", []string{"best-practices", "refactoring"}},
+		{"9780201633610", "software-engineering", []string{"design", "oop"}},
+		{"9781491941195", "programming", []string{"go", "concurrency"}},
+		{"9781455586691", "productivity", []string{"focus"}},
+		{"9781492077213", "programming", []string{"go", "beginner"}},
+	}
+	for _, sample := range samples {
+		tagsJSON, _ := encodeTags(sample.tags)
+		db.Exec("UPDATE books SET category = ?, tags = ? WHERE isbn = ?", sample.category, tagsJSON, sample.isbn)
+	}
+}
+
 // ============================ main =============================
 func main() {
+	// TLS is optional. For local testing, create a self-signed certificate
+	// that is valid for localhost:
+	//
+	//	openssl req -x509 -newkey rsa:2048 -nodes -days 365 \
+	//		-keyout server.key -out server.crt -subj "/CN=localhost" \
+	//		-addext "subjectAltName=DNS:localhost,IP:127.0.0.1"
+	//
+	// then start with -tls-cert server.crt -tls-key server.key, and pass
+	// server.crt to the author service (-book-ca) and the client (-ca) so
+	// they trust it.
+	certFile := flag.String("tls-cert", "", "TLS certificate file; serves TLS together with -tls-key")
+	keyFile := flag.String("tls-key", "", "TLS private key file")
+	flag.Parse()
+
 	db, err := initDB()
 	if err != nil {
 		log.Fatal("DB init error:", err)
@@ -416,7 +770,14 @@ func main() {
 		log.Fatal(err)
 	}
 
-	s := grpc.NewServer()
+	// Plaintext unless a certificate/key pair is given
+	opts, err := servertls.Options(*certFile, *keyFile)
+	if err != nil {
+		log.Fatalf("Failed to load TLS credentials: %v", err)
+	}
+	opts = append(opts, grpc.UnaryInterceptor(interceptor.UnaryLogging))
+
+	s := grpc.NewServer(opts...)
 	pb.RegisterBookCatalogServer(s, &bookCatalogServer{db: db})
 
 	fmt.Println("📚 Book Catalog gRPC server running on :50051")
@@ -2,34 +2,95 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"time"
 	
 	authorpb "book-catalog-grpc/proto/proto"
 	bookpb "book-catalog-grpc/proto/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
-func main() {
-	// Connect to both services
-	bookConn, err := grpc.Dial("localhost:50051",
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+const (
+	bookAddr   = "localhost:50051"
+	authorAddr = "localhost:50052"
+)
+
+// Retry calls that fail with UNAVAILABLE (service restarting, connection
+// dropped) with exponential backoff before giving up
+const retryServiceConfig = `{
+	"methodConfig": [{
+		"name": [{"service": "bookservice.BookCatalog"}, {"service": "authorservice.AuthorCatalog"}],
+		"retryPolicy": {
+			"maxAttempts": 4,
+			"initialBackoff": "0.2s",
+			"maxBackoff": "2s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// How long to wait for the services to come up before giving up
+const readyTimeout = 10 * time.Second
+
+// Create a client connection that retries UNAVAILABLE and waits for the
+// connection instead of failing fast while the service is starting. With
+// caFile set the service must serve TLS with a certificate it trusts.
+func newClientConn(addr, caFile string) *grpc.ClientConn {
+	creds := insecure.NewCredentials()
+	if caFile != "" {
+		var err error
+		creds, err = credentials.NewClientTLSFromFile(caFile, "")
+		if err != nil {
+			log.Fatalf("Failed to load CA certificate: %v", err)
+		}
+	}
+
+	conn, err := grpc.NewClient(addr,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(retryServiceConfig),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)))
 	if err != nil {
 		log.Fatal(err)
 	}
+	return conn
+}
+
+// Readiness check: ping a cheap RPC on each service so an unreachable one
+// is reported up front instead of halfway through the demo
+func waitForServices(bookClient bookpb.BookCatalogClient, authorClient authorpb.AuthorCatalogClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+	defer cancel()
+
+	if _, err := bookClient.GetStats(ctx, &bookpb.GetStatsRequest{}); err != nil {
+		log.Fatalf("Book service at %s is unreachable after %v: %v", bookAddr, readyTimeout, err)
+	}
+	if _, err := authorClient.ListAuthors(ctx, &authorpb.ListAuthorsRequest{Page: 1, PageSize: 1}); err != nil {
+		log.Fatalf("Author service at %s is unreachable after %v: %v", authorAddr, readyTimeout, err)
+	}
+}
+
+func main() {
+	caFile := flag.String("ca", "", "certificate to trust when the services use TLS")
+	flag.Parse()
+
+	// Connect to both services
+	bookConn := newClientConn(bookAddr, *caFile)
 	defer bookConn.Close()
 	
-	authorConn, err := grpc.Dial("localhost:50052",
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		log.Fatal(err)
-	}
+	authorConn := newClientConn(authorAddr, *caFile)
 	defer authorConn.Close()
 	
 	bookClient := bookpb.NewBookCatalogClient(bookConn)
 	authorClient := authorpb.NewAuthorCatalogClient(authorConn)
+	waitForServices(bookClient, authorClient)
 	
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -60,11 +121,16 @@ func main() {
 		Price:         49.99,
 		Stock:         15,
 		PublishedYear: 2018,
+		Category:      "software-engineering",
+		Tags:          []string{"refactoring", "best-practices"},
 	})
-	if err != nil {
+	if status.Code(err) == codes.AlreadyExists {
+		fmt.Println("• Refactoring already exists (created by an earlier run)")
+	} else if err != nil {
 		log.Fatalf("Failed to create book 1: %v", err)
+	} else {
+		fmt.Printf("✓ Created book: %s\n", book1.Book.Title)
 	}
-	fmt.Printf("✓ Created book: %s\n", book1.Book.Title)
 	
 	book2, err := bookClient.CreateBook(ctx, &bookpb.CreateBookRequest{
 		Title:         "Patterns of Enterprise Application Architecture",
@@ -74,16 +140,23 @@ func main() {
 		Price:         54.99,
 		Stock:         8,
 		PublishedYear: 2002,
+		Category:      "software-engineering",
+		Tags:          []string{"architecture", "design"},
 	})
-	if err != nil {
+	if status.Code(err) == codes.AlreadyExists {
+		fmt.Println("• Patterns of Enterprise Application Architecture already exists (created by an earlier run)")
+		fmt.Println()
+	} else if err != nil {
 		log.Fatalf("Failed to create book 2: %v", err)
+	} else {
+		fmt.Printf("✓ Created book: %s\n\n", book2.Book.Title)
 	}
-	fmt.Printf("✓ Created book: %s\n\n", book2.Book.Title)
 	
 	// 3. Get author's books (cross-service call)
 	fmt.Println("3. Fetching author's books (cross-service call)...")
 	booksResp, err := authorClient.GetAuthorBooks(ctx, &authorpb.GetAuthorBooksRequest{
 		AuthorId: authorResp.Author.Id,
+		Strict:   true, // report a down book service instead of zero books
 	})
 	if err != nil {
 		log.Fatalf("Failed to get author books: %v", err)
@@ -95,5 +168,124 @@ func main() {
 		fmt.Printf("  %d. %s (%d)\n", i+1, book.Title, book.PublishedYear)
 	}
 	
+	// 4. Bulk upsert over a bidirectional stream
+	fmt.Println("\n4. Bulk upserting books...")
+	stream, err := bookClient.BulkUpsert(ctx)
+	if err != nil {
+		log.Fatalf("Failed to open bulk upsert stream: %v", err)
+	}
+
+	upserts := []*bookpb.UpsertBookRequest{
+		{Title: "Refactoring (2nd Edition)", Author: authorResp.Author.Name, AuthorId: authorResp.Author.Id,
+			Isbn: "978-0134757599", Price: 44.99, Stock: 20, PublishedYear: 2018},
+		{Title: "UML Distilled", Author: authorResp.Author.Name, AuthorId: authorResp.Author.Id,
+			Isbn: "978-0321193681", Price: 39.99, Stock: 5, PublishedYear: 2003},
+		{Title: "Missing ISBN", Author: authorResp.Author.Name},
+	}
+
+	// Send in the background while results are read as they arrive
+	go func() {
+		for _, req := range upserts {
+			if err := stream.Send(req); err != nil {
+				log.Printf("Send failed: %v", err)
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break // server committed the transaction
+		}
+		if err != nil {
+			log.Fatalf("Bulk upsert failed: %v", err)
+		}
+		if res.Status == "error" {
+			fmt.Printf("  ✗ #%d %s: %s\n", res.Index, res.Isbn, res.Error)
+			continue
+		}
+		fmt.Printf("  ✓ #%d %s %s (ID: %d)\n", res.Index, res.Isbn, res.Status, res.Id)
+	}
+
+	// 5. Invalid books must be rejected with the right status code
+	fmt.Println("\n5. Checking CreateBook validation...")
+	invalid := []struct {
+		name string
+		req  *bookpb.CreateBookRequest
+		want codes.Code
+	}{
+		{"duplicate ISBN", &bookpb.CreateBookRequest{Title: "Refactoring", Author: authorResp.Author.Name,
+			Isbn: "978-0134757599", Price: 49.99, Stock: 1, PublishedYear: 2018}, codes.AlreadyExists},
+		{"negative price", &bookpb.CreateBookRequest{Title: "Cheap Book", Author: authorResp.Author.Name,
+			Isbn: "978-0000000001", Price: -5, Stock: 1, PublishedYear: 2020}, codes.InvalidArgument},
+		{"future year", &bookpb.CreateBookRequest{Title: "Time Travel", Author: authorResp.Author.Name,
+			Isbn: "978-0000000002", Price: 10, Stock: 1, PublishedYear: 3000}, codes.InvalidArgument},
+		{"missing ISBN", &bookpb.CreateBookRequest{Title: "No ISBN", Author: authorResp.Author.Name,
+			Price: 10, Stock: 1, PublishedYear: 2020}, codes.InvalidArgument},
+	}
+	for _, tc := range invalid {
+		_, err := bookClient.CreateBook(ctx, tc.req)
+		if got := status.Code(err); got == tc.want {
+			fmt.Printf("  ✓ %s rejected with %s\n", tc.name, got)
+		} else {
+			fmt.Printf("  ✗ %s: expected %s, got %s (%v)\n", tc.name, tc.want, got, err)
+		}
+	}
+
+	// 6. Browse by category and filter by tag
+	fmt.Println("\n6. Browsing by category and tag...")
+	byCategory, err := bookClient.GetBooksByCategory(ctx, &bookpb.GetBooksByCategoryRequest{
+		Category: "software-engineering",
+	})
+	if err != nil {
+		log.Fatalf("Failed to get books by category: %v", err)
+	}
+	fmt.Printf("✓ %d books in software-engineering:\n", byCategory.Count)
+	for _, b := range byCategory.Books {
+		fmt.Printf("  - %s %v\n", b.Title, b.Tags)
+	}
+
+	tagged, err := bookClient.FilterBooks(ctx, &bookpb.FilterBooksRequest{Tag: "go"})
+	if err != nil {
+		log.Fatalf("Failed to filter books by tag: %v", err)
+	}
+	fmt.Printf("✓ %d books tagged \"go\":\n", tagged.Count)
+	for _, b := range tagged.Books {
+		fmt.Printf("  - %s (%s)\n", b.Title, b.Category)
+	}
+
+	// 7. Page through the author's books, cheapest first
+	fmt.Println("\n7. Paging the author's books by price...")
+	for page := int32(1); ; page++ {
+		resp, err := bookClient.GetBooksByAuthor(ctx, &bookpb.GetBooksByAuthorRequest{
+			AuthorId: authorResp.Author.Id,
+			Page:     page,
+			PageSize: 2,
+			SortBy:   "price",
+		})
+		if err != nil {
+			log.Fatalf("Failed to get books by author: %v", err)
+		}
+		if resp.Count == 0 {
+			break
+		}
+		fmt.Printf("  Page %d (%d of %d):\n", resp.Page, resp.Count, resp.Total)
+		for _, b := range resp.Books {
+			fmt.Printf("    - $%.2f %s\n", b.Price, b.Title)
+		}
+	}
+
+	_, err = bookClient.GetBooksByAuthor(ctx, &bookpb.GetBooksByAuthorRequest{
+		AuthorId: authorResp.Author.Id,
+		SortBy:   "rating",
+	})
+	if status.Code(err) == codes.InvalidArgument {
+		fmt.Println("  ✓ Unknown sort_by rejected with InvalidArgument")
+	} else {
+		fmt.Printf("  ✗ Unknown sort_by: expected InvalidArgument, got %v\n", err)
+	}
+
 	fmt.Println("\n✓ Microservice demo completed!")
 }
\ No newline at end of file
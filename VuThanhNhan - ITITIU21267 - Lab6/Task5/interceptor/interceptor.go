@@ -0,0 +1,36 @@
+// Package interceptor holds the gRPC server interceptors shared by the
+// Task5 book and author services.
+package interceptor
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryLogging logs every unary RPC once it finishes: method, caller
+// address, resulting status code and how long the handler took.
+func UnaryLogging(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	duration := time.Since(start)
+
+	addr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+
+	// status.Code maps nil to OK and non-status errors to Unknown
+	code := status.Code(err)
+	if err != nil {
+		log.Printf("%s from %s: %s (%v): %v", info.FullMethod, addr, code, duration, err)
+	} else {
+		log.Printf("%s from %s: %s (%v)", info.FullMethod, addr, code, duration)
+	}
+
+	return resp, err
+}
@@ -25,7 +25,7 @@ type CalculateRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	A             float32                `protobuf:"fixed32,1,opt,name=a,proto3" json:"a,omitempty"`
 	B             float32                `protobuf:"fixed32,2,opt,name=b,proto3" json:"b,omitempty"`
-	Operation     string                 `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"` // "add", "subtract", "multiply", "divide"
+	Operation     string                 `protobuf:"bytes,3,opt,name=operation,proto3" json:"operation,omitempty"` // "add", "subtract", "multiply", "divide", "power"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -223,6 +223,7 @@ func (x *SquareRootResponse) GetResult() float32 {
 
 type HistoryRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"` // most recent N entries; 0 returns everything kept
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -257,6 +258,13 @@ func (*HistoryRequest) Descriptor() ([]byte, []int) {
 	return file_calculator_proto_rawDescGZIP(), []int{4}
 }
 
+func (x *HistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
 type HistoryResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Calculations  []string               `protobuf:"bytes,1,rep,name=calculations,proto3" json:"calculations,omitempty"`
@@ -309,6 +317,183 @@ func (x *HistoryResponse) GetCount() int32 {
 	return 0
 }
 
+type ClearHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearHistoryRequest) Reset() {
+	*x = ClearHistoryRequest{}
+	mi := &file_calculator_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearHistoryRequest) ProtoMessage() {}
+
+func (x *ClearHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_calculator_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearHistoryRequest.ProtoReflect.Descriptor instead.
+func (*ClearHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_calculator_proto_rawDescGZIP(), []int{6}
+}
+
+type ClearHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cleared       int32                  `protobuf:"varint,1,opt,name=cleared,proto3" json:"cleared,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearHistoryResponse) Reset() {
+	*x = ClearHistoryResponse{}
+	mi := &file_calculator_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearHistoryResponse) ProtoMessage() {}
+
+func (x *ClearHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_calculator_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearHistoryResponse.ProtoReflect.Descriptor instead.
+func (*ClearHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_calculator_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ClearHistoryResponse) GetCleared() int32 {
+	if x != nil {
+		return x.Cleared
+	}
+	return 0
+}
+
+// One number per stream message for ComputeAverage
+type NumberRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Number        float32                `protobuf:"fixed32,1,opt,name=number,proto3" json:"number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NumberRequest) Reset() {
+	*x = NumberRequest{}
+	mi := &file_calculator_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NumberRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NumberRequest) ProtoMessage() {}
+
+func (x *NumberRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_calculator_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NumberRequest.ProtoReflect.Descriptor instead.
+func (*NumberRequest) Descriptor() ([]byte, []int) {
+	return file_calculator_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *NumberRequest) GetNumber() float32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+type AverageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Average       float32                `protobuf:"fixed32,1,opt,name=average,proto3" json:"average,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AverageResponse) Reset() {
+	*x = AverageResponse{}
+	mi := &file_calculator_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AverageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AverageResponse) ProtoMessage() {}
+
+func (x *AverageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_calculator_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AverageResponse.ProtoReflect.Descriptor instead.
+func (*AverageResponse) Descriptor() ([]byte, []int) {
+	return file_calculator_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AverageResponse) GetAverage() float32 {
+	if x != nil {
+		return x.Average
+	}
+	return 0
+}
+
+func (x *AverageResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
 var File_calculator_proto protoreflect.FileDescriptor
 
 const file_calculator_proto_rawDesc = "" +
@@ -325,18 +510,29 @@ const file_calculator_proto_rawDesc = "" +
 	"\x11SquareRootRequest\x12\x16\n" +
 	"\x06number\x18\x01 \x01(\x02R\x06number\",\n" +
 	"\x12SquareRootResponse\x12\x16\n" +
-	"\x06result\x18\x01 \x01(\x02R\x06result\"\x10\n" +
-	"\x0eHistoryRequest\"K\n" +
+	"\x06result\x18\x01 \x01(\x02R\x06result\"&\n" +
+	"\x0eHistoryRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\"K\n" +
 	"\x0fHistoryResponse\x12\"\n" +
 	"\fcalculations\x18\x01 \x03(\tR\fcalculations\x12\x14\n" +
-	"\x05count\x18\x02 \x01(\x05R\x05count2\xea\x01\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"\x15\n" +
+	"\x13ClearHistoryRequest\"0\n" +
+	"\x14ClearHistoryResponse\x12\x18\n" +
+	"\acleared\x18\x01 \x01(\x05R\acleared\"'\n" +
+	"\rNumberRequest\x12\x16\n" +
+	"\x06number\x18\x01 \x01(\x02R\x06number\"A\n" +
+	"\x0fAverageResponse\x12\x18\n" +
+	"\aaverage\x18\x01 \x01(\x02R\aaverage\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count2\x89\x03\n" +
 	"\n" +
 	"Calculator\x12H\n" +
 	"\tCalculate\x12\x1c.calculator.CalculateRequest\x1a\x1d.calculator.CalculateResponse\x12K\n" +
 	"\n" +
 	"SquareRoot\x12\x1d.calculator.SquareRootRequest\x1a\x1e.calculator.SquareRootResponse\x12E\n" +
 	"\n" +
-	"GetHistory\x12\x1a.calculator.HistoryRequest\x1a\x1b.calculator.HistoryResponseB\tZ\a./protob\x06proto3"
+	"GetHistory\x12\x1a.calculator.HistoryRequest\x1a\x1b.calculator.HistoryResponse\x12Q\n" +
+	"\fClearHistory\x12\x1f.calculator.ClearHistoryRequest\x1a .calculator.ClearHistoryResponse\x12J\n" +
+	"\x0eComputeAverage\x12\x19.calculator.NumberRequest\x1a\x1b.calculator.AverageResponse(\x01B\tZ\a./protob\x06proto3"
 
 var (
 	file_calculator_proto_rawDescOnce sync.Once
@@ -350,24 +546,32 @@ func file_calculator_proto_rawDescGZIP() []byte {
 	return file_calculator_proto_rawDescData
 }
 
-var file_calculator_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_calculator_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_calculator_proto_goTypes = []any{
-	(*CalculateRequest)(nil),   // 0: calculator.CalculateRequest
-	(*CalculateResponse)(nil),  // 1: calculator.CalculateResponse
-	(*SquareRootRequest)(nil),  // 2: calculator.SquareRootRequest
-	(*SquareRootResponse)(nil), // 3: calculator.SquareRootResponse
-	(*HistoryRequest)(nil),     // 4: calculator.HistoryRequest
-	(*HistoryResponse)(nil),    // 5: calculator.HistoryResponse
+	(*CalculateRequest)(nil),     // 0: calculator.CalculateRequest
+	(*CalculateResponse)(nil),    // 1: calculator.CalculateResponse
+	(*SquareRootRequest)(nil),    // 2: calculator.SquareRootRequest
+	(*SquareRootResponse)(nil),   // 3: calculator.SquareRootResponse
+	(*HistoryRequest)(nil),       // 4: calculator.HistoryRequest
+	(*HistoryResponse)(nil),      // 5: calculator.HistoryResponse
+	(*ClearHistoryRequest)(nil),  // 6: calculator.ClearHistoryRequest
+	(*ClearHistoryResponse)(nil), // 7: calculator.ClearHistoryResponse
+	(*NumberRequest)(nil),        // 8: calculator.NumberRequest
+	(*AverageResponse)(nil),      // 9: calculator.AverageResponse
 }
 var file_calculator_proto_depIdxs = []int32{
 	0, // 0: calculator.Calculator.Calculate:input_type -> calculator.CalculateRequest
 	2, // 1: calculator.Calculator.SquareRoot:input_type -> calculator.SquareRootRequest
 	4, // 2: calculator.Calculator.GetHistory:input_type -> calculator.HistoryRequest
-	1, // 3: calculator.Calculator.Calculate:output_type -> calculator.CalculateResponse
-	3, // 4: calculator.Calculator.SquareRoot:output_type -> calculator.SquareRootResponse
-	5, // 5: calculator.Calculator.GetHistory:output_type -> calculator.HistoryResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
+	6, // 3: calculator.Calculator.ClearHistory:input_type -> calculator.ClearHistoryRequest
+	8, // 4: calculator.Calculator.ComputeAverage:input_type -> calculator.NumberRequest
+	1, // 5: calculator.Calculator.Calculate:output_type -> calculator.CalculateResponse
+	3, // 6: calculator.Calculator.SquareRoot:output_type -> calculator.SquareRootResponse
+	5, // 7: calculator.Calculator.GetHistory:output_type -> calculator.HistoryResponse
+	7, // 8: calculator.Calculator.ClearHistory:output_type -> calculator.ClearHistoryResponse
+	9, // 9: calculator.Calculator.ComputeAverage:output_type -> calculator.AverageResponse
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -384,7 +588,7 @@ func file_calculator_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_calculator_proto_rawDesc), len(file_calculator_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Calculator_Calculate_FullMethodName  = "/calculator.Calculator/Calculate"
-	Calculator_SquareRoot_FullMethodName = "/calculator.Calculator/SquareRoot"
-	Calculator_GetHistory_FullMethodName = "/calculator.Calculator/GetHistory"
+	Calculator_Calculate_FullMethodName      = "/calculator.Calculator/Calculate"
+	Calculator_SquareRoot_FullMethodName     = "/calculator.Calculator/SquareRoot"
+	Calculator_GetHistory_FullMethodName     = "/calculator.Calculator/GetHistory"
+	Calculator_ClearHistory_FullMethodName   = "/calculator.Calculator/ClearHistory"
+	Calculator_ComputeAverage_FullMethodName = "/calculator.Calculator/ComputeAverage"
 )
 
 // CalculatorClient is the client API for Calculator service.
@@ -31,6 +33,8 @@ type CalculatorClient interface {
 	Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error)
 	SquareRoot(ctx context.Context, in *SquareRootRequest, opts ...grpc.CallOption) (*SquareRootResponse, error)
 	GetHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+	ClearHistory(ctx context.Context, in *ClearHistoryRequest, opts ...grpc.CallOption) (*ClearHistoryResponse, error)
+	ComputeAverage(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[NumberRequest, AverageResponse], error)
 }
 
 type calculatorClient struct {
@@ -71,6 +75,29 @@ func (c *calculatorClient) GetHistory(ctx context.Context, in *HistoryRequest, o
 	return out, nil
 }
 
+func (c *calculatorClient) ClearHistory(ctx context.Context, in *ClearHistoryRequest, opts ...grpc.CallOption) (*ClearHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClearHistoryResponse)
+	err := c.cc.Invoke(ctx, Calculator_ClearHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorClient) ComputeAverage(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[NumberRequest, AverageResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Calculator_ServiceDesc.Streams[0], Calculator_ComputeAverage_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[NumberRequest, AverageResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Calculator_ComputeAverageClient = grpc.ClientStreamingClient[NumberRequest, AverageResponse]
+
 // CalculatorServer is the server API for Calculator service.
 // All implementations must embed UnimplementedCalculatorServer
 // for forward compatibility.
@@ -78,6 +105,8 @@ type CalculatorServer interface {
 	Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error)
 	SquareRoot(context.Context, *SquareRootRequest) (*SquareRootResponse, error)
 	GetHistory(context.Context, *HistoryRequest) (*HistoryResponse, error)
+	ClearHistory(context.Context, *ClearHistoryRequest) (*ClearHistoryResponse, error)
+	ComputeAverage(grpc.ClientStreamingServer[NumberRequest, AverageResponse]) error
 	mustEmbedUnimplementedCalculatorServer()
 }
 
@@ -97,6 +126,12 @@ func (UnimplementedCalculatorServer) SquareRoot(context.Context, *SquareRootRequ
 func (UnimplementedCalculatorServer) GetHistory(context.Context, *HistoryRequest) (*HistoryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetHistory not implemented")
 }
+func (UnimplementedCalculatorServer) ClearHistory(context.Context, *ClearHistoryRequest) (*ClearHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClearHistory not implemented")
+}
+func (UnimplementedCalculatorServer) ComputeAverage(grpc.ClientStreamingServer[NumberRequest, AverageResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ComputeAverage not implemented")
+}
 func (UnimplementedCalculatorServer) mustEmbedUnimplementedCalculatorServer() {}
 func (UnimplementedCalculatorServer) testEmbeddedByValue()                    {}
 
@@ -172,6 +207,31 @@ func _Calculator_GetHistory_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Calculator_ClearHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClearHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).ClearHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Calculator_ClearHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).ClearHistory(ctx, req.(*ClearHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calculator_ComputeAverage_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CalculatorServer).ComputeAverage(&grpc.GenericServerStream[NumberRequest, AverageResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Calculator_ComputeAverageServer = grpc.ClientStreamingServer[NumberRequest, AverageResponse]
+
 // Calculator_ServiceDesc is the grpc.ServiceDesc for Calculator service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,7 +251,17 @@ var Calculator_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetHistory",
 			Handler:    _Calculator_GetHistory_Handler,
 		},
+		{
+			MethodName: "ClearHistory",
+			Handler:    _Calculator_ClearHistory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ComputeAverage",
+			Handler:       _Calculator_ComputeAverage_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "calculator.proto",
 }
@@ -404,6 +404,7 @@ func (x *ListAuthorsResponse) GetTotal() int32 {
 type GetAuthorBooksRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	AuthorId      int32                  `protobuf:"varint,1,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Strict        bool                   `protobuf:"varint,2,opt,name=strict,proto3" json:"strict,omitempty"` // fail with UNAVAILABLE instead of returning no books when the book service is down
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -445,6 +446,13 @@ func (x *GetAuthorBooksRequest) GetAuthorId() int32 {
 	return 0
 }
 
+func (x *GetAuthorBooksRequest) GetStrict() bool {
+	if x != nil {
+		return x.Strict
+	}
+	return false
+}
+
 // Reference to Book from book_service
 type BookSummary struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -603,9 +611,10 @@ const file_author_service_proto_rawDesc = "" +
 	"\tpage_size\x18\x02 \x01(\x05R\bpageSize\"\\\n" +
 	"\x13ListAuthorsResponse\x12/\n" +
 	"\aauthors\x18\x01 \x03(\v2\x15.authorservice.AuthorR\aauthors\x12\x14\n" +
-	"\x05total\x18\x02 \x01(\x05R\x05total\"4\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"L\n" +
 	"\x15GetAuthorBooksRequest\x12\x1b\n" +
-	"\tauthor_id\x18\x01 \x01(\x05R\bauthorId\"p\n" +
+	"\tauthor_id\x18\x01 \x01(\x05R\bauthorId\x12\x16\n" +
+	"\x06strict\x18\x02 \x01(\bR\x06strict\"p\n" +
 	"\vBookSummary\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12\x14\n" +
@@ -32,6 +32,8 @@ type Book struct {
 	Stock         int32                  `protobuf:"varint,6,opt,name=stock,proto3" json:"stock,omitempty"`
 	PublishedYear int32                  `protobuf:"varint,7,opt,name=published_year,json=publishedYear,proto3" json:"published_year,omitempty"`
 	AuthorId      int32                  `protobuf:"varint,8,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Category      string                 `protobuf:"bytes,9,opt,name=category,proto3" json:"category,omitempty"`
+	Tags          []string               `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -122,6 +124,20 @@ func (x *Book) GetAuthorId() int32 {
 	return 0
 }
 
+func (x *Book) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *Book) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
 // ======================= GetBook ==============================
 type GetBookRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -221,6 +237,8 @@ type CreateBookRequest struct {
 	Stock         int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"`
 	PublishedYear int32                  `protobuf:"varint,6,opt,name=published_year,json=publishedYear,proto3" json:"published_year,omitempty"`
 	AuthorId      int32                  `protobuf:"varint,7,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Category      string                 `protobuf:"bytes,8,opt,name=category,proto3" json:"category,omitempty"`
+	Tags          []string               `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -304,6 +322,20 @@ func (x *CreateBookRequest) GetAuthorId() int32 {
 	return 0
 }
 
+func (x *CreateBookRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CreateBookRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
 type CreateBookResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Book          *Book                  `protobuf:"bytes,1,opt,name=book,proto3" json:"book,omitempty"`
@@ -359,6 +391,8 @@ type UpdateBookRequest struct {
 	Stock         int32                  `protobuf:"varint,6,opt,name=stock,proto3" json:"stock,omitempty"`
 	PublishedYear int32                  `protobuf:"varint,7,opt,name=published_year,json=publishedYear,proto3" json:"published_year,omitempty"`
 	AuthorId      int32                  `protobuf:"varint,8,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Category      string                 `protobuf:"bytes,9,opt,name=category,proto3" json:"category,omitempty"`
+	Tags          []string               `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -449,6 +483,20 @@ func (x *UpdateBookRequest) GetAuthorId() int32 {
 	return 0
 }
 
+func (x *UpdateBookRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *UpdateBookRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
 type UpdateBookResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Book          *Book                  `protobuf:"bytes,1,opt,name=book,proto3" json:"book,omitempty"`
@@ -711,6 +759,44 @@ func (x *ListBooksResponse) GetPageSize() int32 {
 	return 0
 }
 
+// ======================= StreamBooks ===========================
+// Streams the whole catalog one Book at a time instead of in pages
+type StreamBooksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamBooksRequest) Reset() {
+	*x = StreamBooksRequest{}
+	mi := &file_book_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamBooksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamBooksRequest) ProtoMessage() {}
+
+func (x *StreamBooksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_book_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamBooksRequest.ProtoReflect.Descriptor instead.
+func (*StreamBooksRequest) Descriptor() ([]byte, []int) {
+	return file_book_service_proto_rawDescGZIP(), []int{11}
+}
+
 // ======================= Task 4: SearchBooks ===================
 type SearchBooksRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -722,7 +808,7 @@ type SearchBooksRequest struct {
 
 func (x *SearchBooksRequest) Reset() {
 	*x = SearchBooksRequest{}
-	mi := &file_book_service_proto_msgTypes[11]
+	mi := &file_book_service_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -734,7 +820,7 @@ func (x *SearchBooksRequest) String() string {
 func (*SearchBooksRequest) ProtoMessage() {}
 
 func (x *SearchBooksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_book_service_proto_msgTypes[11]
+	mi := &file_book_service_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -747,7 +833,7 @@ func (x *SearchBooksRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchBooksRequest.ProtoReflect.Descriptor instead.
 func (*SearchBooksRequest) Descriptor() ([]byte, []int) {
-	return file_book_service_proto_rawDescGZIP(), []int{11}
+	return file_book_service_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *SearchBooksRequest) GetQuery() string {
@@ -775,7 +861,7 @@ type SearchBooksResponse struct {
 
 func (x *SearchBooksResponse) Reset() {
 	*x = SearchBooksResponse{}
-	mi := &file_book_service_proto_msgTypes[12]
+	mi := &file_book_service_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -787,7 +873,7 @@ func (x *SearchBooksResponse) String() string {
 func (*SearchBooksResponse) ProtoMessage() {}
 
 func (x *SearchBooksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_book_service_proto_msgTypes[12]
+	mi := &file_book_service_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -800,7 +886,7 @@ func (x *SearchBooksResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SearchBooksResponse.ProtoReflect.Descriptor instead.
 func (*SearchBooksResponse) Descriptor() ([]byte, []int) {
-	return file_book_service_proto_rawDescGZIP(), []int{12}
+	return file_book_service_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *SearchBooksResponse) GetBooks() []*Book {
@@ -831,13 +917,14 @@ type FilterBooksRequest struct {
 	MaxPrice      float32                `protobuf:"fixed32,2,opt,name=max_price,json=maxPrice,proto3" json:"max_price,omitempty"`
 	MinYear       int32                  `protobuf:"varint,3,opt,name=min_year,json=minYear,proto3" json:"min_year,omitempty"`
 	MaxYear       int32                  `protobuf:"varint,4,opt,name=max_year,json=maxYear,proto3" json:"max_year,omitempty"`
+	Tag           string                 `protobuf:"bytes,5,opt,name=tag,proto3" json:"tag,omitempty"` // only books carrying this tag
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *FilterBooksRequest) Reset() {
 	*x = FilterBooksRequest{}
-	mi := &file_book_service_proto_msgTypes[13]
+	mi := &file_book_service_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -849,7 +936,7 @@ func (x *FilterBooksRequest) String() string {
 func (*FilterBooksRequest) ProtoMessage() {}
 
 func (x *FilterBooksRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_book_service_proto_msgTypes[13]
+	mi := &file_book_service_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -862,7 +949,7 @@ func (x *FilterBooksRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FilterBooksRequest.ProtoReflect.Descriptor instead.
 func (*FilterBooksRequest) Descriptor() ([]byte, []int) {
-	return file_book_service_proto_rawDescGZIP(), []int{13}
+	return file_book_service_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *FilterBooksRequest) GetMinPrice() float32 {
@@ -893,6 +980,13 @@ func (x *FilterBooksRequest) GetMaxYear() int32 {
 	return 0
 }
 
+func (x *FilterBooksRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
 type FilterBooksResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Books         []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
@@ -903,7 +997,7 @@ type FilterBooksResponse struct {
 
 func (x *FilterBooksResponse) Reset() {
 	*x = FilterBooksResponse{}
-	mi := &file_book_service_proto_msgTypes[14]
+	mi := &file_book_service_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -915,7 +1009,7 @@ func (x *FilterBooksResponse) String() string {
 func (*FilterBooksResponse) ProtoMessage() {}
 
 func (x *FilterBooksResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_book_service_proto_msgTypes[14]
+	mi := &file_book_service_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -928,7 +1022,7 @@ func (x *FilterBooksResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FilterBooksResponse.ProtoReflect.Descriptor instead.
 func (*FilterBooksResponse) Descriptor() ([]byte, []int) {
-	return file_book_service_proto_rawDescGZIP(), []int{14}
+	return file_book_service_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *FilterBooksResponse) GetBooks() []*Book {
@@ -954,7 +1048,7 @@ type GetStatsRequest struct {
 
 func (x *GetStatsRequest) Reset() {
 	*x = GetStatsRequest{}
-	mi := &file_book_service_proto_msgTypes[15]
+	mi := &file_book_service_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -966,7 +1060,7 @@ func (x *GetStatsRequest) String() string {
 func (*GetStatsRequest) ProtoMessage() {}
 
 func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_book_service_proto_msgTypes[15]
+	mi := &file_book_service_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -979,7 +1073,7 @@ func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
 func (*GetStatsRequest) Descriptor() ([]byte, []int) {
-	return file_book_service_proto_rawDescGZIP(), []int{15}
+	return file_book_service_proto_rawDescGZIP(), []int{16}
 }
 
 type GetStatsResponse struct {
@@ -995,7 +1089,7 @@ type GetStatsResponse struct {
 
 func (x *GetStatsResponse) Reset() {
 	*x = GetStatsResponse{}
-	mi := &file_book_service_proto_msgTypes[16]
+	mi := &file_book_service_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1007,7 +1101,7 @@ func (x *GetStatsResponse) String() string {
 func (*GetStatsResponse) ProtoMessage() {}
 
 func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_book_service_proto_msgTypes[16]
+	mi := &file_book_service_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1020,7 +1114,7 @@ func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
 func (*GetStatsResponse) Descriptor() ([]byte, []int) {
-	return file_book_service_proto_rawDescGZIP(), []int{16}
+	return file_book_service_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *GetStatsResponse) GetTotalBooks() int32 {
@@ -1062,13 +1156,16 @@ func (x *GetStatsResponse) GetLatestYear() int32 {
 type GetBooksByAuthorRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	AuthorId      int32                  `protobuf:"varint,1,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	Page          int32                  `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`                         // 1-based, defaults to 1
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // 0 returns every book
+	SortBy        string                 `protobuf:"bytes,4,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`        // "price", "year", "title"; defaults to id
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetBooksByAuthorRequest) Reset() {
 	*x = GetBooksByAuthorRequest{}
-	mi := &file_book_service_proto_msgTypes[17]
+	mi := &file_book_service_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1080,7 +1177,7 @@ func (x *GetBooksByAuthorRequest) String() string {
 func (*GetBooksByAuthorRequest) ProtoMessage() {}
 
 func (x *GetBooksByAuthorRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_book_service_proto_msgTypes[17]
+	mi := &file_book_service_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1093,7 +1190,7 @@ func (x *GetBooksByAuthorRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBooksByAuthorRequest.ProtoReflect.Descriptor instead.
 func (*GetBooksByAuthorRequest) Descriptor() ([]byte, []int) {
-	return file_book_service_proto_rawDescGZIP(), []int{17}
+	return file_book_service_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *GetBooksByAuthorRequest) GetAuthorId() int32 {
@@ -1103,17 +1200,41 @@ func (x *GetBooksByAuthorRequest) GetAuthorId() int32 {
 	return 0
 }
 
+func (x *GetBooksByAuthorRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *GetBooksByAuthorRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *GetBooksByAuthorRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
 type GetBooksByAuthorResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Books         []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
-	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"` // books in this page
+	Total         int32                  `protobuf:"varint,3,opt,name=total,proto3" json:"total,omitempty"` // books by the author across all pages
+	Page          int32                  `protobuf:"varint,4,opt,name=page,proto3" json:"page,omitempty"`
+	PageSize      int32                  `protobuf:"varint,5,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetBooksByAuthorResponse) Reset() {
 	*x = GetBooksByAuthorResponse{}
-	mi := &file_book_service_proto_msgTypes[18]
+	mi := &file_book_service_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1125,7 +1246,7 @@ func (x *GetBooksByAuthorResponse) String() string {
 func (*GetBooksByAuthorResponse) ProtoMessage() {}
 
 func (x *GetBooksByAuthorResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_book_service_proto_msgTypes[18]
+	mi := &file_book_service_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1138,7 +1259,7 @@ func (x *GetBooksByAuthorResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetBooksByAuthorResponse.ProtoReflect.Descriptor instead.
 func (*GetBooksByAuthorResponse) Descriptor() ([]byte, []int) {
-	return file_book_service_proto_rawDescGZIP(), []int{18}
+	return file_book_service_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *GetBooksByAuthorResponse) GetBooks() []*Book {
@@ -1155,11 +1276,299 @@ func (x *GetBooksByAuthorResponse) GetCount() int32 {
 	return 0
 }
 
+func (x *GetBooksByAuthorResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *GetBooksByAuthorResponse) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *GetBooksByAuthorResponse) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// ======================= GetBooksByCategory ====================
+type GetBooksByCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Category      string                 `protobuf:"bytes,1,opt,name=category,proto3" json:"category,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBooksByCategoryRequest) Reset() {
+	*x = GetBooksByCategoryRequest{}
+	mi := &file_book_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBooksByCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBooksByCategoryRequest) ProtoMessage() {}
+
+func (x *GetBooksByCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_book_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBooksByCategoryRequest.ProtoReflect.Descriptor instead.
+func (*GetBooksByCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_book_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetBooksByCategoryRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+type GetBooksByCategoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Books         []*Book                `protobuf:"bytes,1,rep,name=books,proto3" json:"books,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBooksByCategoryResponse) Reset() {
+	*x = GetBooksByCategoryResponse{}
+	mi := &file_book_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBooksByCategoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBooksByCategoryResponse) ProtoMessage() {}
+
+func (x *GetBooksByCategoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_book_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBooksByCategoryResponse.ProtoReflect.Descriptor instead.
+func (*GetBooksByCategoryResponse) Descriptor() ([]byte, []int) {
+	return file_book_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetBooksByCategoryResponse) GetBooks() []*Book {
+	if x != nil {
+		return x.Books
+	}
+	return nil
+}
+
+func (x *GetBooksByCategoryResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// ======================= BulkUpsert ============================
+// One book per stream message; matched on isbn (insert or update)
+type UpsertBookRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Title         string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Author        string                 `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Isbn          string                 `protobuf:"bytes,3,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	Price         float32                `protobuf:"fixed32,4,opt,name=price,proto3" json:"price,omitempty"`
+	Stock         int32                  `protobuf:"varint,5,opt,name=stock,proto3" json:"stock,omitempty"`
+	PublishedYear int32                  `protobuf:"varint,6,opt,name=published_year,json=publishedYear,proto3" json:"published_year,omitempty"`
+	AuthorId      int32                  `protobuf:"varint,7,opt,name=author_id,json=authorId,proto3" json:"author_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpsertBookRequest) Reset() {
+	*x = UpsertBookRequest{}
+	mi := &file_book_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpsertBookRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpsertBookRequest) ProtoMessage() {}
+
+func (x *UpsertBookRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_book_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpsertBookRequest.ProtoReflect.Descriptor instead.
+func (*UpsertBookRequest) Descriptor() ([]byte, []int) {
+	return file_book_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UpsertBookRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *UpsertBookRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *UpsertBookRequest) GetIsbn() string {
+	if x != nil {
+		return x.Isbn
+	}
+	return ""
+}
+
+func (x *UpsertBookRequest) GetPrice() float32 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *UpsertBookRequest) GetStock() int32 {
+	if x != nil {
+		return x.Stock
+	}
+	return 0
+}
+
+func (x *UpsertBookRequest) GetPublishedYear() int32 {
+	if x != nil {
+		return x.PublishedYear
+	}
+	return 0
+}
+
+func (x *UpsertBookRequest) GetAuthorId() int32 {
+	if x != nil {
+		return x.AuthorId
+	}
+	return 0
+}
+
+type UpsertBookResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"` // position of the request in the stream, from 0
+	Isbn          string                 `protobuf:"bytes,2,opt,name=isbn,proto3" json:"isbn,omitempty"`
+	Id            int32                  `protobuf:"varint,3,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"` // "created", "updated", "error"
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpsertBookResult) Reset() {
+	*x = UpsertBookResult{}
+	mi := &file_book_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpsertBookResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpsertBookResult) ProtoMessage() {}
+
+func (x *UpsertBookResult) ProtoReflect() protoreflect.Message {
+	mi := &file_book_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpsertBookResult.ProtoReflect.Descriptor instead.
+func (*UpsertBookResult) Descriptor() ([]byte, []int) {
+	return file_book_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *UpsertBookResult) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *UpsertBookResult) GetIsbn() string {
+	if x != nil {
+		return x.Isbn
+	}
+	return ""
+}
+
+func (x *UpsertBookResult) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpsertBookResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *UpsertBookResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
 var File_book_service_proto protoreflect.FileDescriptor
 
 const file_book_service_proto_rawDesc = "" +
 	"\n" +
-	"\x12book_service.proto\x12\vbookservice\"\xc8\x01\n" +
+	"\x12book_service.proto\x12\vbookservice\"\xf8\x01\n" +
 	"\x04Book\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
@@ -1168,11 +1577,14 @@ const file_book_service_proto_rawDesc = "" +
 	"\x05price\x18\x05 \x01(\x02R\x05price\x12\x14\n" +
 	"\x05stock\x18\x06 \x01(\x05R\x05stock\x12%\n" +
 	"\x0epublished_year\x18\a \x01(\x05R\rpublishedYear\x12\x1b\n" +
-	"\tauthor_id\x18\b \x01(\x05R\bauthorId\" \n" +
+	"\tauthor_id\x18\b \x01(\x05R\bauthorId\x12\x1a\n" +
+	"\bcategory\x18\t \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\" \n" +
 	"\x0eGetBookRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\"8\n" +
 	"\x0fGetBookResponse\x12%\n" +
-	"\x04book\x18\x01 \x01(\v2\x11.bookservice.BookR\x04book\"\xc5\x01\n" +
+	"\x04book\x18\x01 \x01(\v2\x11.bookservice.BookR\x04book\"\xf5\x01\n" +
 	"\x11CreateBookRequest\x12\x14\n" +
 	"\x05title\x18\x01 \x01(\tR\x05title\x12\x16\n" +
 	"\x06author\x18\x02 \x01(\tR\x06author\x12\x12\n" +
@@ -1180,9 +1592,11 @@ const file_book_service_proto_rawDesc = "" +
 	"\x05price\x18\x04 \x01(\x02R\x05price\x12\x14\n" +
 	"\x05stock\x18\x05 \x01(\x05R\x05stock\x12%\n" +
 	"\x0epublished_year\x18\x06 \x01(\x05R\rpublishedYear\x12\x1b\n" +
-	"\tauthor_id\x18\a \x01(\x05R\bauthorId\";\n" +
+	"\tauthor_id\x18\a \x01(\x05R\bauthorId\x12\x1a\n" +
+	"\bcategory\x18\b \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\";\n" +
 	"\x12CreateBookResponse\x12%\n" +
-	"\x04book\x18\x01 \x01(\v2\x11.bookservice.BookR\x04book\"\xd5\x01\n" +
+	"\x04book\x18\x01 \x01(\v2\x11.bookservice.BookR\x04book\"\x85\x02\n" +
 	"\x11UpdateBookRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x14\n" +
 	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
@@ -1191,7 +1605,10 @@ const file_book_service_proto_rawDesc = "" +
 	"\x05price\x18\x05 \x01(\x02R\x05price\x12\x14\n" +
 	"\x05stock\x18\x06 \x01(\x05R\x05stock\x12%\n" +
 	"\x0epublished_year\x18\a \x01(\x05R\rpublishedYear\x12\x1b\n" +
-	"\tauthor_id\x18\b \x01(\x05R\bauthorId\";\n" +
+	"\tauthor_id\x18\b \x01(\x05R\bauthorId\x12\x1a\n" +
+	"\bcategory\x18\t \x01(\tR\bcategory\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\";\n" +
 	"\x12UpdateBookResponse\x12%\n" +
 	"\x04book\x18\x01 \x01(\v2\x11.bookservice.BookR\x04book\"#\n" +
 	"\x11DeleteBookRequest\x12\x0e\n" +
@@ -1206,19 +1623,21 @@ const file_book_service_proto_rawDesc = "" +
 	"\x05books\x18\x01 \x03(\v2\x11.bookservice.BookR\x05books\x12\x14\n" +
 	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x12\n" +
 	"\x04page\x18\x03 \x01(\x05R\x04page\x12\x1b\n" +
-	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\"@\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\"\x14\n" +
+	"\x12StreamBooksRequest\"@\n" +
 	"\x12SearchBooksRequest\x12\x14\n" +
 	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
 	"\x05field\x18\x02 \x01(\tR\x05field\"j\n" +
 	"\x13SearchBooksResponse\x12'\n" +
 	"\x05books\x18\x01 \x03(\v2\x11.bookservice.BookR\x05books\x12\x14\n" +
 	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x14\n" +
-	"\x05query\x18\x03 \x01(\tR\x05query\"\x84\x01\n" +
+	"\x05query\x18\x03 \x01(\tR\x05query\"\x96\x01\n" +
 	"\x12FilterBooksRequest\x12\x1b\n" +
 	"\tmin_price\x18\x01 \x01(\x02R\bminPrice\x12\x1b\n" +
 	"\tmax_price\x18\x02 \x01(\x02R\bmaxPrice\x12\x19\n" +
 	"\bmin_year\x18\x03 \x01(\x05R\aminYear\x12\x19\n" +
-	"\bmax_year\x18\x04 \x01(\x05R\amaxYear\"T\n" +
+	"\bmax_year\x18\x04 \x01(\x05R\amaxYear\x12\x10\n" +
+	"\x03tag\x18\x05 \x01(\tR\x03tag\"T\n" +
 	"\x13FilterBooksResponse\x12'\n" +
 	"\x05books\x18\x01 \x03(\v2\x11.bookservice.BookR\x05books\x12\x14\n" +
 	"\x05count\x18\x02 \x01(\x05R\x05count\"\x11\n" +
@@ -1231,12 +1650,37 @@ const file_book_service_proto_rawDesc = "" +
 	"totalStock\x12#\n" +
 	"\rearliest_year\x18\x04 \x01(\x05R\fearliestYear\x12\x1f\n" +
 	"\vlatest_year\x18\x05 \x01(\x05R\n" +
-	"latestYear\"6\n" +
+	"latestYear\"\x80\x01\n" +
 	"\x17GetBooksByAuthorRequest\x12\x1b\n" +
-	"\tauthor_id\x18\x01 \x01(\x05R\bauthorId\"Y\n" +
+	"\tauthor_id\x18\x01 \x01(\x05R\bauthorId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x17\n" +
+	"\asort_by\x18\x04 \x01(\tR\x06sortBy\"\xa0\x01\n" +
 	"\x18GetBooksByAuthorResponse\x12'\n" +
 	"\x05books\x18\x01 \x03(\v2\x11.bookservice.BookR\x05books\x12\x14\n" +
-	"\x05count\x18\x02 \x01(\x05R\x05count2\xda\x05\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12\x14\n" +
+	"\x05total\x18\x03 \x01(\x05R\x05total\x12\x12\n" +
+	"\x04page\x18\x04 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x05 \x01(\x05R\bpageSize\"7\n" +
+	"\x19GetBooksByCategoryRequest\x12\x1a\n" +
+	"\bcategory\x18\x01 \x01(\tR\bcategory\"[\n" +
+	"\x1aGetBooksByCategoryResponse\x12'\n" +
+	"\x05books\x18\x01 \x03(\v2\x11.bookservice.BookR\x05books\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"\xc5\x01\n" +
+	"\x11UpsertBookRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x16\n" +
+	"\x06author\x18\x02 \x01(\tR\x06author\x12\x12\n" +
+	"\x04isbn\x18\x03 \x01(\tR\x04isbn\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x02R\x05price\x12\x14\n" +
+	"\x05stock\x18\x05 \x01(\x05R\x05stock\x12%\n" +
+	"\x0epublished_year\x18\x06 \x01(\x05R\rpublishedYear\x12\x1b\n" +
+	"\tauthor_id\x18\a \x01(\x05R\bauthorId\"z\n" +
+	"\x10UpsertBookResult\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04isbn\x18\x02 \x01(\tR\x04isbn\x12\x0e\n" +
+	"\x02id\x18\x03 \x01(\x05R\x02id\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error2\xd7\a\n" +
 	"\vBookCatalog\x12D\n" +
 	"\aGetBook\x12\x1b.bookservice.GetBookRequest\x1a\x1c.bookservice.GetBookResponse\x12M\n" +
 	"\n" +
@@ -1245,11 +1689,15 @@ const file_book_service_proto_rawDesc = "" +
 	"UpdateBook\x12\x1e.bookservice.UpdateBookRequest\x1a\x1f.bookservice.UpdateBookResponse\x12M\n" +
 	"\n" +
 	"DeleteBook\x12\x1e.bookservice.DeleteBookRequest\x1a\x1f.bookservice.DeleteBookResponse\x12J\n" +
-	"\tListBooks\x12\x1d.bookservice.ListBooksRequest\x1a\x1e.bookservice.ListBooksResponse\x12P\n" +
+	"\tListBooks\x12\x1d.bookservice.ListBooksRequest\x1a\x1e.bookservice.ListBooksResponse\x12C\n" +
+	"\vStreamBooks\x12\x1f.bookservice.StreamBooksRequest\x1a\x11.bookservice.Book0\x01\x12P\n" +
 	"\vSearchBooks\x12\x1f.bookservice.SearchBooksRequest\x1a .bookservice.SearchBooksResponse\x12P\n" +
 	"\vFilterBooks\x12\x1f.bookservice.FilterBooksRequest\x1a .bookservice.FilterBooksResponse\x12G\n" +
 	"\bGetStats\x12\x1c.bookservice.GetStatsRequest\x1a\x1d.bookservice.GetStatsResponse\x12_\n" +
-	"\x10GetBooksByAuthor\x12$.bookservice.GetBooksByAuthorRequest\x1a%.bookservice.GetBooksByAuthorResponseB\tZ\a./protob\x06proto3"
+	"\x10GetBooksByAuthor\x12$.bookservice.GetBooksByAuthorRequest\x1a%.bookservice.GetBooksByAuthorResponse\x12e\n" +
+	"\x12GetBooksByCategory\x12&.bookservice.GetBooksByCategoryRequest\x1a'.bookservice.GetBooksByCategoryResponse\x12O\n" +
+	"\n" +
+	"BulkUpsert\x12\x1e.bookservice.UpsertBookRequest\x1a\x1d.bookservice.UpsertBookResult(\x010\x01B\tZ\a./protob\x06proto3"
 
 var (
 	file_book_service_proto_rawDescOnce sync.Once
@@ -1263,27 +1711,32 @@ func file_book_service_proto_rawDescGZIP() []byte {
 	return file_book_service_proto_rawDescData
 }
 
-var file_book_service_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_book_service_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
 var file_book_service_proto_goTypes = []any{
-	(*Book)(nil),                     // 0: bookservice.Book
-	(*GetBookRequest)(nil),           // 1: bookservice.GetBookRequest
-	(*GetBookResponse)(nil),          // 2: bookservice.GetBookResponse
-	(*CreateBookRequest)(nil),        // 3: bookservice.CreateBookRequest
-	(*CreateBookResponse)(nil),       // 4: bookservice.CreateBookResponse
-	(*UpdateBookRequest)(nil),        // 5: bookservice.UpdateBookRequest
-	(*UpdateBookResponse)(nil),       // 6: bookservice.UpdateBookResponse
-	(*DeleteBookRequest)(nil),        // 7: bookservice.DeleteBookRequest
-	(*DeleteBookResponse)(nil),       // 8: bookservice.DeleteBookResponse
-	(*ListBooksRequest)(nil),         // 9: bookservice.ListBooksRequest
-	(*ListBooksResponse)(nil),        // 10: bookservice.ListBooksResponse
-	(*SearchBooksRequest)(nil),       // 11: bookservice.SearchBooksRequest
-	(*SearchBooksResponse)(nil),      // 12: bookservice.SearchBooksResponse
-	(*FilterBooksRequest)(nil),       // 13: bookservice.FilterBooksRequest
-	(*FilterBooksResponse)(nil),      // 14: bookservice.FilterBooksResponse
-	(*GetStatsRequest)(nil),          // 15: bookservice.GetStatsRequest
-	(*GetStatsResponse)(nil),         // 16: bookservice.GetStatsResponse
-	(*GetBooksByAuthorRequest)(nil),  // 17: bookservice.GetBooksByAuthorRequest
-	(*GetBooksByAuthorResponse)(nil), // 18: bookservice.GetBooksByAuthorResponse
+	(*Book)(nil),                       // 0: bookservice.Book
+	(*GetBookRequest)(nil),             // 1: bookservice.GetBookRequest
+	(*GetBookResponse)(nil),            // 2: bookservice.GetBookResponse
+	(*CreateBookRequest)(nil),          // 3: bookservice.CreateBookRequest
+	(*CreateBookResponse)(nil),         // 4: bookservice.CreateBookResponse
+	(*UpdateBookRequest)(nil),          // 5: bookservice.UpdateBookRequest
+	(*UpdateBookResponse)(nil),         // 6: bookservice.UpdateBookResponse
+	(*DeleteBookRequest)(nil),          // 7: bookservice.DeleteBookRequest
+	(*DeleteBookResponse)(nil),         // 8: bookservice.DeleteBookResponse
+	(*ListBooksRequest)(nil),           // 9: bookservice.ListBooksRequest
+	(*ListBooksResponse)(nil),          // 10: bookservice.ListBooksResponse
+	(*StreamBooksRequest)(nil),         // 11: bookservice.StreamBooksRequest
+	(*SearchBooksRequest)(nil),         // 12: bookservice.SearchBooksRequest
+	(*SearchBooksResponse)(nil),        // 13: bookservice.SearchBooksResponse
+	(*FilterBooksRequest)(nil),         // 14: bookservice.FilterBooksRequest
+	(*FilterBooksResponse)(nil),        // 15: bookservice.FilterBooksResponse
+	(*GetStatsRequest)(nil),            // 16: bookservice.GetStatsRequest
+	(*GetStatsResponse)(nil),           // 17: bookservice.GetStatsResponse
+	(*GetBooksByAuthorRequest)(nil),    // 18: bookservice.GetBooksByAuthorRequest
+	(*GetBooksByAuthorResponse)(nil),   // 19: bookservice.GetBooksByAuthorResponse
+	(*GetBooksByCategoryRequest)(nil),  // 20: bookservice.GetBooksByCategoryRequest
+	(*GetBooksByCategoryResponse)(nil), // 21: bookservice.GetBooksByCategoryResponse
+	(*UpsertBookRequest)(nil),          // 22: bookservice.UpsertBookRequest
+	(*UpsertBookResult)(nil),           // 23: bookservice.UpsertBookResult
 }
 var file_book_service_proto_depIdxs = []int32{
 	0,  // 0: bookservice.GetBookResponse.book:type_name -> bookservice.Book
@@ -1293,29 +1746,36 @@ var file_book_service_proto_depIdxs = []int32{
 	0,  // 4: bookservice.SearchBooksResponse.books:type_name -> bookservice.Book
 	0,  // 5: bookservice.FilterBooksResponse.books:type_name -> bookservice.Book
 	0,  // 6: bookservice.GetBooksByAuthorResponse.books:type_name -> bookservice.Book
-	1,  // 7: bookservice.BookCatalog.GetBook:input_type -> bookservice.GetBookRequest
-	3,  // 8: bookservice.BookCatalog.CreateBook:input_type -> bookservice.CreateBookRequest
-	5,  // 9: bookservice.BookCatalog.UpdateBook:input_type -> bookservice.UpdateBookRequest
-	7,  // 10: bookservice.BookCatalog.DeleteBook:input_type -> bookservice.DeleteBookRequest
-	9,  // 11: bookservice.BookCatalog.ListBooks:input_type -> bookservice.ListBooksRequest
-	11, // 12: bookservice.BookCatalog.SearchBooks:input_type -> bookservice.SearchBooksRequest
-	13, // 13: bookservice.BookCatalog.FilterBooks:input_type -> bookservice.FilterBooksRequest
-	15, // 14: bookservice.BookCatalog.GetStats:input_type -> bookservice.GetStatsRequest
-	17, // 15: bookservice.BookCatalog.GetBooksByAuthor:input_type -> bookservice.GetBooksByAuthorRequest
-	2,  // 16: bookservice.BookCatalog.GetBook:output_type -> bookservice.GetBookResponse
-	4,  // 17: bookservice.BookCatalog.CreateBook:output_type -> bookservice.CreateBookResponse
-	6,  // 18: bookservice.BookCatalog.UpdateBook:output_type -> bookservice.UpdateBookResponse
-	8,  // 19: bookservice.BookCatalog.DeleteBook:output_type -> bookservice.DeleteBookResponse
-	10, // 20: bookservice.BookCatalog.ListBooks:output_type -> bookservice.ListBooksResponse
-	12, // 21: bookservice.BookCatalog.SearchBooks:output_type -> bookservice.SearchBooksResponse
-	14, // 22: bookservice.BookCatalog.FilterBooks:output_type -> bookservice.FilterBooksResponse
-	16, // 23: bookservice.BookCatalog.GetStats:output_type -> bookservice.GetStatsResponse
-	18, // 24: bookservice.BookCatalog.GetBooksByAuthor:output_type -> bookservice.GetBooksByAuthorResponse
-	16, // [16:25] is the sub-list for method output_type
-	7,  // [7:16] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	0,  // 7: bookservice.GetBooksByCategoryResponse.books:type_name -> bookservice.Book
+	1,  // 8: bookservice.BookCatalog.GetBook:input_type -> bookservice.GetBookRequest
+	3,  // 9: bookservice.BookCatalog.CreateBook:input_type -> bookservice.CreateBookRequest
+	5,  // 10: bookservice.BookCatalog.UpdateBook:input_type -> bookservice.UpdateBookRequest
+	7,  // 11: bookservice.BookCatalog.DeleteBook:input_type -> bookservice.DeleteBookRequest
+	9,  // 12: bookservice.BookCatalog.ListBooks:input_type -> bookservice.ListBooksRequest
+	11, // 13: bookservice.BookCatalog.StreamBooks:input_type -> bookservice.StreamBooksRequest
+	12, // 14: bookservice.BookCatalog.SearchBooks:input_type -> bookservice.SearchBooksRequest
+	14, // 15: bookservice.BookCatalog.FilterBooks:input_type -> bookservice.FilterBooksRequest
+	16, // 16: bookservice.BookCatalog.GetStats:input_type -> bookservice.GetStatsRequest
+	18, // 17: bookservice.BookCatalog.GetBooksByAuthor:input_type -> bookservice.GetBooksByAuthorRequest
+	20, // 18: bookservice.BookCatalog.GetBooksByCategory:input_type -> bookservice.GetBooksByCategoryRequest
+	22, // 19: bookservice.BookCatalog.BulkUpsert:input_type -> bookservice.UpsertBookRequest
+	2,  // 20: bookservice.BookCatalog.GetBook:output_type -> bookservice.GetBookResponse
+	4,  // 21: bookservice.BookCatalog.CreateBook:output_type -> bookservice.CreateBookResponse
+	6,  // 22: bookservice.BookCatalog.UpdateBook:output_type -> bookservice.UpdateBookResponse
+	8,  // 23: bookservice.BookCatalog.DeleteBook:output_type -> bookservice.DeleteBookResponse
+	10, // 24: bookservice.BookCatalog.ListBooks:output_type -> bookservice.ListBooksResponse
+	0,  // 25: bookservice.BookCatalog.StreamBooks:output_type -> bookservice.Book
+	13, // 26: bookservice.BookCatalog.SearchBooks:output_type -> bookservice.SearchBooksResponse
+	15, // 27: bookservice.BookCatalog.FilterBooks:output_type -> bookservice.FilterBooksResponse
+	17, // 28: bookservice.BookCatalog.GetStats:output_type -> bookservice.GetStatsResponse
+	19, // 29: bookservice.BookCatalog.GetBooksByAuthor:output_type -> bookservice.GetBooksByAuthorResponse
+	21, // 30: bookservice.BookCatalog.GetBooksByCategory:output_type -> bookservice.GetBooksByCategoryResponse
+	23, // 31: bookservice.BookCatalog.BulkUpsert:output_type -> bookservice.UpsertBookResult
+	20, // [20:32] is the sub-list for method output_type
+	8,  // [8:20] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_book_service_proto_init() }
@@ -1329,7 +1789,7 @@ func file_book_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_book_service_proto_rawDesc), len(file_book_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   19,
+			NumMessages:   24,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,15 +19,18 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	BookCatalog_GetBook_FullMethodName          = "/bookservice.BookCatalog/GetBook"
-	BookCatalog_CreateBook_FullMethodName       = "/bookservice.BookCatalog/CreateBook"
-	BookCatalog_UpdateBook_FullMethodName       = "/bookservice.BookCatalog/UpdateBook"
-	BookCatalog_DeleteBook_FullMethodName       = "/bookservice.BookCatalog/DeleteBook"
-	BookCatalog_ListBooks_FullMethodName        = "/bookservice.BookCatalog/ListBooks"
-	BookCatalog_SearchBooks_FullMethodName      = "/bookservice.BookCatalog/SearchBooks"
-	BookCatalog_FilterBooks_FullMethodName      = "/bookservice.BookCatalog/FilterBooks"
-	BookCatalog_GetStats_FullMethodName         = "/bookservice.BookCatalog/GetStats"
-	BookCatalog_GetBooksByAuthor_FullMethodName = "/bookservice.BookCatalog/GetBooksByAuthor"
+	BookCatalog_GetBook_FullMethodName            = "/bookservice.BookCatalog/GetBook"
+	BookCatalog_CreateBook_FullMethodName         = "/bookservice.BookCatalog/CreateBook"
+	BookCatalog_UpdateBook_FullMethodName         = "/bookservice.BookCatalog/UpdateBook"
+	BookCatalog_DeleteBook_FullMethodName         = "/bookservice.BookCatalog/DeleteBook"
+	BookCatalog_ListBooks_FullMethodName          = "/bookservice.BookCatalog/ListBooks"
+	BookCatalog_StreamBooks_FullMethodName        = "/bookservice.BookCatalog/StreamBooks"
+	BookCatalog_SearchBooks_FullMethodName        = "/bookservice.BookCatalog/SearchBooks"
+	BookCatalog_FilterBooks_FullMethodName        = "/bookservice.BookCatalog/FilterBooks"
+	BookCatalog_GetStats_FullMethodName           = "/bookservice.BookCatalog/GetStats"
+	BookCatalog_GetBooksByAuthor_FullMethodName   = "/bookservice.BookCatalog/GetBooksByAuthor"
+	BookCatalog_GetBooksByCategory_FullMethodName = "/bookservice.BookCatalog/GetBooksByCategory"
+	BookCatalog_BulkUpsert_FullMethodName         = "/bookservice.BookCatalog/BulkUpsert"
 )
 
 // BookCatalogClient is the client API for BookCatalog service.
@@ -41,10 +44,13 @@ type BookCatalogClient interface {
 	UpdateBook(ctx context.Context, in *UpdateBookRequest, opts ...grpc.CallOption) (*UpdateBookResponse, error)
 	DeleteBook(ctx context.Context, in *DeleteBookRequest, opts ...grpc.CallOption) (*DeleteBookResponse, error)
 	ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (*ListBooksResponse, error)
+	StreamBooks(ctx context.Context, in *StreamBooksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Book], error)
 	SearchBooks(ctx context.Context, in *SearchBooksRequest, opts ...grpc.CallOption) (*SearchBooksResponse, error)
 	FilterBooks(ctx context.Context, in *FilterBooksRequest, opts ...grpc.CallOption) (*FilterBooksResponse, error)
 	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
 	GetBooksByAuthor(ctx context.Context, in *GetBooksByAuthorRequest, opts ...grpc.CallOption) (*GetBooksByAuthorResponse, error)
+	GetBooksByCategory(ctx context.Context, in *GetBooksByCategoryRequest, opts ...grpc.CallOption) (*GetBooksByCategoryResponse, error)
+	BulkUpsert(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[UpsertBookRequest, UpsertBookResult], error)
 }
 
 type bookCatalogClient struct {
@@ -105,6 +111,25 @@ func (c *bookCatalogClient) ListBooks(ctx context.Context, in *ListBooksRequest,
 	return out, nil
 }
 
+func (c *bookCatalogClient) StreamBooks(ctx context.Context, in *StreamBooksRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Book], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BookCatalog_ServiceDesc.Streams[0], BookCatalog_StreamBooks_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamBooksRequest, Book]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BookCatalog_StreamBooksClient = grpc.ServerStreamingClient[Book]
+
 func (c *bookCatalogClient) SearchBooks(ctx context.Context, in *SearchBooksRequest, opts ...grpc.CallOption) (*SearchBooksResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(SearchBooksResponse)
@@ -145,6 +170,29 @@ func (c *bookCatalogClient) GetBooksByAuthor(ctx context.Context, in *GetBooksBy
 	return out, nil
 }
 
+func (c *bookCatalogClient) GetBooksByCategory(ctx context.Context, in *GetBooksByCategoryRequest, opts ...grpc.CallOption) (*GetBooksByCategoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBooksByCategoryResponse)
+	err := c.cc.Invoke(ctx, BookCatalog_GetBooksByCategory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bookCatalogClient) BulkUpsert(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[UpsertBookRequest, UpsertBookResult], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BookCatalog_ServiceDesc.Streams[1], BookCatalog_BulkUpsert_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[UpsertBookRequest, UpsertBookResult]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BookCatalog_BulkUpsertClient = grpc.BidiStreamingClient[UpsertBookRequest, UpsertBookResult]
+
 // BookCatalogServer is the server API for BookCatalog service.
 // All implementations must embed UnimplementedBookCatalogServer
 // for forward compatibility.
@@ -156,10 +204,13 @@ type BookCatalogServer interface {
 	UpdateBook(context.Context, *UpdateBookRequest) (*UpdateBookResponse, error)
 	DeleteBook(context.Context, *DeleteBookRequest) (*DeleteBookResponse, error)
 	ListBooks(context.Context, *ListBooksRequest) (*ListBooksResponse, error)
+	StreamBooks(*StreamBooksRequest, grpc.ServerStreamingServer[Book]) error
 	SearchBooks(context.Context, *SearchBooksRequest) (*SearchBooksResponse, error)
 	FilterBooks(context.Context, *FilterBooksRequest) (*FilterBooksResponse, error)
 	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
 	GetBooksByAuthor(context.Context, *GetBooksByAuthorRequest) (*GetBooksByAuthorResponse, error)
+	GetBooksByCategory(context.Context, *GetBooksByCategoryRequest) (*GetBooksByCategoryResponse, error)
+	BulkUpsert(grpc.BidiStreamingServer[UpsertBookRequest, UpsertBookResult]) error
 	mustEmbedUnimplementedBookCatalogServer()
 }
 
@@ -185,6 +236,9 @@ func (UnimplementedBookCatalogServer) DeleteBook(context.Context, *DeleteBookReq
 func (UnimplementedBookCatalogServer) ListBooks(context.Context, *ListBooksRequest) (*ListBooksResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListBooks not implemented")
 }
+func (UnimplementedBookCatalogServer) StreamBooks(*StreamBooksRequest, grpc.ServerStreamingServer[Book]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamBooks not implemented")
+}
 func (UnimplementedBookCatalogServer) SearchBooks(context.Context, *SearchBooksRequest) (*SearchBooksResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SearchBooks not implemented")
 }
@@ -197,6 +251,12 @@ func (UnimplementedBookCatalogServer) GetStats(context.Context, *GetStatsRequest
 func (UnimplementedBookCatalogServer) GetBooksByAuthor(context.Context, *GetBooksByAuthorRequest) (*GetBooksByAuthorResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBooksByAuthor not implemented")
 }
+func (UnimplementedBookCatalogServer) GetBooksByCategory(context.Context, *GetBooksByCategoryRequest) (*GetBooksByCategoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBooksByCategory not implemented")
+}
+func (UnimplementedBookCatalogServer) BulkUpsert(grpc.BidiStreamingServer[UpsertBookRequest, UpsertBookResult]) error {
+	return status.Errorf(codes.Unimplemented, "method BulkUpsert not implemented")
+}
 func (UnimplementedBookCatalogServer) mustEmbedUnimplementedBookCatalogServer() {}
 func (UnimplementedBookCatalogServer) testEmbeddedByValue()                     {}
 
@@ -308,6 +368,17 @@ func _BookCatalog_ListBooks_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BookCatalog_StreamBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamBooksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BookCatalogServer).StreamBooks(m, &grpc.GenericServerStream[StreamBooksRequest, Book]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BookCatalog_StreamBooksServer = grpc.ServerStreamingServer[Book]
+
 func _BookCatalog_SearchBooks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(SearchBooksRequest)
 	if err := dec(in); err != nil {
@@ -380,6 +451,31 @@ func _BookCatalog_GetBooksByAuthor_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BookCatalog_GetBooksByCategory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBooksByCategoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BookCatalogServer).GetBooksByCategory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BookCatalog_GetBooksByCategory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BookCatalogServer).GetBooksByCategory(ctx, req.(*GetBooksByCategoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BookCatalog_BulkUpsert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BookCatalogServer).BulkUpsert(&grpc.GenericServerStream[UpsertBookRequest, UpsertBookResult]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BookCatalog_BulkUpsertServer = grpc.BidiStreamingServer[UpsertBookRequest, UpsertBookResult]
+
 // BookCatalog_ServiceDesc is the grpc.ServiceDesc for BookCatalog service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -423,7 +519,23 @@ var BookCatalog_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBooksByAuthor",
 			Handler:    _BookCatalog_GetBooksByAuthor_Handler,
 		},
+		{
+			MethodName: "GetBooksByCategory",
+			Handler:    _BookCatalog_GetBooksByCategory_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamBooks",
+			Handler:       _BookCatalog_StreamBooks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "BulkUpsert",
+			Handler:       _BookCatalog_BulkUpsert_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "book_service.proto",
 }
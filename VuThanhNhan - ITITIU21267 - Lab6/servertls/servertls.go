@@ -0,0 +1,26 @@
+// Package servertls holds the optional TLS setup shared by the gRPC servers
+// of Task2 to Task5.
+package servertls
+
+import (
+	"log"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Options returns the server options for the -tls-cert/-tls-key flags:
+// none when both are empty (plaintext), grpc.Creds for the pair otherwise.
+// A missing or unreadable certificate or key is an error, never a silent
+// fallback to plaintext.
+func Options(certFile, keyFile string) ([]grpc.ServerOption, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("🔒 TLS enabled")
+	return []grpc.ServerOption{grpc.Creds(creds)}, nil
+}
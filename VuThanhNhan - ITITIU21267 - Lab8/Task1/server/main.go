@@ -4,17 +4,26 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 	
 	"github.com/gorilla/websocket" // Importing the Gorilla WebSocket package
+
+	"websocket-chat/origin"
+)
+
+// Connection limits and keepalive timing
+const (
+	maxMessageSize = 4096                // bytes per incoming WebSocket message
+	writeWait      = 10 * time.Second    // time allowed to write a message
+	pongWait       = 60 * time.Second    // time allowed between pongs
+	pingPeriod     = (pongWait * 9) / 10 // must be shorter than pongWait
 )
 
 // Upgrader upgrades HTTP connection to WebSocket
 var upgrader = websocket.Upgrader{ 
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool { //
-		return true
-	},
+	CheckOrigin:     origin.Check,
 }
 
 // Handle WebSocket connections
@@ -29,18 +38,57 @@ func handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	
 	log.Printf("New client connected: %s", conn.RemoteAddr())
 	
+	// A message over the limit fails ReadMessage; gorilla answers it with
+	// a 1009 (message too big) close frame before returning ErrReadLimit.
+	// That is the oversized-message case of a policy violation: RFC 6455
+	// keeps the generic 1008 for when no more specific code fits, and a
+	// second close frame with 1008 would be ignored by the client anyway.
+	conn.SetReadLimit(maxMessageSize)
+	
+	// Connection times out unless a message or pong arrives in time
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+	
+	// Ping in the background; WriteControl may run alongside the echo writes
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(pingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	
 	// Read messages in a loop
 	for {
 		// Read message from client
 		messageType, message, err := conn.ReadMessage()
+		if err == websocket.ErrReadLimit {
+			// The 1009 close frame has already been sent
+			log.Printf("Client %s sent more than %d bytes, closing", conn.RemoteAddr(), maxMessageSize)
+			break
+		}
 		if err != nil {
 			log.Printf("Client disconnected: %v", err)
 			break
 		}
+		conn.SetReadDeadline(time.Now().Add(pongWait))
 		
 		log.Printf("Received: %s", message)
 		
 		// Echo message back to client
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
 		err = conn.WriteMessage(messageType, message)
 		if err != nil {
 			log.Printf("Failed to write message: %v", err)
@@ -2,8 +2,10 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
@@ -11,16 +13,38 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+// Message is the JSON envelope broadcast by the server
+type Message struct {
+	Type   string `json:"type"`
+	Sender string `json:"sender"`
+	Room   string `json:"room,omitempty"`
+	Text   string `json:"text"`
+	Time   string `json:"time"`
+}
+
 func main() {
+	// Optional room from the command line, e.g. go run main.go golang
+	room := "general"
+	if len(os.Args) > 1 {
+		room = os.Args[1]
+	}
+
+	u := url.URL{
+		Scheme:   "ws",
+		Host:     "localhost:8080",
+		Path:     "/ws",
+		RawQuery: url.Values{"room": {room}}.Encode(),
+	}
+
 	// Connect to WebSocket server
-	conn, _, err := websocket.DefaultDialer.Dial("ws://localhost:8080/ws", nil)
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
 	if err != nil {
 		log.Fatal("Failed to connect:", err)
 	}
 	defer conn.Close()
 
-	fmt.Println("✓ Connected to broadcast chat server")
-	fmt.Println("Type messages to broadcast to all clients (Ctrl+C to exit)")
+	fmt.Printf("✓ Connected to broadcast chat server (room: %s)\n", room)
+	fmt.Println("Type messages to send to your room, /all <text> for everyone (Ctrl+C to exit)")
 	fmt.Println("---")
 
 	// Channel for interrupt signal (Ctrl+C)
@@ -39,8 +63,22 @@ func main() {
 				log.Println("Connection closed:", err)
 				return
 			}
-			// Display broadcast message
-			fmt.Printf("%s\n", message)
+			// Decode the envelope and display by type
+			var msg Message
+			if err := json.Unmarshal(message, &msg); err != nil {
+				fmt.Printf("%s\n", message)
+				continue
+			}
+			switch msg.Type {
+			case "system":
+				// Join/leave notification
+				fmt.Printf("[%s] * %s\n", msg.Time, msg.Text)
+			case "announcement":
+				// Sent to every room
+				fmt.Printf("[%s] 📢 %s (%s): %s\n", msg.Time, msg.Sender, msg.Room, msg.Text)
+			default:
+				fmt.Printf("[%s] %s: %s\n", msg.Time, msg.Sender, msg.Text)
+			}
 		}
 	}()
 
@@ -1,33 +1,69 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
-	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+
+	"websocket-chat/origin"
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
-	},
+	CheckOrigin: origin.Check,
+}
+
+// Message is the JSON envelope for everything the server broadcasts
+type Message struct {
+	Type   string `json:"type"`           // "chat", "announcement" or "system"
+	Sender string `json:"sender"`         // Client ID, empty for system messages
+	Room   string `json:"room,omitempty"` // Sender's room
+	Text   string `json:"text"`           // Message content
+	Time   string `json:"time"`           // Timestamp HH:MM:SS
+}
+
+// Marshal a message for sending; Message always encodes
+func encodeMessage(msg Message) []byte {
+	data, _ := json.Marshal(msg)
+	return data
+}
+
+// Build a system message, e.g. for join/leave notices
+func systemMessage(text string) []byte {
+	return encodeMessage(Message{
+		Type: "system",
+		Text: text,
+		Time: time.Now().Format("15:04:05"),
+	})
 }
 
 // Client represents a WebSocket client
 type Client struct {
 	ID   string
+	Room string // Messages only reach clients in the same room
 	Conn *websocket.Conn
 	Send chan []byte
 }
 
+// Room used when the client doesn't ask for one
+const defaultRoom = "general"
+
+// roomMessage is an encoded message and the room it goes to;
+// an empty room means every connected client
+type roomMessage struct {
+	room string
+	data []byte
+}
+
 // Hub manages all connected clients
 type Hub struct {
-	clients    map[*Client]bool
-	broadcast  chan []byte
+	rooms      map[string]map[*Client]bool // room -> clients in it
+	broadcast  chan roomMessage
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
@@ -36,48 +72,94 @@ type Hub struct {
 // Create new hub instance
 func newHub() *Hub {
 	return &Hub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
+		rooms:      make(map[string]map[*Client]bool),
+		broadcast:  make(chan roomMessage),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 	}
 }
 
+// SendToRoom queues a message for the clients in one room
+func (h *Hub) SendToRoom(room string, data []byte) {
+	h.broadcast <- roomMessage{room: room, data: data}
+}
+
+// SendToAll queues a message for every client, e.g. global announcements
+func (h *Hub) SendToAll(data []byte) {
+	h.broadcast <- roomMessage{data: data}
+}
+
 // Run starts the hub's main event loop
 func (h *Hub) run() {
 	for {
 		select {
 		case client := <-h.register:
-			// Add client to map (thread-safe)
+			// Add client to its room (thread-safe)
 			h.mu.Lock()
-			h.clients[client] = true
+			if h.rooms[client.Room] == nil {
+				h.rooms[client.Room] = make(map[*Client]bool)
+			}
+			h.rooms[client.Room][client] = true
+			count := len(h.rooms[client.Room])
 			h.mu.Unlock()
-			log.Printf("Client registered: %s (Total: %d)", client.ID, len(h.clients))
+			log.Printf("Client registered: %s in %s (Room total: %d)", client.ID, client.Room, count)
+			h.deliver(roomMessage{
+				room: client.Room,
+				data: systemMessage(fmt.Sprintf("%s joined %s (%d online)", client.ID, client.Room, count)),
+			})
 
 		case client := <-h.unregister:
-			// Remove client from map and close channel
+			// Remove client from its room and close channel
 			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.Send)
+			_, ok := h.rooms[client.Room][client]
+			if ok {
+				h.removeClient(client)
 			}
+			count := len(h.rooms[client.Room])
 			h.mu.Unlock()
-			log.Printf("Client unregistered: %s (Total: %d)", client.ID, len(h.clients))
+			log.Printf("Client unregistered: %s from %s (Room total: %d)", client.ID, client.Room, count)
+			if ok {
+				h.deliver(roomMessage{
+					room: client.Room,
+					data: systemMessage(fmt.Sprintf("%s left %s (%d online)", client.ID, client.Room, count)),
+				})
+			}
 
 		case message := <-h.broadcast:
-			// Send message to all connected clients
-			h.mu.RLock()
-			for client := range h.clients {
-				select {
-				case client.Send <- message:
-					// Message sent successfully
-				default:
-					// Channel full, client slow/dead - close it
-					close(client.Send)
-					delete(h.clients, client)
-				}
+			h.deliver(message)
+		}
+	}
+}
+
+// removeClient drops a client and closes its channel; empty rooms are
+// deleted. Caller must hold h.mu.
+func (h *Hub) removeClient(client *Client) {
+	delete(h.rooms[client.Room], client)
+	close(client.Send)
+	if len(h.rooms[client.Room]) == 0 {
+		delete(h.rooms, client.Room)
+	}
+}
+
+// deliver sends an encoded message to the clients of its room, or to all
+// clients; only called from run, which is why it can't go through the
+// broadcast channel
+func (h *Hub) deliver(message roomMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for room, clients := range h.rooms {
+		if message.room != "" && room != message.room {
+			continue
+		}
+		for client := range clients {
+			select {
+			case client.Send <- message.data:
+				// Message sent successfully
+			default:
+				// Channel full, client slow/dead - close it
+				h.removeClient(client)
 			}
-			h.mu.RUnlock()
 		}
 	}
 }
@@ -110,11 +192,30 @@ func (c *Client) readPump(hub *Hub) {
 			break
 		}
 
-		// Add sender ID to message
-		fullMessage := fmt.Sprintf("[%s]: %s", c.ID, message)
+		// "/all <text>" is an announcement to every room
+		text := string(message)
+		if announcement, ok := strings.CutPrefix(text, "/all "); ok {
+			hub.SendToAll(encodeMessage(Message{
+				Type:   "announcement",
+				Sender: c.ID,
+				Room:   c.Room,
+				Text:   announcement,
+				Time:   time.Now().Format("15:04:05"),
+			}))
+			continue
+		}
+
+		// Wrap the text in an envelope with the sender ID and time
+		envelope := encodeMessage(Message{
+			Type:   "chat",
+			Sender: c.ID,
+			Room:   c.Room,
+			Text:   text,
+			Time:   time.Now().Format("15:04:05"),
+		})
 		
-		// Broadcast message to all clients via hub
-		hub.broadcast <- []byte(fullMessage)
+		// Broadcast message to the sender's room via hub
+		hub.SendToRoom(c.Room, envelope)
 	}
 }
 
@@ -159,6 +260,12 @@ var hub = newHub()
 
 // handleWebSocket handles WebSocket connection upgrades
 func handleWebSocket(c *gin.Context) {
+	// Room from the URL query, e.g. /ws?room=golang
+	room := strings.TrimSpace(c.DefaultQuery("room", defaultRoom))
+	if room == "" {
+		room = defaultRoom
+	}
+
 	// Upgrade HTTP connection to WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -166,9 +273,10 @@ func handleWebSocket(c *gin.Context) {
 		return
 	}
 
-	// Create new client with unique ID
+	// Create new client with unique ID in the requested room
 	client := &Client{
 		ID:   fmt.Sprintf("client-%d", time.Now().Unix()),
+		Room: room,
 		Conn: conn,
 		Send: make(chan []byte, 256), // Buffered channel
 	}
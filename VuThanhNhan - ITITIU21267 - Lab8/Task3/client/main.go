@@ -11,6 +11,8 @@ import (
 	"strings"
 
 	"github.com/gorilla/websocket"
+
+	"websocket-chat/reconnect"
 )
 
 // Message structure matching server
@@ -18,10 +20,22 @@ type Message struct {
 	Type     string `json:"type"`
 	Room     string `json:"room"`
 	Username string `json:"username"`
+	To       string `json:"to,omitempty"`
 	Text     string `json:"text"`
 	Time     string `json:"time"`
 }
 
+// chatURL is the server's WebSocket URL for username in room
+func chatURL(username, room string) string {
+	u := url.URL{
+		Scheme:   "ws",
+		Host:     "localhost:8080",
+		Path:     "/ws",
+		RawQuery: fmt.Sprintf("username=%s&room=%s", username, room),
+	}
+	return u.String()
+}
+
 func main() {
 	// Check command line arguments
 	if len(os.Args) < 3 {
@@ -34,39 +48,30 @@ func main() {
 	username := os.Args[1]
 	room := os.Args[2]
 
-	// Build WebSocket URL with query parameters
-	u := url.URL{
-		Scheme:   "ws",
-		Host:     "localhost:8080",
-		Path:     "/ws",
-		RawQuery: fmt.Sprintf("username=%s&room=%s", username, room),
-	}
-
 	// Connect to WebSocket server
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	first, _, err := websocket.DefaultDialer.Dial(chatURL(username, room), nil)
 	if err != nil {
 		log.Fatal("Failed to connect:", err)
 	}
-	defer conn.Close()
+	conn := reconnect.NewConn(first)
+	backoff := reconnect.New()
 
 	fmt.Printf("✓ Connected to room '%s' as '%s'\n", room, username)
 	fmt.Println("Type messages and press Enter (Ctrl+C to exit)")
+	fmt.Println("Private message: /w <username> <text>")
 	fmt.Println("---")
 
 	// Channel for interrupt signal (Ctrl+C)
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
-	// Channel to signal when reading is done
-	done := make(chan struct{})
-
-	// Goroutine to read messages from server
-	go func() {
-		defer close(done)
+	// Reads messages from one connection; the read error is sent on done
+	readMessages := func(conn *websocket.Conn, done chan<- error) {
 		for {
 			_, data, err := conn.ReadMessage()
 			if err != nil {
 				log.Println("Connection closed:", err)
+				done <- err
 				return
 			}
 
@@ -85,9 +90,18 @@ func main() {
 			case "system":
 				// System notification (join/leave)
 				fmt.Printf("[%s] * %s\n", msg.Time, msg.Text)
+				// Our own join notice: the server let us in (again)
+				if msg.Username == username && msg.Text == username+" joined the room" {
+					backoff.Reset()
+				}
+			case "whisper":
+				// Private message (also our own echo)
+				fmt.Printf("[%s] (whisper) %s -> %s: %s\n", msg.Time, msg.Username, msg.To, msg.Text)
+			case "typing":
+				fmt.Printf("* %s is typing...\n", msg.Username)
 			}
 		}
-	}()
+	}
 
 	// Read input from user and send to server
 	scanner := bufio.NewScanner(os.Stdin)
@@ -103,6 +117,16 @@ func main() {
 				Text: text,
 			}
 
+			// "/w Bob hello" sends a whisper to Bob
+			if strings.HasPrefix(text, "/w ") {
+				parts := strings.SplitN(text, " ", 3)
+				if len(parts) < 3 {
+					fmt.Println("Usage: /w <username> <text>")
+					continue
+				}
+				msg = Message{Type: "whisper", To: parts[1], Text: parts[2]}
+			}
+
 			// Marshal to JSON
 			data, err := json.Marshal(msg)
 			if err != nil {
@@ -110,29 +134,47 @@ func main() {
 				continue
 			}
 
-			// Send message to server
-			err = conn.WriteMessage(websocket.TextMessage, data)
-			if err != nil {
-				log.Println("Write error:", err)
-				return
+			// Send message to server (dropped while reconnecting)
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				fmt.Println("✗ Not connected, message not sent")
 			}
 		}
 	}()
 
-	// Wait for interrupt signal or connection close
-	select {
-	case <-done:
-		// Connection closed by server
-		fmt.Println("\nServer closed the connection")
-	case <-interrupt:
-		// User pressed Ctrl+C - graceful shutdown
-		fmt.Println("\nShutting down gracefully...")
-		err := conn.WriteMessage(
-			websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
-		)
-		if err != nil {
-			log.Println("Write close error:", err)
+	for {
+		done := make(chan error, 1)
+		go readMessages(conn.Get(), done)
+
+		// Wait for interrupt signal or connection close
+		select {
+		case err := <-done:
+			// Connection dropped: reconnect unless the server refused us
+			// (e.g. username taken), which a retry wouldn't fix. Right
+			// after a redial "username taken" may only mean the server
+			// still holds our half-open old session, so keep retrying then.
+			conn.Get().Close()
+			if websocket.IsCloseError(err, websocket.ClosePolicyViolation) && !backoff.Retrying() {
+				fmt.Println("\nServer closed the connection")
+				return
+			}
+
+			newConn := backoff.Dial(chatURL(username, room), interrupt)
+			if newConn == nil {
+				return
+			}
+			conn.Set(newConn)
+
+		case <-interrupt:
+			// User pressed Ctrl+C - graceful shutdown
+			fmt.Println("\nShutting down gracefully...")
+			err := conn.WriteMessage(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			)
+			if err != nil {
+				log.Println("Write close error:", err)
+			}
+			return
 		}
 	}
 }
@@ -1,30 +1,45 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+
+	"websocket-chat/origin"
+)
+
+// Per-client abuse limits
+const (
+	maxMessageSize    = 4096 // bytes per incoming WebSocket message
+	messagesPerSecond = 5    // sustained message rate per client
+	messageBurst      = 10   // messages allowed in a short burst
+	maxRateViolations = 5    // dropped messages before disconnecting
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
-	},
+	CheckOrigin: origin.Check,
 }
 
 // Message types for different chat events
 type Message struct {
-	Type     string `json:"type"`     // "join", "leave", "chat", "system"
-	Room     string `json:"room"`     // Room name
-	Username string `json:"username"` // Sender's username
-	Text     string `json:"text"`     // Message content
-	Time     string `json:"time"`     // Timestamp HH:MM:SS
+	Type     string `json:"type"`         // "join", "leave", "chat", "system", "whisper", "typing"
+	Room     string `json:"room"`         // Room name
+	Username string `json:"username"`     // Sender's username
+	To       string `json:"to,omitempty"` // Whisper recipient
+	Text     string `json:"text"`         // Message content
+	Time     string `json:"time"`         // Timestamp HH:MM:SS
 }
 
 // Client represents a connected user
@@ -34,29 +49,91 @@ type Client struct {
 	Conn     *websocket.Conn
 	Room     string
 	Send     chan []byte
+
+	// Token bucket, only touched by readPump
+	tokens     float64
+	lastRefill time.Time
+	violations int
+
+	// Close frame sent by writePump once Send is closed (0 = normal)
+	closeCode int
+	closeText string
+
+	// Guards closing Send, so late direct sends are dropped instead of
+	// panicking (e.g. readPump replying while the server shuts down)
+	sendMu     sync.Mutex
+	sendClosed bool
+}
+
+// closeSend closes Send once; writePump then sends a close frame with
+// code and text (code 0 sends an empty close frame)
+func (c *Client) closeSend(code int, text string) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.sendClosed {
+		return
+	}
+	c.sendClosed = true
+	c.closeCode = code
+	c.closeText = text
+	close(c.Send)
 }
 
+// allowMessage takes a token from the client's bucket, refilling it at
+// messagesPerSecond up to messageBurst
+func (c *Client) allowMessage() bool {
+	now := time.Now()
+	if c.lastRefill.IsZero() {
+		c.tokens = messageBurst
+	} else {
+		c.tokens += now.Sub(c.lastRefill).Seconds() * messagesPerSecond
+		if c.tokens > messageBurst {
+			c.tokens = messageBurst
+		}
+	}
+	c.lastRefill = now
+
+	if c.tokens < 1 {
+		return false
+	}
+	c.tokens--
+	return true
+}
+
+// Number of recent chat messages each room keeps for new joiners
+const defaultHistorySize = 50
+
 // Room represents a chat room with multiple clients
 type Room struct {
 	Name    string
 	Clients map[*Client]bool
+	history []Message // last chat messages, oldest first
 	mu      sync.RWMutex
 }
 
 // Hub manages all rooms and clients
 type Hub struct {
-	rooms      map[string]*Room
-	register   chan *Client
-	unregister chan *Client
-	mu         sync.RWMutex
+	rooms       map[string]*Room
+	register    chan *Client
+	unregister  chan *Client
+	historySize int
+	mu          sync.RWMutex
+
+	quit    chan struct{}  // closed to ask run to stop
+	done    chan struct{}  // closed once run has stopped
+	writers sync.WaitGroup // running writePumps
 }
 
 // Create new hub instance
 func newHub() *Hub {
 	return &Hub{
-		rooms:      make(map[string]*Room),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		rooms:       make(map[string]*Room),
+		register:    make(chan *Client),
+		unregister:  make(chan *Client),
+		historySize: defaultHistorySize,
+		quit:        make(chan struct{}),
+		done:        make(chan struct{}),
 	}
 }
 
@@ -71,8 +148,65 @@ func (h *Hub) run() {
 		case client := <-h.unregister:
 			// Remove client from their room
 			h.removeClientFromRoom(client)
+
+		case <-h.quit:
+			// Say goodbye to everyone, then stop the loop
+			h.closeAllClients("Server shutting down")
+			close(h.done)
+			return
+		}
+	}
+}
+
+// shutdown stops the run loop and waits up to timeout for writePumps to
+// flush their last messages and close frames
+func (h *Hub) shutdown(timeout time.Duration) {
+	close(h.quit)
+	<-h.done
+
+	flushed := make(chan struct{})
+	go func() {
+		h.writers.Wait()
+		close(flushed)
+	}()
+
+	select {
+	case <-flushed:
+	case <-time.After(timeout):
+		log.Printf("Timed out waiting for clients to flush")
+	}
+}
+
+// closeAllClients notifies every room, then closes each client with a
+// going-away close frame and forgets all rooms
+func (h *Hub) closeAllClients(reason string) {
+	h.mu.RLock()
+	names := make([]string, 0, len(h.rooms))
+	for name := range h.rooms {
+		names = append(names, name)
+	}
+	h.mu.RUnlock()
+
+	for _, name := range names {
+		h.broadcastToRoom(name, Message{
+			Type: "system",
+			Room: name,
+			Text: reason,
+			Time: time.Now().Format("15:04:05"),
+		})
+	}
+
+	h.mu.Lock()
+	for _, room := range h.rooms {
+		room.mu.Lock()
+		for client := range room.Clients {
+			delete(room.Clients, client)
+			client.closeSend(websocket.CloseGoingAway, reason) // writePump flushes first
 		}
+		room.mu.Unlock()
 	}
+	h.rooms = make(map[string]*Room)
+	h.mu.Unlock()
 }
 
 // addClientToRoom adds a client to specified room (creates room if needed)
@@ -90,15 +224,32 @@ func (h *Hub) addClientToRoom(client *Client) {
 		log.Printf("Created new room: %s", client.Room)
 	}
 
-	// Add client to room
+	// Check the username and add client to room while holding the lock,
+	// so two simultaneous joins can't both take the same name
 	room.mu.Lock()
+	for other := range room.Clients {
+		if other.Username == client.Username {
+			room.mu.Unlock()
+			h.mu.Unlock()
+			h.rejectClient(client, fmt.Sprintf("Username %s is already taken in room %s", client.Username, client.Room))
+			return
+		}
+	}
 	room.Clients[client] = true
+	clientCount := len(room.Clients)
+	history := make([]Message, len(room.history))
+	copy(history, room.history)
 	room.mu.Unlock()
 
 	h.mu.Unlock() // ⭐ UNLOCK TRƯỚC KHI BROADCAST!
 
 	log.Printf("Client %s joined room %s (Total: %d)",
-		client.Username, client.Room, len(room.Clients))
+		client.Username, client.Room, clientCount)
+
+	// Replay recent messages to the new client only
+	for _, old := range history {
+		h.sendToClient(client, old)
+	}
 
 	// Send join notification to all clients in room
 	msg := Message{
@@ -111,6 +262,20 @@ func (h *Hub) addClientToRoom(client *Client) {
 	h.broadcastToRoom(client.Room, msg)
 }
 
+// rejectClient sends a system error to a client that never joined a room,
+// then closes it with a policy-violation close code
+func (h *Hub) rejectClient(client *Client, reason string) {
+	log.Printf("Rejected %s from room %s: %s", client.Username, client.Room, reason)
+
+	h.sendToClient(client, Message{
+		Type: "system",
+		Room: client.Room,
+		Text: reason,
+		Time: time.Now().Format("15:04:05"),
+	})
+	client.closeSend(websocket.ClosePolicyViolation, reason) // writePump flushes the error first
+}
+
 // removeClientFromRoom removes a client from their room
 func (h *Hub) removeClientFromRoom(client *Client) {
 	h.mu.RLock()
@@ -123,13 +288,19 @@ func (h *Hub) removeClientFromRoom(client *Client) {
 
 	// Remove client from room
 	room.mu.Lock()
-	if _, ok := room.Clients[client]; ok {
+	_, joined := room.Clients[client]
+	if joined {
 		delete(room.Clients, client)
-		close(client.Send)
+		client.closeSend(0, "")
 	}
 	clientCount := len(room.Clients)
 	room.mu.Unlock()
 
+	// Rejected clients never joined, so there is nobody to notify
+	if !joined && client.closeCode == websocket.ClosePolicyViolation {
+		return
+	}
+
 	log.Printf("Client %s left room %s (Remaining: %d)",
 		client.Username, client.Room, clientCount)
 
@@ -143,17 +314,68 @@ func (h *Hub) removeClientFromRoom(client *Client) {
 	}
 	h.broadcastToRoom(client.Room, msg)
 
-	// Delete room if empty
+	// Delete room if empty. Someone may have joined since room.mu was
+	// released, so re-check under the hub lock (joins hold it while adding).
 	if clientCount == 0 {
 		h.mu.Lock()
-		delete(h.rooms, client.Room)
+		room.mu.RLock()
+		empty := len(room.Clients) == 0
+		room.mu.RUnlock()
+		if empty && h.rooms[client.Room] == room {
+			delete(h.rooms, client.Room)
+			log.Printf("Deleted empty room: %s", client.Room)
+		}
 		h.mu.Unlock()
-		log.Printf("Deleted empty room: %s", client.Room)
 	}
 }
 
+// appendHistory records a chat message in the room's ring buffer
+func (h *Hub) appendHistory(roomName string, msg Message) {
+	h.mu.RLock()
+	room, exists := h.rooms[roomName]
+	h.mu.RUnlock()
+
+	if !exists || h.historySize <= 0 {
+		return
+	}
+
+	room.mu.Lock()
+	room.history = append(room.history, msg)
+	if len(room.history) > h.historySize {
+		room.history = room.history[len(room.history)-h.historySize:]
+	}
+	room.mu.Unlock()
+}
+
+// roomHistory returns a copy of the last limit messages of a room
+// (empty for unknown rooms)
+func (h *Hub) roomHistory(roomName string, limit int) []Message {
+	h.mu.RLock()
+	room, exists := h.rooms[roomName]
+	h.mu.RUnlock()
+
+	history := []Message{}
+	if !exists {
+		return history
+	}
+
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	start := 0
+	if limit < len(room.history) {
+		start = len(room.history) - limit
+	}
+	return append(history, room.history[start:]...)
+}
+
 // broadcastToRoom sends message to all clients in specified room only
 func (h *Hub) broadcastToRoom(roomName string, msg Message) {
+	h.broadcastToRoomExcept(roomName, msg, nil)
+}
+
+// broadcastToRoomExcept is broadcastToRoom skipping one client (e.g. the sender)
+func (h *Hub) broadcastToRoomExcept(roomName string, msg Message, except *Client) {
 	h.mu.RLock()
 	room, exists := h.rooms[roomName]
 	h.mu.RUnlock()
@@ -170,29 +392,106 @@ func (h *Hub) broadcastToRoom(roomName string, msg Message) {
 	}
 
 	// Send to all clients in this room
+	var slow []*Client
 	room.mu.RLock()
-	defer room.mu.RUnlock()
-
 	for client := range room.Clients {
+		if client == except {
+			continue
+		}
 		select {
 		case client.Send <- data:
 			// Message sent successfully
 		default:
-			// Channel full, client slow/dead - close it
-			close(client.Send)
-			delete(room.Clients, client)
+			// Channel full, client slow/dead - drop it below
+			slow = append(slow, client)
 		}
 	}
+	room.mu.RUnlock()
+
+	// Removing needs the write lock; the read lock above only allows lookups
+	if len(slow) > 0 {
+		room.mu.Lock()
+		for _, client := range slow {
+			if _, ok := room.Clients[client]; ok {
+				delete(room.Clients, client)
+				client.closeSend(0, "")
+			}
+		}
+		room.mu.Unlock()
+	}
+}
+
+// sendWhisper delivers a private message to msg.To in the sender's room,
+// echoing it back to the sender; the sender gets a system error otherwise
+func (h *Hub) sendWhisper(from *Client, msg Message) {
+	h.mu.RLock()
+	room, exists := h.rooms[from.Room]
+	h.mu.RUnlock()
+
+	var target *Client
+	if exists {
+		room.mu.RLock()
+		for client := range room.Clients {
+			if client.Username == msg.To {
+				target = client
+				break
+			}
+		}
+		room.mu.RUnlock()
+	}
+
+	if target == nil {
+		h.sendToClient(from, Message{
+			Type: "system",
+			Room: from.Room,
+			Text: fmt.Sprintf("User %s is not in this room", msg.To),
+			Time: msg.Time,
+		})
+		return
+	}
+
+	h.sendToClient(target, msg)
+	if target != from {
+		h.sendToClient(from, msg)
+	}
+}
+
+// sendToClient queues a message for a single client
+func (h *Hub) sendToClient(client *Client, msg Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Failed to marshal message: %v", err)
+		return
+	}
+
+	client.sendMu.Lock()
+	defer client.sendMu.Unlock()
+	if client.sendClosed {
+		return // client is already leaving
+	}
+
+	select {
+	case client.Send <- data:
+	default:
+		log.Printf("Send buffer full for %s, dropping message", client.Username)
+	}
 }
 
 // readPump reads messages from WebSocket connection
 func (c *Client) readPump(hub *Hub) {
 	defer func() {
 		// Cleanup on exit: unregister and close connection
-		hub.unregister <- c
+		// (the hub is gone after shutdown, nothing to unregister from)
+		select {
+		case hub.unregister <- c:
+		case <-hub.done:
+		}
 		c.Conn.Close()
 	}()
 
+	// Oversized messages fail ReadMessage and close the connection
+	c.Conn.SetReadLimit(maxMessageSize)
+
 	// Set read deadline - connection times out after 60 seconds
 	c.Conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 
@@ -212,6 +511,25 @@ func (c *Client) readPump(hub *Hub) {
 			break
 		}
 
+		// Rate limit: warn on excess, disconnect repeat offenders
+		if !c.allowMessage() {
+			c.violations++
+			if c.violations >= maxRateViolations {
+				log.Printf("Disconnecting %s: rate limit exceeded", c.Username)
+				c.Conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "rate limit exceeded"),
+					time.Now().Add(time.Second))
+				break
+			}
+			hub.sendToClient(c, Message{
+				Type: "system",
+				Room: c.Room,
+				Text: "You are sending messages too fast; message dropped",
+				Time: time.Now().Format("15:04:05"),
+			})
+			continue
+		}
+
 		// Parse incoming JSON message
 		var msg Message
 		if err := json.Unmarshal(data, &msg); err != nil {
@@ -222,21 +540,41 @@ func (c *Client) readPump(hub *Hub) {
 		// Set message metadata (server-side, not trusted from client)
 		msg.Username = c.Username
 		msg.Room = c.Room
-		msg.Type = "chat"
 		msg.Time = time.Now().Format("15:04:05")
 
-		// Broadcast to room only
-		hub.broadcastToRoom(c.Room, msg)
+		switch msg.Type {
+		case "whisper":
+			// Private message to one user in the same room
+			hub.sendWhisper(c, msg)
+		case "typing":
+			// Typing indicator for everyone else in the room
+			msg.Text = ""
+			hub.broadcastToRoomExcept(c.Room, msg, c)
+		case "", "chat":
+			// Broadcast to room only
+			msg.Type = "chat"
+			msg.To = ""
+			hub.appendHistory(c.Room, msg)
+			hub.broadcastToRoom(c.Room, msg)
+		default:
+			hub.sendToClient(c, Message{
+				Type: "system",
+				Room: c.Room,
+				Text: fmt.Sprintf("Unknown message type: %s", msg.Type),
+				Time: msg.Time,
+			})
+		}
 	}
 }
 
 // writePump writes messages to WebSocket connection
-func (c *Client) writePump() {
+func (c *Client) writePump(hub *Hub) {
 	// Create ticker for sending pings every 54 seconds
 	ticker := time.NewTicker(54 * time.Second)
 	defer func() {
 		ticker.Stop()
 		c.Conn.Close()
+		hub.writers.Done()
 	}()
 
 	for {
@@ -247,7 +585,11 @@ func (c *Client) writePump() {
 
 			if !ok {
 				// Channel closed, send close message
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				closeMsg := []byte{}
+				if c.closeCode != 0 {
+					closeMsg = websocket.FormatCloseMessage(c.closeCode, c.closeText)
+				}
+				c.Conn.WriteMessage(websocket.CloseMessage, closeMsg)
 				return
 			}
 
@@ -269,6 +611,21 @@ func (c *Client) writePump() {
 // Global hub instance
 var hub = newHub()
 
+// handleRoomHistory serves GET /rooms/:room/history?limit=50
+func handleRoomHistory(c *gin.Context) {
+	limit := defaultHistorySize
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			c.JSON(400, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = n
+	}
+
+	c.JSON(200, hub.roomHistory(c.Param("room"), limit))
+}
+
 // handleWebSocket handles WebSocket connection upgrades
 func handleWebSocket(c *gin.Context) {
 	// Get username and room from URL query parameters
@@ -297,18 +654,26 @@ func handleWebSocket(c *gin.Context) {
 		Send:     make(chan []byte, 256), // Buffered channel
 	}
 
-	// Register client with hub
-	hub.register <- client
-
-	// Start goroutines for reading and writing
-	go client.writePump()
+	// Order matters: writePump drains Send before the join notification and
+	// history are queued, and readPump (whose exit unregisters) only starts
+	// once the client is registered
+	hub.writers.Add(1)
+	go client.writePump(hub)
+	select {
+	case hub.register <- client: // sends join notification + history
+	case <-hub.done:
+		// Shutting down: writePump sends a close frame and exits
+		client.closeSend(websocket.CloseGoingAway, "Server shutting down")
+		return
+	}
 	go client.readPump(hub)
-
-	// Register client to hub (this will send join notification + history)
-	hub.register <- client
 }
 
 func main() {
+	historySize := flag.Int("history", defaultHistorySize, "chat messages kept per room for new joiners (0 disables)")
+	flag.Parse()
+	hub.historySize = *historySize
+
 	// Start hub in background goroutine
 	go hub.run()
 
@@ -318,8 +683,37 @@ func main() {
 	// WebSocket endpoint
 	router.GET("/ws", handleWebSocket)
 
+	// Recent messages of a room, for dashboards without a WebSocket
+	router.GET("/rooms/:room/history", handleRoomHistory)
+
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Server error:", err)
+		}
+	}()
+
 	fmt.Println("🚀 Chat Rooms Server started on :8080")
 
-	// Start server
-	router.Run(":8080")
+	// Wait for Ctrl+C or a termination signal
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	fmt.Println("🛑 Shutting down server...")
+
+	// Notify and close every WebSocket, then stop the hub
+	hub.shutdown(2 * time.Second)
+
+	// Give in-flight HTTP requests up to 5 seconds to finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+	fmt.Println("👋 Server stopped")
 }
\ No newline at end of file
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var testServer *httptest.Server
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	go hub.run()
+
+	router := gin.New()
+	router.GET("/ws", handleWebSocket)
+	testServer = httptest.NewServer(router)
+
+	code := m.Run()
+	testServer.Close()
+	os.Exit(code)
+}
+
+// Connect a real WebSocket client as username in room
+func dial(t *testing.T, username, room string) *websocket.Conn {
+	t.Helper()
+	url := fmt.Sprintf("ws%s/ws?username=%s&room=%s", strings.TrimPrefix(testServer.URL, "http"), username, room)
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial %s: %v", username, err)
+	}
+	return conn
+}
+
+// Read messages until one matches, or fail after timeout
+func waitFor(t *testing.T, conn *websocket.Conn, match func(Message) bool) Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("waiting for message: %v", err)
+		}
+		if match(msg) {
+			return msg
+		}
+	}
+}
+
+// Whether room exists in the hub
+func roomExists(name string) bool {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	_, ok := hub.rooms[name]
+	return ok
+}
+
+func TestJoinReceivesOwnJoinNotification(t *testing.T) {
+	// Repeat to catch an ordering where the notification is queued before
+	// anything drains Send
+	for i := 0; i < 20; i++ {
+		username := fmt.Sprintf("joiner%d", i)
+		conn := dial(t, username, "lobby")
+		msg := waitFor(t, conn, func(m Message) bool { return m.Type == "system" && m.Username == username })
+		if msg.Text != username+" joined the room" || msg.Room != "lobby" {
+			t.Errorf("join notification = %+v", msg)
+		}
+		conn.Close()
+	}
+}
+
+func TestConcurrentJoinAndLeave(t *testing.T) {
+	const clients = 20
+	room := "busy"
+
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			username := fmt.Sprintf("user%d", i)
+			url := fmt.Sprintf("ws%s/ws?username=%s&room=%s", strings.TrimPrefix(testServer.URL, "http"), username, room)
+			conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+			if err != nil {
+				t.Errorf("dial %s: %v", username, err)
+				return
+			}
+			defer conn.Close()
+
+			// Wait until joined, then leave while others are still joining
+			conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			for {
+				var msg Message
+				if err := conn.ReadJSON(&msg); err != nil {
+					t.Errorf("%s: %v", username, err)
+					return
+				}
+				if msg.Type == "system" && msg.Username == username {
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The last one out deletes the room
+	deadline := time.Now().Add(2 * time.Second)
+	for roomExists(room) {
+		if time.Now().After(deadline) {
+			t.Fatal("room still exists after every client left")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
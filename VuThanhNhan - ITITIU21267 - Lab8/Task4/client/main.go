@@ -9,8 +9,11 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 
 	"github.com/gorilla/websocket"
+
+	"websocket-chat/reconnect"
 )
 
 // Message types
@@ -20,6 +23,7 @@ const (
 	MsgUserList = "user_list"
 	MsgStats    = "stats"
 	MsgRooms    = "rooms"
+	MsgAck      = "ack"
 )
 
 // Message structures matching server
@@ -29,6 +33,12 @@ type Message struct {
 	Username string `json:"username"`
 	Text     string `json:"text"`
 	Time     string `json:"time"`
+	Seq      int64  `json:"seq,omitempty"`
+}
+
+type AckMessage struct {
+	Type string `json:"type"`
+	Seq  int64  `json:"seq"`
 }
 
 type StatsMessage struct {
@@ -51,6 +61,17 @@ type RoomsMessage struct {
 	Rooms      []string `json:"rooms"`
 }
 
+// chatURL is the server's WebSocket URL for username in room
+func chatURL(username, room string) string {
+	u := url.URL{
+		Scheme:   "ws",
+		Host:     "localhost:8080",
+		Path:     "/ws",
+		RawQuery: fmt.Sprintf("username=%s&room=%s", username, room),
+	}
+	return u.String()
+}
+
 func main() {
 	// Check command line arguments
 	if len(os.Args) < 3 {
@@ -60,25 +81,20 @@ func main() {
 	}
 
 	username := os.Args[1]
+	// Current room: follows /join, so a reconnect rejoins it. Only the
+	// reader goroutine writes it, and main reads it once that has exited.
 	room := os.Args[2]
 
-	// Build WebSocket URL with query parameters
-	u := url.URL{
-		Scheme:   "ws",
-		Host:     "localhost:8080",
-		Path:     "/ws",
-		RawQuery: fmt.Sprintf("username=%s&room=%s", username, room),
-	}
-
 	// Connect to WebSocket server
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	first, _, err := websocket.DefaultDialer.Dial(chatURL(username, room), nil)
 	if err != nil {
 		log.Fatal("Failed to connect:", err)
 	}
-	defer conn.Close()
+	conn := reconnect.NewConn(first)
+	backoff := reconnect.New()
 
 	fmt.Printf("✓ Connected to room '%s' as '%s'\n", room, username)
-	fmt.Println("Commands: /users, /stats, /rooms")
+	fmt.Println("Commands: /users, /stats, /rooms, /join <room>, /kick <username> (admins)")
 	fmt.Println("Type messages to chat (Ctrl+C to exit)")
 	fmt.Println("---")
 
@@ -86,16 +102,18 @@ func main() {
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
-	// Channel to signal when reading is done
-	done := make(chan struct{})
+	// Chat messages sent but not yet acknowledged, by seq
+	var pendingMu sync.Mutex
+	pending := make(map[int64]string)
+	var nextSeq int64
 
-	// Goroutine to read messages from server
-	go func() {
-		defer close(done)
+	// Reads messages from one connection; the read error is sent on done
+	readMessages := func(conn *websocket.Conn, done chan<- error) {
 		for {
 			_, data, err := conn.ReadMessage()
 			if err != nil {
 				log.Println("Connection closed:", err)
+				done <- err
 				return
 			}
 
@@ -125,6 +143,13 @@ func main() {
 				json.Unmarshal(data, &msg)
 				fmt.Printf("[%s] * %s\n", msg.Time, msg.Text)
 
+				// Our own join notice: the server let us in (again), and
+				// after /join it names the new room
+				if msg.Username == username && msg.Text == username+" joined the room" {
+					room = msg.Room
+					backoff.Reset()
+				}
+
 			case MsgUserList:
 				// User list response
 				var msg UserListMessage
@@ -148,6 +173,21 @@ func main() {
 				}
 				fmt.Println()
 
+			case MsgAck:
+				// Server queued our message; acks arrive in order, so any
+				// older pending message lost its message or its ack
+				var msg AckMessage
+				json.Unmarshal(data, &msg)
+				pendingMu.Lock()
+				for seq, text := range pending {
+					if seq < msg.Seq {
+						fmt.Printf("⚠ No ack for message #%d (%q), it may not have been delivered\n", seq, text)
+						delete(pending, seq)
+					}
+				}
+				delete(pending, msg.Seq)
+				pendingMu.Unlock()
+
 			case MsgRooms:
 				// Rooms list response
 				var msg RoomsMessage
@@ -159,7 +199,7 @@ func main() {
 				fmt.Println()
 			}
 		}
-	}()
+	}
 
 	// Read input from user and send to server
 	scanner := bufio.NewScanner(os.Stdin)
@@ -175,6 +215,15 @@ func main() {
 				Text: text,
 			}
 
+			// Number chat messages so the server acknowledges them
+			if !strings.HasPrefix(text, "/") {
+				pendingMu.Lock()
+				nextSeq++
+				msg.Seq = nextSeq
+				pending[msg.Seq] = text
+				pendingMu.Unlock()
+			}
+
 			// Marshal to JSON
 			data, err := json.Marshal(msg)
 			if err != nil {
@@ -182,27 +231,55 @@ func main() {
 				continue
 			}
 
-			// Send message to server
-			err = conn.WriteMessage(websocket.TextMessage, data)
-			if err != nil {
-				log.Println("Write error:", err)
-				return
+			// Send message to server (dropped while reconnecting)
+			if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				fmt.Println("✗ Not connected, message not sent")
 			}
 		}
 	}()
 
-	// Wait for interrupt signal or connection close
-	select {
-	case <-done:
-		fmt.Println("\nServer closed the connection")
-	case <-interrupt:
-		fmt.Println("\nShutting down gracefully...")
-		err := conn.WriteMessage(
-			websocket.CloseMessage,
-			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
-		)
-		if err != nil {
-			log.Println("Write close error:", err)
+	// Report unacknowledged messages on exit
+	defer func() {
+		pendingMu.Lock()
+		if len(pending) > 0 {
+			fmt.Printf("⚠ %d message(s) were never acknowledged\n", len(pending))
+		}
+		pendingMu.Unlock()
+	}()
+
+	for {
+		done := make(chan error, 1)
+		go readMessages(conn.Get(), done)
+
+		// Wait for interrupt signal or connection close
+		select {
+		case err := <-done:
+			// Connection dropped: reconnect unless the server refused us
+			// (e.g. kicked), which a retry wouldn't fix. Right after a
+			// redial a refusal may only mean the server still holds our
+			// half-open old session, so keep retrying then.
+			conn.Get().Close()
+			if websocket.IsCloseError(err, websocket.ClosePolicyViolation) && !backoff.Retrying() {
+				fmt.Println("\nServer closed the connection")
+				return
+			}
+
+			newConn := backoff.Dial(chatURL(username, room), interrupt)
+			if newConn == nil {
+				return
+			}
+			conn.Set(newConn)
+
+		case <-interrupt:
+			fmt.Println("\nShutting down gracefully...")
+			err := conn.WriteMessage(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			)
+			if err != nil {
+				log.Println("Write close error:", err)
+			}
+			return
 		}
 	}
 }
\ No newline at end of file
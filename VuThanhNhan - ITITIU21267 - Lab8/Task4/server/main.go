@@ -1,22 +1,27 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	_ "github.com/mattn/go-sqlite3"
+
+	"websocket-chat/origin"
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
-	},
+	CheckOrigin: origin.Check,
 }
 
 // Message types
@@ -27,15 +32,52 @@ const (
 	MsgStats    = "stats"
 	MsgCommand  = "command"
 	MsgRooms    = "rooms"
+	MsgAck      = "ack"
 )
 
-// Message structure for chat events
+// Longest accepted username or room name, in characters
+const maxNameLength = 32
+
+// validateName trims a username or room name and checks that it is 1 to
+// maxNameLength letters, digits, dashes or underscores. kind ("username",
+// "room") is used in the error message.
+func validateName(kind, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%s is required", kind)
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return "", fmt.Errorf("%s must be at most %d characters", kind, maxNameLength)
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return "", fmt.Errorf("%s may only contain letters, digits, '-' and '_'", kind)
+		}
+	}
+	return name, nil
+}
+
+// Message structure for chat events.
+//
+// Acknowledgements: a client may number its chat messages with "seq"
+// (1, 2, 3, ... per connection). Once such a message is queued for the room,
+// the server replies to the sender only with {"type":"ack","seq":N}. A
+// missing ack means the message or its ack was dropped (e.g. a full Send
+// buffer). Resending a seq that was already acked is not broadcast again,
+// only acked. Messages without seq are broadcast as before and never acked.
 type Message struct {
 	Type     string `json:"type"`
 	Room     string `json:"room"`
 	Username string `json:"username"`
 	Text     string `json:"text"`
 	Time     string `json:"time"`
+	Seq      int64  `json:"seq,omitempty"` // client-side sequence number, sender only
+}
+
+// AckMessage confirms that the sender's message seq was queued for broadcast
+type AckMessage struct {
+	Type string `json:"type"`
+	Seq  int64  `json:"seq"`
 }
 
 // StatsMessage structure for statistics
@@ -68,6 +110,48 @@ type Client struct {
 	Conn     *websocket.Conn
 	Room     string
 	Send     chan []byte
+
+	// Close frame sent by writePump once Send is closed (0 = normal)
+	closeCode int
+	closeText string
+
+	// Highest acknowledged seq, only touched by readPump
+	lastSeq int64
+
+	// Guards closing Send, so sends racing with a kick are dropped
+	// instead of panicking
+	sendMu     sync.Mutex
+	sendClosed bool
+}
+
+// send queues data for writePump without blocking
+func (c *Client) send(data []byte) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.sendClosed {
+		return
+	}
+
+	select {
+	case c.Send <- data:
+	default:
+		log.Printf("Send buffer full for %s, dropping message", c.Username)
+	}
+}
+
+// closeSend closes Send once; writePump then sends a close frame with
+// code and text (code 0 sends an empty close frame)
+func (c *Client) closeSend(code int, text string) {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if c.sendClosed {
+		return
+	}
+	c.sendClosed = true
+	c.closeCode = code
+	c.closeText = text
+	close(c.Send)
 }
 
 // Room represents a chat room with multiple clients
@@ -77,12 +161,126 @@ type Room struct {
 	mu      sync.RWMutex
 }
 
+// StoredMessage is a chat message as saved in the database
+type StoredMessage struct {
+	ID        int64     `json:"id"`
+	Room      string    `json:"room"`
+	Username  string    `json:"username"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MessageStore logs chat messages to SQLite. Inserts go through a buffered
+// channel to a single writer goroutine, so readPumps never block on the
+// database and never race each other into "database is locked".
+type MessageStore struct {
+	db    *sql.DB
+	queue chan StoredMessage
+	done  chan struct{}
+}
+
+// openMessageStore opens (or creates) the database and starts the writer
+func openMessageStore(path string) (*MessageStore, error) {
+	// _busy_timeout lets the REST reads wait for the writer instead of failing
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		room TEXT NOT NULL,
+		username TEXT NOT NULL,
+		text TEXT NOT NULL,
+		created_at INTEGER NOT NULL -- unix milliseconds
+	);
+	CREATE INDEX IF NOT EXISTS idx_messages_room_time ON messages(room, created_at);`
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create messages table: %w", err)
+	}
+
+	s := &MessageStore{
+		db:    db,
+		queue: make(chan StoredMessage, 1024),
+		done:  make(chan struct{}),
+	}
+	go s.writer()
+	return s, nil
+}
+
+// writer is the only goroutine that inserts into the database
+func (s *MessageStore) writer() {
+	defer close(s.done)
+	for m := range s.queue {
+		_, err := s.db.Exec(
+			"INSERT INTO messages (room, username, text, created_at) VALUES (?, ?, ?, ?)",
+			m.Room, m.Username, m.Text, m.CreatedAt.UnixMilli())
+		if err != nil {
+			log.Printf("Failed to persist message: %v", err)
+		}
+	}
+}
+
+// Save queues a chat message for the writer (dropped if the queue is full)
+func (s *MessageStore) Save(msg Message) {
+	m := StoredMessage{
+		Room:      msg.Room,
+		Username:  msg.Username,
+		Text:      msg.Text,
+		CreatedAt: time.Now(),
+	}
+	select {
+	case s.queue <- m:
+	default:
+		log.Printf("Persist queue full, dropping message from %s", msg.Username)
+	}
+}
+
+// Messages returns up to limit messages of a room newer than since, oldest first
+func (s *MessageStore) Messages(room string, since time.Time, limit int) ([]StoredMessage, error) {
+	rows, err := s.db.Query(`
+		SELECT id, room, username, text, created_at FROM messages
+		WHERE room = ? AND created_at > ?
+		ORDER BY created_at, id
+		LIMIT ?`, room, since.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []StoredMessage{}
+	for rows.Next() {
+		var m StoredMessage
+		var createdAt int64
+		if err := rows.Scan(&m.ID, &m.Room, &m.Username, &m.Text, &createdAt); err != nil {
+			return nil, err
+		}
+		m.CreatedAt = time.UnixMilli(createdAt)
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// Close flushes queued messages and closes the database
+func (s *MessageStore) Close() error {
+	close(s.queue)
+	<-s.done
+	return s.db.Close()
+}
+
 // Hub manages all rooms and clients
 type Hub struct {
 	rooms      map[string]*Room
 	register   chan *Client
 	unregister chan *Client
+	store      *MessageStore   // nil unless started with -persist
+	admins     map[string]bool // usernames allowed to /kick
 	mu         sync.RWMutex
+
+	statsChanged chan struct{} // user counts changed, stats push pending
+	quit         chan struct{} // closed by stop to end background goroutines
 }
 
 // Create new hub instance
@@ -91,6 +289,63 @@ func newHub() *Hub {
 		rooms:      make(map[string]*Room),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+		admins:     make(map[string]bool),
+
+		statsChanged: make(chan struct{}, 1),
+		quit:         make(chan struct{}),
+	}
+}
+
+// Minimum time between two automatic stats pushes
+const statsPushInterval = time.Second
+
+// stop ends the hub's background goroutines (the stats pusher)
+func (h *Hub) stop() {
+	close(h.quit)
+}
+
+// notifyStatsChanged marks the stats as dirty without blocking; several
+// changes before the next push collapse into one
+func (h *Hub) notifyStatsChanged() {
+	select {
+	case h.statsChanged <- struct{}{}:
+	default:
+	}
+}
+
+// pushStats broadcasts stats to everyone when user counts change, at most
+// once per statsPushInterval so a burst of joins sends a single update
+func (h *Hub) pushStats() {
+	var lastPush time.Time
+	var timer *time.Timer
+	var timerC <-chan time.Time // nil (blocks forever) while no push is scheduled
+
+	for {
+		select {
+		case <-h.statsChanged:
+			if timerC != nil {
+				continue // a push is already scheduled and will include this change
+			}
+			wait := statsPushInterval - time.Since(lastPush)
+			if wait <= 0 {
+				h.broadcastStats()
+				lastPush = time.Now()
+				continue
+			}
+			timer = time.NewTimer(wait)
+			timerC = timer.C
+
+		case <-timerC:
+			timerC = nil
+			h.broadcastStats()
+			lastPush = time.Now()
+
+		case <-h.quit:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
 	}
 }
 
@@ -144,6 +399,7 @@ func (h *Hub) addClientToRoom(client *Client) {
 
 	// Send updated user count to room
 	h.sendUserCountUpdate(client.Room)
+	h.notifyStatsChanged()
 }
 
 // removeClientFromRoom removes a client from their room
@@ -158,13 +414,22 @@ func (h *Hub) removeClientFromRoom(client *Client) {
 
 	// Remove client from room
 	room.mu.Lock()
-	if _, ok := room.Clients[client]; ok {
+	_, joined := room.Clients[client]
+	if joined {
 		delete(room.Clients, client)
-		close(client.Send)
+		client.closeSend(0, "")
 	}
 	clientCount := len(room.Clients)
 	room.mu.Unlock()
 
+	// Kicked clients were already removed and announced by kickUser
+	client.sendMu.Lock()
+	kicked := client.closeCode == websocket.ClosePolicyViolation
+	client.sendMu.Unlock()
+	if !joined && kicked {
+		return
+	}
+
 	log.Printf("Client %s left room %s (Remaining: %d)",
 		client.Username, client.Room, clientCount)
 
@@ -181,13 +446,21 @@ func (h *Hub) removeClientFromRoom(client *Client) {
 	// Send updated user count to room
 	h.sendUserCountUpdate(client.Room)
 
-	// Delete room if empty
+	// Delete room if empty. A /join on another client's readPump may have
+	// moved someone in since room.mu was released, so re-check under the
+	// hub lock (switchRoom holds it while adding).
 	if clientCount == 0 {
 		h.mu.Lock()
-		delete(h.rooms, client.Room)
+		room.mu.RLock()
+		empty := len(room.Clients) == 0
+		room.mu.RUnlock()
+		if empty && h.rooms[client.Room] == room {
+			delete(h.rooms, client.Room)
+			log.Printf("Deleted empty room: %s", client.Room)
+		}
 		h.mu.Unlock()
-		log.Printf("Deleted empty room: %s", client.Room)
 	}
+	h.notifyStatsChanged()
 }
 
 // broadcastToRoom sends message to all clients in specified room
@@ -215,7 +488,7 @@ func (h *Hub) broadcastToRoom(roomName string, msg Message) {
 		select {
 		case client.Send <- data:
 		default:
-			close(client.Send)
+			client.closeSend(0, "")
 			delete(room.Clients, client)
 		}
 	}
@@ -246,9 +519,11 @@ func (h *Hub) sendUserCountUpdate(roomName string) {
 	h.broadcastToRoom(roomName, msg)
 }
 
-// handleCommand processes commands like /users, /stats, /rooms
-func (h *Hub) handleCommand(client *Client, cmd string) {
-	cmd = strings.TrimSpace(strings.ToLower(cmd))
+// handleCommand processes commands like /users, /stats, /rooms, /kick
+func (h *Hub) handleCommand(client *Client, text string) {
+	// Only the command name is case-insensitive, arguments are kept as typed
+	args := strings.Fields(text)
+	cmd := strings.ToLower(args[0])
 
 	switch cmd {
 	case "/users":
@@ -263,17 +538,192 @@ func (h *Hub) handleCommand(client *Client, cmd string) {
 		// Send list of all rooms
 		h.sendRooms(client)
 
+	case "/kick":
+		// Admin only: disconnect a user from the admin's room
+		if len(args) != 2 {
+			h.sendSystemMessage(client, "Usage: /kick <username>")
+			return
+		}
+		h.kickUser(client, args[1])
+
+	case "/join":
+		// Switch rooms without reconnecting
+		if len(args) != 2 {
+			h.sendSystemMessage(client, "Usage: /join <room>")
+			return
+		}
+		room, err := validateName("room", args[1])
+		if err != nil {
+			h.sendSystemMessage(client, err.Error())
+			return
+		}
+		h.switchRoom(client, room)
+
 	default:
 		// Unknown command
-		msg := Message{
-			Type: MsgSystem,
-			Room: client.Room,
-			Text: fmt.Sprintf("Unknown command: %s. Available: /users, /stats, /rooms", cmd),
-			Time: time.Now().Format("15:04:05"),
+		h.sendSystemMessage(client, fmt.Sprintf("Unknown command: %s. Available: /users, /stats, /rooms, /join, /kick", cmd))
+	}
+}
+
+// sendSystemMessage sends a system message to one client
+func (h *Hub) sendSystemMessage(client *Client, text string) {
+	msg := Message{
+		Type: MsgSystem,
+		Room: client.Room,
+		Text: text,
+		Time: time.Now().Format("15:04:05"),
+	}
+	data, _ := json.Marshal(msg)
+	client.send(data)
+}
+
+// switchRoom moves a client from its current room to newRoom. Both rooms
+// are locked together under the hub lock, so the client is never in two
+// rooms (or none) as seen by other goroutines.
+func (h *Hub) switchRoom(client *Client, newRoom string) {
+	oldRoom := client.Room
+	if newRoom == oldRoom {
+		h.sendSystemMessage(client, fmt.Sprintf("You are already in room %s", newRoom))
+		return
+	}
+
+	h.mu.Lock()
+	from, exists := h.rooms[oldRoom]
+	if !exists {
+		h.mu.Unlock()
+		return
+	}
+
+	// Get existing room or create new one
+	to, exists := h.rooms[newRoom]
+	if !exists {
+		to = &Room{
+			Name:    newRoom,
+			Clients: make(map[*Client]bool),
+		}
+		h.rooms[newRoom] = to
+		log.Printf("Created new room: %s", newRoom)
+	}
+
+	from.mu.Lock()
+	to.mu.Lock()
+	_, inRoom := from.Clients[client]
+	if inRoom {
+		delete(from.Clients, client)
+		to.Clients[client] = true
+		client.Room = newRoom
+	}
+	remaining := len(from.Clients)
+	total := len(to.Clients)
+	to.mu.Unlock()
+	from.mu.Unlock()
+
+	if inRoom && remaining == 0 {
+		delete(h.rooms, oldRoom)
+		log.Printf("Deleted empty room: %s", oldRoom)
+	}
+	if !inRoom && total == 0 {
+		delete(h.rooms, newRoom) // don't leave behind the room we just created
+	}
+	h.mu.Unlock()
+
+	if !inRoom {
+		return // kicked or disconnected meanwhile
+	}
+
+	log.Printf("Client %s moved from room %s to %s (Total: %d)",
+		client.Username, oldRoom, newRoom, total)
+
+	// Leave notification and user count for the old room
+	if remaining > 0 {
+		h.broadcastToRoom(oldRoom, Message{
+			Type:     MsgSystem,
+			Room:     oldRoom,
+			Username: client.Username,
+			Text:     fmt.Sprintf("%s left the room", client.Username),
+			Time:     time.Now().Format("15:04:05"),
+		})
+		h.sendUserCountUpdate(oldRoom)
+	}
+
+	// Join notification and user count for the new room
+	h.broadcastToRoom(newRoom, Message{
+		Type:     MsgSystem,
+		Room:     newRoom,
+		Username: client.Username,
+		Text:     fmt.Sprintf("%s joined the room", client.Username),
+		Time:     time.Now().Format("15:04:05"),
+	})
+	h.sendUserCountUpdate(newRoom)
+
+	h.sendSystemMessage(client, fmt.Sprintf("You are now in room %s", newRoom))
+	h.sendUserList(client)
+	h.notifyStatsChanged()
+}
+
+// kickUser removes every client named target from the admin's room, tells
+// them why and closes their connections
+func (h *Hub) kickUser(admin *Client, target string) {
+	if !h.admins[admin.Username] {
+		h.sendSystemMessage(admin, "Permission denied: only admins can use /kick")
+		return
+	}
+	if target == admin.Username {
+		h.sendSystemMessage(admin, "You cannot kick yourself")
+		return
+	}
+
+	h.mu.RLock()
+	room, exists := h.rooms[admin.Room]
+	h.mu.RUnlock()
+
+	if !exists {
+		return
+	}
+
+	// Remove targets under the lock so they can't be kicked twice or
+	// receive room broadcasts after the kick
+	var kicked []*Client
+	room.mu.Lock()
+	for c := range room.Clients {
+		if c.Username == target {
+			delete(room.Clients, c)
+			kicked = append(kicked, c)
 		}
-		data, _ := json.Marshal(msg)
-		client.Send <- data
 	}
+	room.mu.Unlock()
+
+	if len(kicked) == 0 {
+		h.sendSystemMessage(admin, fmt.Sprintf("User %s is not in this room", target))
+		return
+	}
+
+	for _, c := range kicked {
+		h.sendSystemMessage(c, fmt.Sprintf("You were kicked from %s by %s", admin.Room, admin.Username))
+		c.closeSend(websocket.ClosePolicyViolation, "kicked by admin") // writePump flushes the message first
+	}
+	log.Printf("Admin %s kicked %s from room %s", admin.Username, target, admin.Room)
+
+	h.broadcastToRoom(admin.Room, Message{
+		Type:     MsgSystem,
+		Room:     admin.Room,
+		Username: target,
+		Text:     fmt.Sprintf("%s was kicked by %s", target, admin.Username),
+		Time:     time.Now().Format("15:04:05"),
+	})
+	h.sendUserCountUpdate(admin.Room)
+	h.notifyStatsChanged()
+}
+
+// sendAck tells a client its message seq was queued for broadcast
+func (h *Hub) sendAck(client *Client, seq int64) {
+	data, err := json.Marshal(AckMessage{Type: MsgAck, Seq: seq})
+	if err != nil {
+		log.Printf("Failed to marshal ack: %v", err)
+		return
+	}
+
+	client.send(data)
 }
 
 // sendUserList sends list of users in client's room
@@ -309,11 +759,42 @@ func (h *Hub) sendUserList(client *Client) {
 		return
 	}
 
-	client.Send <- data
+	client.send(data)
 }
 
 // sendStats sends global statistics to client
 func (h *Hub) sendStats(client *Client) {
+	data, err := json.Marshal(h.collectStats())
+	if err != nil {
+		log.Printf("Failed to marshal stats: %v", err)
+		return
+	}
+
+	client.send(data)
+}
+
+// broadcastStats sends global statistics to every connected client
+func (h *Hub) broadcastStats() {
+	data, err := json.Marshal(h.collectStats())
+	if err != nil {
+		log.Printf("Failed to marshal stats: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, room := range h.rooms {
+		room.mu.RLock()
+		for client := range room.Clients {
+			client.send(data)
+		}
+		room.mu.RUnlock()
+	}
+}
+
+// collectStats counts users per room
+func (h *Hub) collectStats() StatsMessage {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
@@ -331,21 +812,12 @@ func (h *Hub) sendStats(client *Client) {
 	}
 
 	// Create stats message
-	statsMsg := StatsMessage{
+	return StatsMessage{
 		Type:        MsgStats,
 		TotalUsers:  totalUsers,
 		TotalRooms:  len(h.rooms),
 		RoomDetails: roomDetails,
 	}
-
-	// Marshal and send
-	data, err := json.Marshal(statsMsg)
-	if err != nil {
-		log.Printf("Failed to marshal stats: %v", err)
-		return
-	}
-
-	client.Send <- data
 }
 
 // sendRooms sends list of all rooms to client
@@ -373,7 +845,7 @@ func (h *Hub) sendRooms(client *Client) {
 		return
 	}
 
-	client.Send <- data
+	client.send(data)
 }
 
 // readPump reads messages from WebSocket connection
@@ -417,8 +889,24 @@ func (c *Client) readPump(hub *Hub) {
 		msg.Type = MsgChat
 		msg.Time = time.Now().Format("15:04:05")
 
+		// A seq we've already acked is a resend: ack again, don't repeat it
+		seq := msg.Seq
+		msg.Seq = 0
+		if seq > 0 && seq <= c.lastSeq {
+			hub.sendAck(c, seq)
+			continue
+		}
+
 		// Broadcast to room
 		hub.broadcastToRoom(c.Room, msg)
+		if hub.store != nil {
+			hub.store.Save(msg)
+		}
+
+		if seq > 0 {
+			c.lastSeq = seq
+			hub.sendAck(c, seq)
+		}
 	}
 }
 
@@ -436,7 +924,11 @@ func (c *Client) writePump() {
 			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 
 			if !ok {
-				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				closeMsg := []byte{}
+				if c.closeCode != 0 {
+					closeMsg = websocket.FormatCloseMessage(c.closeCode, c.closeText)
+				}
+				c.Conn.WriteMessage(websocket.CloseMessage, closeMsg)
 				return
 			}
 
@@ -458,11 +950,14 @@ var hub = newHub()
 
 // handleWebSocket handles WebSocket connection upgrades
 func handleWebSocket(c *gin.Context) {
-	username := c.Query("username")
-	room := c.Query("room")
-
-	if username == "" || room == "" {
-		c.JSON(400, gin.H{"error": "username and room required"})
+	username, err := validateName("username", c.Query("username"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	room, err := validateName("room", c.Query("room"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -480,22 +975,85 @@ func handleWebSocket(c *gin.Context) {
 		Send:     make(chan []byte, 256),
 	}
 
-	// Start goroutines BEFORE registering to hub
+	// Order matters: writePump drains Send before the join notification is
+	// queued, and readPump (whose exit unregisters) only starts once the
+	// client is registered
 	go client.writePump()
+	hub.register <- client // sends join notification
 	go client.readPump(hub)
-	
-	// Small delay to ensure goroutines are ready
-	time.Sleep(10 * time.Millisecond)
-	
-	// Register client to hub (this will send join notification)
-	hub.register <- client
+}
+
+// handleMessages serves GET /messages?room=lobby&since=2024-01-02T15:04:05Z&limit=100
+func handleMessages(c *gin.Context) {
+	if hub.store == nil {
+		c.JSON(503, gin.H{"error": "message persistence is disabled (start the server with -persist)"})
+		return
+	}
+
+	room := c.Query("room")
+	if room == "" {
+		c.JSON(400, gin.H{"error": "room required"})
+		return
+	}
+
+	var since time.Time // zero: from the beginning
+	if s := c.Query("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "since must be an RFC3339 timestamp"})
+			return
+		}
+		since = t
+	}
+
+	limit := 100
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 1000 {
+			c.JSON(400, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		limit = n
+	}
+
+	messages, err := hub.store.Messages(room, since, limit)
+	if err != nil {
+		log.Printf("Failed to query messages: %v", err)
+		c.JSON(500, gin.H{"error": "failed to query messages"})
+		return
+	}
+	c.JSON(200, messages)
 }
 
 func main() {
+	persist := flag.Bool("persist", false, "log chat messages to SQLite")
+	dbPath := flag.String("db", "chat.db", "SQLite database file used with -persist")
+	admins := flag.String("admins", "", "comma-separated usernames allowed to /kick")
+	flag.Parse()
+
+	for _, name := range strings.Split(*admins, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			hub.admins[name] = true
+		}
+	}
+
+	if *persist {
+		store, err := openMessageStore(*dbPath)
+		if err != nil {
+			log.Fatalf("Failed to open message store: %v", err)
+		}
+		defer store.Close()
+		hub.store = store
+		log.Printf("Persisting chat messages to %s", *dbPath)
+	}
+
 	go hub.run()
+	go hub.pushStats()
+	defer hub.stop()
 
 	router := gin.Default()
 	router.GET("/ws", handleWebSocket)
+	router.GET("/messages", handleMessages)
 
 	fmt.Println("🚀 Chat Server with Statistics on :8080")
 
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"plain", "alice", "alice", ""},
+		{"digits dash underscore", "room_42-b", "room_42-b", ""},
+		{"trimmed", "  bob \t", "bob", ""},
+		{"unicode letters", "Nhân", "Nhân", ""},
+		{"exactly max length", strings.Repeat("a", maxNameLength), strings.Repeat("a", maxNameLength), ""},
+		{"max length in runes, not bytes", strings.Repeat("ă", maxNameLength), strings.Repeat("ă", maxNameLength), ""},
+		{"empty", "", "", "username is required"},
+		{"only spaces", "   ", "", "username is required"},
+		{"too long", strings.Repeat("a", maxNameLength+1), "", "at most 32 characters"},
+		{"10KB", strings.Repeat("x", 10240), "", "at most 32 characters"},
+		{"inner space", "bad name", "", "may only contain"},
+		{"control character", "bad\x00name", "", "may only contain"},
+		{"zero-width space", "ali\u200bce", "", "may only contain"},
+		{"markup", "<script>", "", "may only contain"},
+		{"slash", "a/b", "", "may only contain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateName("username", tt.in)
+			if tt.wantErr == "" {
+				if err != nil || got != tt.want {
+					t.Errorf("validateName(%q) = %q, %v; want %q, nil", tt.in, got, err, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateName(%q) error = %v, want one containing %q", tt.in, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateNameUsesKind(t *testing.T) {
+	_, err := validateName("room", "")
+	if err == nil || err.Error() != "room is required" {
+		t.Errorf("error = %v, want \"room is required\"", err)
+	}
+}
+
+func TestHandleWebSocketRejectsBadNames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/ws", handleWebSocket)
+
+	for _, query := range []string{
+		"username=&room=general",
+		"username=alice&room=",
+		"username=al%20ice&room=general",
+		"username=alice&room=" + strings.Repeat("r", maxNameLength+1),
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func newTestClient(username, room string) *Client {
+	return &Client{ID: username, Username: username, Room: room, Send: make(chan []byte, 256)}
+}
+
+func TestLeaveKeepsRoomJoinedMeanwhile(t *testing.T) {
+	h := newHub()
+	leaver := newTestClient("leaver", "x")
+	h.addClientToRoom(leaver)
+	h.mu.RLock()
+	room := h.rooms["x"]
+	h.mu.RUnlock()
+
+	// Holding a read lock stalls removeClientFromRoom at its h.mu.Lock,
+	// after it has emptied the room
+	h.mu.RLock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.removeClientFromRoom(leaver)
+	}()
+	for {
+		room.mu.RLock()
+		_, in := room.Clients[leaver]
+		room.mu.RUnlock()
+		if !in {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A /join lands in that gap
+	joiner := newTestClient("joiner", "x")
+	room.mu.Lock()
+	room.Clients[joiner] = true
+	room.mu.Unlock()
+	h.mu.RUnlock()
+	<-done
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.rooms["x"] != room {
+		t.Fatal("room x deleted while joiner is in it")
+	}
+}
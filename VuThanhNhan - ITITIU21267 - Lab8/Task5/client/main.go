@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -39,6 +40,19 @@ type Notification struct {
 	Target    string    `json:"target"`
 }
 
+// Notification severities, lowest first
+var severityLevels = map[string]int32{
+	"info":    0,
+	"success": 1,
+	"warning": 2,
+	"error":   3,
+}
+
+// severity ranks a notification type (unknown types count as info)
+func severity(notifType string) int32 {
+	return severityLevels[strings.ToLower(notifType)]
+}
+
 func main() {
 	if len(os.Args) < 3 {
 		fmt.Println("Usage: go run notification_client.go <username> <room>")
@@ -63,6 +77,7 @@ func main() {
 	defer conn.Close()
 
 	fmt.Printf("Connected to room '%s' as '%s'\n", room, username)
+	fmt.Println("Type /filter <info|success|warning|error> to hide less severe notifications")
 	fmt.Println("---")
 
 	// Interrupt signal
@@ -72,6 +87,10 @@ func main() {
 	// Done channel
 	done := make(chan struct{})
 
+	// Minimum severity to display, set by /filter from the input goroutine
+	// and read by the reader goroutine
+	var minSeverity atomic.Int32
+
 	// Read messages
 	go func() {
 		defer close(done)
@@ -84,7 +103,9 @@ func main() {
 			// Try parse as notification first
 			var notif Notification
 			if err := json.Unmarshal(data, &notif); err == nil && notif.ID != "" {
-				displayNotification(notif)
+				if severity(notif.Type) >= minSeverity.Load() {
+					displayNotification(notif)
+				}
 				continue
 			}
 
@@ -112,6 +133,23 @@ func main() {
 				continue
 			}
 
+			// /filter <level> is handled locally, not sent to the server
+			if fields := strings.Fields(text); fields[0] == "/filter" {
+				if len(fields) != 2 {
+					fmt.Println("Usage: /filter info|success|warning|error")
+					continue
+				}
+				level := strings.ToLower(fields[1])
+				rank, ok := severityLevels[level]
+				if !ok {
+					fmt.Printf("Unknown level %q (use info, success, warning or error)\n", fields[1])
+					continue
+				}
+				minSeverity.Store(rank)
+				fmt.Printf("* Showing %s notifications and above\n", level)
+				continue
+			}
+
 			msg := Message{Text: text}
 			data, _ := json.Marshal(msg)
 			conn.WriteMessage(websocket.TextMessage, data)
@@ -1,22 +1,25 @@
 package main
 
 import (
+	"container/heap"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
-	"net/http"
+	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+
+	"websocket-chat/origin"
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for development
-	},
+	CheckOrigin: origin.Check,
 }
 
 // Message types
@@ -38,11 +41,130 @@ type Message struct {
 // Notification structure for admin notifications
 type Notification struct {
 	ID        string    `json:"id"`
-	Type      string    `json:"type"`      // "info", "warning", "error", "success"
-	Title     string    `json:"title"`     // Notification title
-	Message   string    `json:"message"`   // Notification content
-	Timestamp time.Time `json:"timestamp"` // When notification was created
-	Target    string    `json:"target"`    // "all", "room:name", "user:username"
+	Type      string    `json:"type"`             // "info", "warning", "error", "success"
+	Title     string    `json:"title"`            // Notification title
+	Message   string    `json:"message"`          // Notification content
+	Timestamp time.Time `json:"timestamp"`        // When notification was created
+	Target    string    `json:"target"`           // "all", "room:name", "user:username"
+	SendAt    time.Time `json:"send_at,omitzero"` // Optional: deliver later instead of now
+}
+
+// DeliveryResult counts how many clients a notification reached
+type DeliveryResult struct {
+	Delivered int `json:"delivered"`
+	Skipped   int `json:"skipped"` // send buffer full
+}
+
+// notificationRequest carries a notification to the hub; result (if set)
+// receives the delivery counts once it has been routed
+type notificationRequest struct {
+	notif  *Notification
+	result chan DeliveryResult
+}
+
+// notificationQueue is a min-heap of notifications ordered by SendAt
+type notificationQueue []*Notification
+
+func (q notificationQueue) Len() int           { return len(q) }
+func (q notificationQueue) Less(i, j int) bool { return q[i].SendAt.Before(q[j].SendAt) }
+func (q notificationQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *notificationQueue) Push(x any)        { *q = append(*q, x.(*Notification)) }
+func (q *notificationQueue) Pop() any {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// Scheduler holds notifications until their SendAt and then delivers them
+type Scheduler struct {
+	mu      sync.Mutex
+	queue   notificationQueue
+	wake    chan struct{} // pokes run when the earliest notification changes
+	deliver func(*Notification)
+}
+
+// newScheduler creates a scheduler; call run to start delivering
+func newScheduler(deliver func(*Notification)) *Scheduler {
+	return &Scheduler{
+		wake:    make(chan struct{}, 1),
+		deliver: deliver,
+	}
+}
+
+// Add schedules a notification for notif.SendAt
+func (s *Scheduler) Add(notif *Notification) {
+	s.mu.Lock()
+	heap.Push(&s.queue, notif)
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Cancel removes a pending notification, reporting whether it was found
+func (s *Scheduler) Cancel(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, n := range s.queue {
+		if n.ID == id {
+			heap.Remove(&s.queue, i)
+			s.poke()
+			return true
+		}
+	}
+	return false
+}
+
+// Pending returns the scheduled notifications, earliest first
+func (s *Scheduler) Pending() []Notification {
+	s.mu.Lock()
+	pending := make([]Notification, 0, len(s.queue))
+	for _, n := range s.queue {
+		pending = append(pending, *n)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].SendAt.Before(pending[j].SendAt) })
+	return pending
+}
+
+func (s *Scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sleeps until the earliest notification is due, delivers everything
+// that is due, and starts over when the queue changes
+func (s *Scheduler) run() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		var due []*Notification
+		wait := time.Hour // nothing scheduled: just wait for a poke
+
+		s.mu.Lock()
+		now := time.Now()
+		for len(s.queue) > 0 && !s.queue[0].SendAt.After(now) {
+			due = append(due, heap.Pop(&s.queue).(*Notification))
+		}
+		if len(s.queue) > 0 {
+			wait = s.queue[0].SendAt.Sub(now)
+		}
+		s.mu.Unlock()
+
+		for _, n := range due {
+			s.deliver(n)
+		}
+
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		}
+	}
 }
 
 // Client represents a connected user
@@ -56,9 +178,10 @@ type Client struct {
 
 // Room represents a chat room with multiple clients
 type Room struct {
-	Name    string
-	Clients map[*Client]bool
-	mu      sync.RWMutex
+	Name      string
+	Clients   map[*Client]bool
+	CreatedAt time.Time // rooms are recreated after being emptied
+	mu        sync.RWMutex
 }
 
 // Hub manages all rooms and clients
@@ -66,19 +189,95 @@ type Hub struct {
 	rooms              map[string]*Room
 	register           chan *Client
 	unregister         chan *Client
-	notificationChan   chan *Notification
-	notificationHistory []Notification // Last 50 notifications
+	notificationChan   chan notificationRequest
+	notificationHistory []Notification // Last historyLimit notifications
 	mu                 sync.RWMutex
+
+	historyFile string      // JSON file the history is saved to ("" = memory only)
+	saveTimer   *time.Timer // pending debounced save, guarded by mu
+	saveMu      sync.Mutex  // serializes file writes
 }
 
-// Create new hub instance
-func newHub() *Hub {
-	return &Hub{
+// Notification history kept in memory and on disk
+const (
+	historyLimit     = 50
+	historySaveDelay = time.Second // debounce: one write per burst of notifications
+)
+
+// Create new hub instance, loading notification history from historyFile
+// ("" disables persistence)
+func newHub(historyFile string) *Hub {
+	h := &Hub{
 		rooms:               make(map[string]*Room),
 		register:            make(chan *Client),
 		unregister:          make(chan *Client),
-		notificationChan:    make(chan *Notification, 100),
-		notificationHistory: make([]Notification, 0, 50),
+		notificationChan:    make(chan notificationRequest, 100),
+		notificationHistory: make([]Notification, 0, historyLimit),
+		historyFile:         historyFile,
+	}
+	if historyFile != "" {
+		h.notificationHistory = loadHistory(historyFile)
+	}
+	return h
+}
+
+// loadHistory reads saved notifications, starting empty if the file is
+// missing or unreadable
+func loadHistory(path string) []Notification {
+	history := make([]Notification, 0, historyLimit)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Failed to read notification history: %v", err)
+		}
+		return history
+	}
+
+	if err := json.Unmarshal(data, &history); err != nil {
+		log.Printf("Ignoring corrupt notification history %s: %v", path, err)
+		return make([]Notification, 0, historyLimit)
+	}
+	if len(history) > historyLimit {
+		history = history[len(history)-historyLimit:]
+	}
+
+	log.Printf("Loaded %d notifications from %s", len(history), path)
+	return history
+}
+
+// scheduleHistorySave writes the history to disk after historySaveDelay,
+// unless a save is already pending. Caller must hold h.mu.
+func (h *Hub) scheduleHistorySave() {
+	if h.historyFile == "" || h.saveTimer != nil {
+		return
+	}
+	h.saveTimer = time.AfterFunc(historySaveDelay, h.saveHistory)
+}
+
+// saveHistory writes a snapshot of the history to a temp file and renames
+// it over historyFile, so a crash never leaves a half-written file
+func (h *Hub) saveHistory() {
+	h.mu.Lock()
+	h.saveTimer = nil
+	data, err := json.MarshalIndent(h.notificationHistory, "", "  ")
+	h.mu.Unlock()
+	if err != nil {
+		log.Printf("Failed to marshal notification history: %v", err)
+		return
+	}
+
+	// A slow write may overlap the next debounced save
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+
+	tmp := h.historyFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Printf("Failed to save notification history: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, h.historyFile); err != nil {
+		log.Printf("Failed to save notification history: %v", err)
 	}
 }
 
@@ -94,11 +293,14 @@ func (h *Hub) run() {
 		case client := <-h.unregister:
 			h.removeClientFromRoom(client)
 
-		case notif := <-h.notificationChan:
-			// Add to history (keep last 50)
-			h.addToHistory(notif)
-			// Route notification to target
-			h.routeNotification(notif)
+		case req := <-h.notificationChan:
+			// Add to history (keep last historyLimit)
+			h.addToHistory(req.notif)
+			// Route notification to target and report back
+			result := h.routeNotification(req.notif)
+			if req.result != nil {
+				req.result <- result
+			}
 		}
 	}
 }
@@ -111,8 +313,9 @@ func (h *Hub) addClientToRoom(client *Client) {
 	room, exists := h.rooms[client.Room]
 	if !exists {
 		room = &Room{
-			Name:    client.Room,
-			Clients: make(map[*Client]bool),
+			Name:      client.Room,
+			Clients:   make(map[*Client]bool),
+			CreatedAt: time.Now(),
 		}
 		h.rooms[client.Room] = room
 		log.Printf("Created new room: %s", client.Room)
@@ -210,17 +413,18 @@ func (h *Hub) broadcastToRoom(roomName string, msg Message) {
 	}
 }
 
-// addToHistory adds notification to history (keep last 50)
+// addToHistory adds notification to history (keep last historyLimit)
 func (h *Hub) addToHistory(notif *Notification) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	h.notificationHistory = append(h.notificationHistory, *notif)
 
-	// Keep only last 50 notifications
-	if len(h.notificationHistory) > 50 {
-		h.notificationHistory = h.notificationHistory[len(h.notificationHistory)-50:]
+	// Keep only last historyLimit notifications
+	if len(h.notificationHistory) > historyLimit {
+		h.notificationHistory = h.notificationHistory[len(h.notificationHistory)-historyLimit:]
 	}
+	h.scheduleHistorySave()
 
 	log.Printf("Added notification to history: %s (Total: %d)", notif.ID, len(h.notificationHistory))
 }
@@ -230,10 +434,20 @@ func (h *Hub) sendNotificationHistory(client *Client) {
 	h.mu.RLock()
 	history := make([]Notification, len(h.notificationHistory))
 	copy(history, h.notificationHistory)
+	var roomCreated time.Time
+	if room, exists := h.rooms[client.Room]; exists {
+		roomCreated = room.CreatedAt
+	}
 	h.mu.RUnlock()
 
 	// Send each notification to client
 	for _, notif := range history {
+		// A room notification sent before this room existed reached
+		// nobody live, so it isn't replayed either
+		if kind, _, _ := parseTarget(notif.Target); kind == "room" && notif.deliveredAt().Before(roomCreated) {
+			continue
+		}
+
 		// Only send relevant notifications
 		if h.shouldReceiveNotification(client, &notif) {
 			data, _ := json.Marshal(notif)
@@ -248,73 +462,101 @@ func (h *Hub) sendNotificationHistory(client *Client) {
 	log.Printf("Sent %d historical notifications to %s", len(history), client.Username)
 }
 
-// shouldReceiveNotification checks if client should receive notification
-func (h *Hub) shouldReceiveNotification(client *Client, notif *Notification) bool {
-	// Parse target
-	if notif.Target == "all" {
-		return true
+// parseTarget splits a notification target into its kind ("all", "room",
+// "user") and name. Anything else, including "room:" with no name, is
+// malformed and reaches nobody.
+func parseTarget(target string) (kind, name string, ok bool) {
+	if target == "all" {
+		return "all", "", true
+	}
+	kind, name, found := strings.Cut(target, ":")
+	if !found || name == "" || (kind != "room" && kind != "user") {
+		return "", "", false
 	}
+	return kind, name, true
+}
 
-	if strings.HasPrefix(notif.Target, "room:") {
-		roomName := strings.TrimPrefix(notif.Target, "room:")
-		return client.Room == roomName
+// deliveredAt is when a notification was routed: now-or-creation for
+// immediate ones, SendAt for scheduled ones
+func (n *Notification) deliveredAt() time.Time {
+	if n.SendAt.After(n.Timestamp) {
+		return n.SendAt
 	}
+	return n.Timestamp
+}
 
-	if strings.HasPrefix(notif.Target, "user:") {
-		username := strings.TrimPrefix(notif.Target, "user:")
-		return client.Username == username
+// shouldReceiveNotification checks if client should receive notification,
+// using the same target rules as routeNotification
+func (h *Hub) shouldReceiveNotification(client *Client, notif *Notification) bool {
+	kind, name, ok := parseTarget(notif.Target)
+	if !ok {
+		return false
 	}
 
-	return false
+	switch kind {
+	case "room":
+		return client.Room == name
+	case "user":
+		return client.Username == name
+	}
+	return true // "all"
 }
 
-// routeNotification routes notification to appropriate clients
-func (h *Hub) routeNotification(notif *Notification) {
+// routeNotification routes notification to appropriate clients and
+// counts the deliveries
+func (h *Hub) routeNotification(notif *Notification) DeliveryResult {
+	var result DeliveryResult
+
 	data, err := json.Marshal(notif)
 	if err != nil {
 		log.Printf("Failed to marshal notification: %v", err)
-		return
+		return result
 	}
 
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	// Route based on target
-	if notif.Target == "all" {
+	// Route based on target (malformed targets reach nobody)
+	kind, name, _ := parseTarget(notif.Target)
+	if kind == "all" {
 		// Broadcast to all clients in all rooms
 		for _, room := range h.rooms {
 			room.mu.RLock()
 			for client := range room.Clients {
 				select {
 				case client.Send <- data:
+					result.Delivered++
 				default:
 					// Skip if channel full
+					result.Skipped++
 				}
 			}
 			room.mu.RUnlock()
 		}
 		log.Printf("Broadcasted notification %s to all users", notif.ID)
 
-	} else if strings.HasPrefix(notif.Target, "room:") {
+	} else if kind == "room" {
 		// Send to specific room
-		roomName := strings.TrimPrefix(notif.Target, "room:")
+		roomName := name
 		room, exists := h.rooms[roomName]
 		if exists {
 			room.mu.RLock()
 			for client := range room.Clients {
 				select {
 				case client.Send <- data:
+					result.Delivered++
 				default:
 					// Skip if channel full
+					result.Skipped++
 				}
 			}
 			room.mu.RUnlock()
 			log.Printf("Sent notification %s to room %s", notif.ID, roomName)
 		}
 
-	} else if strings.HasPrefix(notif.Target, "user:") {
+	} else if kind == "user" {
 		// Send to specific user
-		username := strings.TrimPrefix(notif.Target, "user:")
+		username := name
 		sent := false
 		for _, room := range h.rooms {
 			room.mu.RLock()
@@ -323,8 +565,10 @@ func (h *Hub) routeNotification(notif *Notification) {
 					select {
 					case client.Send <- data:
 						sent = true
+						result.Delivered++
 					default:
 						// Skip if channel full
+						result.Skipped++
 					}
 				}
 			}
@@ -336,6 +580,8 @@ func (h *Hub) routeNotification(notif *Notification) {
 			log.Printf("User %s not found for notification %s", username, notif.ID)
 		}
 	}
+
+	return result
 }
 
 // readPump reads messages from WebSocket connection
@@ -409,8 +655,14 @@ func (c *Client) writePump() {
 	}
 }
 
-// Global hub instance
-var hub = newHub()
+// Global hub instance, created in main once flags are parsed
+var hub *Hub
+
+// Delivers scheduled notifications through the hub when they are due
+var scheduler = newScheduler(func(notif *Notification) {
+	log.Printf("Delivering scheduled notification %s", notif.ID)
+	hub.notificationChan <- notificationRequest{notif: notif}
+})
 
 // handleWebSocket handles WebSocket connection upgrades
 func handleWebSocket(c *gin.Context) {
@@ -472,17 +724,148 @@ func handleNotification(c *gin.Context) {
 	}
 	notif.Timestamp = time.Now()
 
-	// Send notification to hub
-	hub.notificationChan <- &notif
+	// Future SendAt: hold it in the scheduler instead of sending now
+	if notif.SendAt.After(notif.Timestamp) {
+		scheduler.Add(&notif)
+		log.Printf("Scheduled notification: ID=%s, Target=%s, SendAt=%s", notif.ID, notif.Target, notif.SendAt.Format(time.RFC3339))
+		c.JSON(202, gin.H{
+			"status":  "scheduled",
+			"id":      notif.ID,
+			"send_at": notif.SendAt,
+		})
+		return
+	}
+
+	// Send notification to hub and wait for the delivery counts
+	result := sendNotification(&notif)
 
 	log.Printf("Received notification: ID=%s, Type=%s, Target=%s", notif.ID, notif.Type, notif.Target)
 
+	select {
+	case res := <-result:
+		c.JSON(200, gin.H{
+			"status":    "sent",
+			"id":        notif.ID,
+			"delivered": res.Delivered,
+			"skipped":   res.Skipped,
+		})
+	case <-time.After(deliveryTimeout):
+		c.JSON(504, gin.H{"error": "timed out waiting for delivery", "id": notif.ID})
+	}
+}
+
+// How long HTTP handlers wait for the hub to report delivery counts
+const deliveryTimeout = 5 * time.Second
+
+// sendNotification queues a notification on the hub; the returned channel
+// receives its delivery counts once routed
+func sendNotification(notif *Notification) <-chan DeliveryResult {
+	result := make(chan DeliveryResult, 1) // buffered: the hub never waits on us
+	hub.notificationChan <- notificationRequest{notif: notif, result: result}
+	return result
+}
+
+// BatchNotification sends the same content to several targets
+type BatchNotification struct {
+	Targets []string `json:"targets"` // each "all", "room:name" or "user:username"
+	Type    string   `json:"type"`
+	Title   string   `json:"title"`
+	Message string   `json:"message"`
+}
+
+// handleBatchNotification fans one notification out to several targets,
+// each with its own ID, and reports delivery counts per target
+func handleBatchNotification(c *gin.Context) {
+	var batch BatchNotification
+	if err := c.BindJSON(&batch); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Validate batch
+	if batch.Message == "" {
+		c.JSON(400, gin.H{"error": "message is required"})
+		return
+	}
+	if len(batch.Targets) == 0 {
+		c.JSON(400, gin.H{"error": "targets must not be empty"})
+		return
+	}
+	for _, target := range batch.Targets {
+		if _, _, ok := parseTarget(target); !ok {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("invalid target %q (use all, room:<name> or user:<name>)", target)})
+			return
+		}
+	}
+	if batch.Type == "" {
+		batch.Type = "info"
+	}
+
+	// Queue every target first, then collect the counts
+	now := time.Now()
+	notifs := make([]*Notification, len(batch.Targets))
+	results := make([]<-chan DeliveryResult, len(batch.Targets))
+	for i, target := range batch.Targets {
+		notifs[i] = &Notification{
+			ID:        fmt.Sprintf("notif-%d-%d", now.UnixNano(), i),
+			Type:      batch.Type,
+			Title:     batch.Title,
+			Message:   batch.Message,
+			Timestamp: now,
+			Target:    target,
+		}
+		results[i] = sendNotification(notifs[i])
+	}
+
+	log.Printf("Received batch notification: Type=%s, Targets=%v", batch.Type, batch.Targets)
+
+	timeout := time.After(deliveryTimeout)
+	perTarget := make([]gin.H, len(notifs))
+	total := DeliveryResult{}
+	for i, notif := range notifs {
+		select {
+		case res := <-results[i]:
+			perTarget[i] = gin.H{
+				"target":    notif.Target,
+				"id":        notif.ID,
+				"delivered": res.Delivered,
+				"skipped":   res.Skipped,
+			}
+			total.Delivered += res.Delivered
+			total.Skipped += res.Skipped
+		case <-timeout:
+			c.JSON(504, gin.H{"error": "timed out waiting for delivery"})
+			return
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"status":    "sent",
+		"results":   perTarget,
+		"delivered": total.Delivered,
+		"skipped":   total.Skipped,
+	})
+}
+
+// listScheduled returns notifications waiting for their SendAt
+func listScheduled(c *gin.Context) {
+	pending := scheduler.Pending()
 	c.JSON(200, gin.H{
-		"status": "sent",
-		"id":     notif.ID,
+		"scheduled": pending,
+		"count":     len(pending),
 	})
 }
 
+// cancelScheduled cancels a scheduled notification by ID
+func cancelScheduled(c *gin.Context) {
+	id := c.Param("id")
+	if !scheduler.Cancel(id) {
+		c.JSON(404, gin.H{"error": "scheduled notification not found"})
+		return
+	}
+	c.JSON(200, gin.H{"status": "cancelled", "id": id})
+}
+
 // getStats returns current statistics
 func getStats(c *gin.Context) {
 	hub.mu.RLock()
@@ -509,8 +892,14 @@ func getStats(c *gin.Context) {
 }
 
 func main() {
-	// Start hub in background goroutine
+	historyFile := flag.String("history-file", "notifications.json", "file notification history is saved to (empty disables)")
+	flag.Parse()
+
+	hub = newHub(*historyFile)
+
+	// Start hub and scheduler in background goroutines
 	go hub.run()
+	go scheduler.run()
 
 	// Create Gin router
 	router := gin.Default()
@@ -520,7 +909,10 @@ func main() {
 
 	// HTTP API endpoints for admin
 	router.POST("/api/notify", handleNotification)
+	router.POST("/api/notify/batch", handleBatchNotification)
 	router.GET("/api/stats", getStats)
+	router.GET("/api/notifications/scheduled", listScheduled)
+	router.DELETE("/api/notifications/scheduled/:id", cancelScheduled)
 
 	fmt.Println("🚀 Notification Server on :8080")
 	fmt.Println("📱 WebSocket: ws://localhost:8080/ws")
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestClient(username, room string) *Client {
+	return &Client{ID: username, Username: username, Room: room, Send: make(chan []byte, 64)}
+}
+
+// IDs of the notifications queued on client.Send, ignoring chat messages
+func replayedIDs(t *testing.T, client *Client) []string {
+	t.Helper()
+	var ids []string
+	for {
+		select {
+		case data := <-client.Send:
+			var notif Notification
+			if err := json.Unmarshal(data, &notif); err != nil {
+				t.Fatal(err)
+			}
+			if notif.ID != "" {
+				ids = append(ids, notif.ID)
+			}
+		default:
+			sort.Strings(ids)
+			return ids
+		}
+	}
+}
+
+func TestNotificationHistoryReplayRespectsTarget(t *testing.T) {
+	h := newHub("")
+
+	// Both rooms exist before any notification is sent
+	h.addClientToRoom(newTestClient("bob", "B"))
+	alice := newTestClient("alice", "A")
+	h.addClientToRoom(alice)
+	replayedIDs(t, alice) // drop the join message
+
+	sent := time.Now().Add(time.Second)
+	for _, n := range []Notification{
+		{ID: "all", Target: "all"},
+		{ID: "room-a", Target: "room:A"},
+		{ID: "room-b", Target: "room:B"},
+		{ID: "user-alice", Target: "user:alice"},
+		{ID: "user-bob", Target: "user:bob"},
+		{ID: "bad-target", Target: "team:A"},
+	} {
+		n.Timestamp = sent
+		h.addToHistory(&n)
+	}
+	// Sent to room A before the current room A was created: nobody got it live
+	h.addToHistory(&Notification{ID: "room-a-old", Target: "room:A", Timestamp: sent.Add(-time.Hour)})
+
+	h.sendNotificationHistory(alice)
+
+	got := strings.Join(replayedIDs(t, alice), ",")
+	if want := "all,room-a,user-alice"; got != want {
+		t.Errorf("alice in room A got %s, want %s", got, want)
+	}
+}
+
+func TestNotificationHistoryReplayForOtherRoom(t *testing.T) {
+	h := newHub("")
+	h.addToHistory(&Notification{ID: "room-b", Target: "room:B", Timestamp: time.Now()})
+
+	// Joining room A afterwards must not replay the room:B notification
+	alice := newTestClient("alice", "A")
+	h.addClientToRoom(alice)
+	h.sendNotificationHistory(alice)
+
+	if got := replayedIDs(t, alice); len(got) != 0 {
+		t.Errorf("alice in room A got %v, want nothing", got)
+	}
+}
@@ -0,0 +1,50 @@
+// Package origin holds the WebSocket Origin check shared by the Task1-Task5
+// chat servers.
+package origin
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Allowed lists the origins allowed to open WebSockets, from ALLOWED_ORIGINS
+// (comma-separated, e.g. "https://chat.example.com"). Empty means pages
+// served from localhost only.
+var Allowed = Parse(os.Getenv("ALLOWED_ORIGINS"))
+
+// Parse turns a comma-separated origin list into a lowercase set
+func Parse(list string) map[string]bool {
+	origins := make(map[string]bool)
+	for _, origin := range strings.Split(list, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins[strings.ToLower(origin)] = true
+		}
+	}
+	return origins
+}
+
+// Check blocks cross-site WebSocket hijacking from browser pages on other
+// origins; use it as websocket.Upgrader.CheckOrigin. Non-browser clients
+// send no Origin and are allowed.
+func Check(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	var ok bool
+	if len(Allowed) > 0 {
+		ok = Allowed[strings.ToLower(origin)]
+	} else if u, err := url.Parse(origin); err == nil {
+		host := u.Hostname()
+		ok = host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+
+	if !ok {
+		log.Printf("Rejected WebSocket connection from origin %q", origin)
+	}
+	return ok
+}
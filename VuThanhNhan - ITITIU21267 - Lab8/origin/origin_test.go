@@ -0,0 +1,48 @@
+package origin
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	got := Parse(" https://Chat.example.com, ,http://localhost:3000 ")
+	if len(got) != 2 || !got["https://chat.example.com"] || !got["http://localhost:3000"] {
+		t.Errorf("Parse = %v", got)
+	}
+	if len(Parse("")) != 0 {
+		t.Error("Parse(\"\") should be empty")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed string
+		origin  string
+		want    bool
+	}{
+		{"no origin header", "", "", true},
+		{"localhost by default", "", "http://localhost:8080", true},
+		{"loopback IP by default", "", "http://127.0.0.1:8080", true},
+		{"other site by default", "", "https://evil.example.com", false},
+		{"listed origin", "https://chat.example.com", "https://CHAT.example.com", true},
+		{"unlisted origin", "https://chat.example.com", "https://evil.example.com", false},
+		{"localhost once a list is set", "https://chat.example.com", "http://localhost:8080", false},
+	}
+
+	saved := Allowed
+	defer func() { Allowed = saved }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Allowed = Parse(tt.allowed)
+			r := httptest.NewRequest("GET", "/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := Check(r); got != tt.want {
+				t.Errorf("Check with Origin %q = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Package reconnect redials a dropped WebSocket connection with capped
+// exponential backoff, for the Task3 and Task4 chat clients.
+package reconnect
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Backoff is the redial policy: up to Attempts tries, waiting Initial
+// before the first and doubling after each failure up to Max.
+//
+// Attempts add up across Dial calls until Reset, so a connection that is
+// accepted and then refused straight away doesn't restart the count.
+type Backoff struct {
+	Attempts int
+	Initial  time.Duration
+	Max      time.Duration
+
+	mu      sync.Mutex
+	attempt int // attempts since the last Reset
+}
+
+// New returns the clients' policy: 5 attempts, from 1s doubling to 30s
+func New() *Backoff {
+	return &Backoff{Attempts: 5, Initial: time.Second, Max: 30 * time.Second}
+}
+
+// Delay is the wait before the given attempt, counting from 1
+func (b *Backoff) Delay(attempt int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < attempt && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if delay > b.Max {
+		delay = b.Max
+	}
+	return delay
+}
+
+// Dial redials url until it connects. It returns nil when the attempts
+// run out or the user presses Ctrl+C (interrupt) while waiting.
+func (b *Backoff) Dial(url string, interrupt <-chan os.Signal) *websocket.Conn {
+	for {
+		b.mu.Lock()
+		if b.attempt >= b.Attempts {
+			b.mu.Unlock()
+			fmt.Printf("Giving up after %d attempts\n", b.Attempts)
+			return nil
+		}
+		b.attempt++
+		attempt := b.attempt
+		b.mu.Unlock()
+
+		delay := b.Delay(attempt)
+		fmt.Printf("⟳ Reconnecting in %v (attempt %d/%d)...\n", delay, attempt, b.Attempts)
+		select {
+		case <-time.After(delay):
+		case <-interrupt:
+			fmt.Println("\nShutting down...")
+			return nil
+		}
+
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err == nil {
+			fmt.Println("✓ Reconnected")
+			return conn
+		}
+		fmt.Printf("✗ Reconnect failed: %v\n", err)
+	}
+}
+
+// Reset marks the current connection as working (the server let us in),
+// so the next drop starts again from the first attempt
+func (b *Backoff) Reset() {
+	b.mu.Lock()
+	b.attempt = 0
+	b.mu.Unlock()
+}
+
+// Retrying reports whether Dial has redialed since the last Reset, i.e.
+// the current connection hasn't been confirmed yet
+func (b *Backoff) Retrying() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.attempt > 0
+}
+
+// Conn is the client's current connection. It is replaced after a redial
+// while the input goroutine keeps writing, and the mutex also keeps writes
+// from that goroutine and main from overlapping.
+type Conn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// NewConn wraps the first connection
+func NewConn(conn *websocket.Conn) *Conn {
+	return &Conn{conn: conn}
+}
+
+// Get returns the current connection, for reading and closing
+func (c *Conn) Get() *websocket.Conn {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn
+}
+
+// Set replaces the connection after a redial
+func (c *Conn) Set(conn *websocket.Conn) {
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+}
+
+// WriteMessage writes to the current connection
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
@@ -0,0 +1,91 @@
+package reconnect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDelayDoublesUpToMax(t *testing.T) {
+	b := New()
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i, w := range want {
+		if got := b.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := b.Delay(100); got != 30*time.Second {
+		t.Errorf("Delay(100) = %v, want the 30s cap", got)
+	}
+}
+
+func TestDialInterruptedWhileWaiting(t *testing.T) {
+	b := &Backoff{Attempts: 5, Initial: time.Hour, Max: time.Hour}
+	interrupt := make(chan os.Signal, 1)
+	interrupt <- os.Interrupt
+
+	start := time.Now()
+	if conn := b.Dial("ws://127.0.0.1:1/ws", interrupt); conn != nil {
+		conn.Close()
+		t.Fatal("Dial connected after Ctrl+C")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Dial took %v to notice Ctrl+C", elapsed)
+	}
+}
+
+func TestDialGivesUp(t *testing.T) {
+	b := &Backoff{Attempts: 3, Initial: time.Millisecond, Max: time.Millisecond}
+	if conn := b.Dial("ws://127.0.0.1:1/ws", make(chan os.Signal)); conn != nil {
+		conn.Close()
+		t.Fatal("Dial connected to a closed port")
+	}
+	// Used up until Reset
+	if !b.Retrying() {
+		t.Error("Retrying = false after failed attempts")
+	}
+	b.Reset()
+	if b.Retrying() {
+		t.Error("Retrying = true after Reset")
+	}
+}
+
+func TestDialAttemptsAddUpUntilReset(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	b := &Backoff{Attempts: 2, Initial: time.Millisecond, Max: time.Millisecond}
+	for i := 0; i < 2; i++ {
+		conn := b.Dial(url, make(chan os.Signal))
+		if conn == nil {
+			t.Fatalf("Dial %d failed", i+1)
+		}
+		conn.Close()
+		if !b.Retrying() {
+			t.Fatalf("Retrying = false after unconfirmed Dial %d", i+1)
+		}
+	}
+	// Both attempts went to connections that were never confirmed
+	if conn := b.Dial(url, make(chan os.Signal)); conn != nil {
+		conn.Close()
+		t.Fatal("Dial kept going past Attempts")
+	}
+
+	b.Reset()
+	conn := b.Dial(url, make(chan os.Signal))
+	if conn == nil {
+		t.Fatal("Dial after Reset failed")
+	}
+	conn.Close()
+}